@@ -6,6 +6,7 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/oapi-codegen/runtime"
@@ -26,16 +27,182 @@ type AuthRequest struct {
 
 // AuthResponse defines model for AuthResponse.
 type AuthResponse struct {
+	// ExpiresIn Через сколько секунд истекает JWT-токен.
+	ExpiresIn *int `json:"expiresIn,omitempty"`
+
+	// RefreshToken Одноразовый токен для получения новой пары токенов.
+	RefreshToken *string `json:"refreshToken,omitempty"`
+
 	// Token JWT-токен для доступа к защищенным ресурсам.
 	Token *string `json:"token,omitempty"`
 }
 
+// BalanceRequest defines model for BalanceRequest.
+type BalanceRequest struct {
+	// Coins Новый баланс пользователя.
+	Coins int `json:"coins"`
+}
+
+// Cart defines model for Cart.
+type Cart struct {
+	Items []CartItem `json:"items"`
+
+	// Total Стоимость корзины.
+	Total int `json:"total"`
+}
+
+// CartItem defines model for CartItem.
+type CartItem struct {
+	// Id ID позиции корзины.
+	Id int `json:"id"`
+
+	// MerchId ID товара.
+	MerchId int `json:"merchId"`
+
+	// Name Название товара.
+	Name string `json:"name"`
+
+	// Price Цена единицы на момент добавления в корзину.
+	Price int `json:"price"`
+
+	// Quantity Количество.
+	Quantity int `json:"quantity"`
+
+	// Variant Артикул варианта.
+	Variant *string `json:"variant,omitempty"`
+}
+
+// CartItemRequest defines model for CartItemRequest.
+type CartItemRequest struct {
+	// MerchId ID товара.
+	MerchId int `json:"merchId"`
+
+	// Quantity Сколько единиц добавить.
+	Quantity *int `json:"quantity,omitempty"`
+
+	// Variant Артикул варианта. Обязателен, если у товара есть варианты.
+	Variant *string `json:"variant,omitempty"`
+}
+
+// CheckoutRequest defines model for CheckoutRequest.
+type CheckoutRequest struct {
+	// Notes Комментарий к заказу.
+	Notes *string `json:"notes,omitempty"`
+
+	// PickupLocation Где удобно получить заказ.
+	PickupLocation *string `json:"pickupLocation,omitempty"`
+}
+
 // ErrorResponse defines model for ErrorResponse.
 type ErrorResponse struct {
+	// Code Машиночитаемый код ошибки. Общие коды:
+	//   - invalid_request (400) - неверное тело, параметр или заголовок запроса;
+	//   - unauthorized (401) - нет действующего токена;
+	//   - forbidden (403) - не хватает прав;
+	//   - not_found (404) - маршрут не найден;
+	//   - method_not_allowed (405) - метод не поддерживается маршрутом;
+	//   - conflict (409) - конфликт с текущим состоянием;
+	//   - internal_error (500) - внутренняя ошибка сервера, подробности только в логе.
+	// Коды ошибок сервиса:
+	//   - invalid_username, invalid_password (400) - имя или пароль не соответствуют правилам регистрации;
+	//   - self_transfer (400) - перевод самому себе;
+	//   - invalid_transfer_note (400) - неверное сообщение или теги перевода;
+	//   - invalid_history_filter (400) - неверный фильтр или курсор истории;
+	//   - invalid_feed_filter (400) - неверный тег или размер ленты благодарностей;
+	//   - invalid_merch, invalid_variant (400) - неверные данные товара или варианта;
+	//   - invalid_quantity (400) - количество должно быть положительным;
+	//   - variant_required (400) - у товара есть варианты, нужен артикул;
+	//   - cart_empty (400) - корзина пуста;
+	//   - invalid_order, invalid_order_status (400) - неверные данные или статус заказа;
+	//   - invalid_reversal (400) - неверная сумма или причина сторно;
+	//   - unknown_role (400) - неизвестная роль;
+	//   - revoke_own_admin (400) - нельзя отозвать роль admin у себя;
+	//   - invalid_credentials (401) - неверное имя пользователя или пароль;
+	//   - invalid_refresh_token (401) - refresh-токен недействителен;
+	//   - insufficient_funds (402) - на балансе недостаточно монет;
+	//   - registration_forbidden (403) - регистрация требует кода приглашения или адреса в разрешенном домене;
+	//   - user_not_found, merch_not_found, variant_not_found, reservation_not_found, cart_item_not_found, order_not_found, transfer_not_found, purchase_not_found (404) - объект не найден;
+	//   - username_taken, merch_name_taken, variant_sku_taken (409) - имя или артикул уже заняты;
+	//   - sold_out (409) - товар раскуплен;
+	//   - cart_stale (409) - цены в корзине изменились;
+	//   - order_transition_not_allowed, order_status_changed, order_not_cancellable (409) - заказ нельзя перевести в этот статус;
+	//   - already_reversed, reversal_exceeds_amount, purchase_not_refundable (409) - операцию нельзя сторнировать;
+	//   - idempotency_key_reused, idempotency_key_in_progress (409) - ключ идемпотентности использован для другого запроса или запрос с ним еще выполняется;
+	//   - limit_exceeded (422) - перевод нарушает лимит, подробности в details.
+	Code string `json:"code"`
+
+	// Details Подробности ошибки. Для limit_exceeded - limit (название лимита - min_amount, max_amount, daily_outgoing, weekly_outgoing, daily_incoming, weekly_incoming или daily_recipients), value (значение лимита), used (сколько уже израсходовано за период) и resetsAt (когда лимит обновится; нет для лимитов суммы одного перевода).
+	Details *map[string]interface{} `json:"details,omitempty"`
+
 	// Errors Сообщение об ошибке, описывающее проблему.
 	Errors *string `json:"errors,omitempty"`
 }
 
+// HealthCheck defines model for HealthCheck.
+type HealthCheck struct {
+	// Details Подробности проверки, например текущая и ожидаемая версии миграций или состояние пула.
+	Details *map[string]interface{} `json:"details,omitempty"`
+
+	// DurationMs Длительность проверки в миллисекундах.
+	DurationMs float64 `json:"durationMs"`
+
+	// Error Почему проверка не пройдена.
+	Error *string `json:"error,omitempty"`
+
+	// Name Название проверки - lifecycle, database, migrations или pool.
+	Name string `json:"name"`
+
+	// Status ok или fail.
+	Status string `json:"status"`
+}
+
+// HealthResponse defines model for HealthResponse.
+type HealthResponse struct {
+	// Status Всегда ok.
+	Status string `json:"status"`
+}
+
+// HistoryItem defines model for HistoryItem.
+type HistoryItem struct {
+	// Amount Сумма операции.
+	Amount int `json:"amount"`
+
+	// Counterparty Второй участник перевода.
+	Counterparty *string `json:"counterparty,omitempty"`
+
+	// CreatedAt Время операции.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Direction incoming - монеты получены, outgoing - списаны.
+	Direction string `json:"direction"`
+
+	// Id ID перевода или покупки.
+	Id int `json:"id"`
+
+	// Item Купленный товар.
+	Item *string `json:"item,omitempty"`
+
+	// Message Сообщение к переводу.
+	Message *string `json:"message,omitempty"`
+
+	// Tags Теги перевода.
+	Tags *[]string `json:"tags,omitempty"`
+
+	// Type Операция - transfer (перевод) или purchase (покупка).
+	Type string `json:"type"`
+
+	// Variant Артикул купленного варианта товара.
+	Variant *string `json:"variant,omitempty"`
+}
+
+// HistoryPage defines model for HistoryPage.
+type HistoryPage struct {
+	Items []HistoryItem `json:"items"`
+
+	// NextCursor Курсор следующей страницы. Отсутствует на последней странице.
+	NextCursor *string `json:"nextCursor,omitempty"`
+}
+
 // InfoResponse defines model for InfoResponse.
 type InfoResponse struct {
 	CoinHistory *struct {
@@ -43,13 +210,40 @@ type InfoResponse struct {
 			// Amount Количество полученных монет.
 			Amount *int `json:"amount,omitempty"`
 
+			// CreatedAt Время перевода.
+			CreatedAt *time.Time `json:"createdAt,omitempty"`
+
 			// FromUser Имя пользователя, который отправил монеты.
 			FromUser *string `json:"fromUser,omitempty"`
+
+			// Id ID перевода.
+			Id *int `json:"id,omitempty"`
+
+			// Message Сообщение отправителя.
+			Message *string `json:"message,omitempty"`
+
+			// Tags Теги перевода.
+			Tags *[]string `json:"tags,omitempty"`
 		} `json:"received,omitempty"`
-		Sent *[]struct {
+
+		// Reversals Сторно переводов и возвраты покупок, изменившие баланс пользователя.
+		Reversals *[]Reversal `json:"reversals,omitempty"`
+		Sent      *[]struct {
 			// Amount Количество отправленных монет.
 			Amount *int `json:"amount,omitempty"`
 
+			// CreatedAt Время перевода.
+			CreatedAt *time.Time `json:"createdAt,omitempty"`
+
+			// Id ID перевода.
+			Id *int `json:"id,omitempty"`
+
+			// Message Сообщение отправителя.
+			Message *string `json:"message,omitempty"`
+
+			// Tags Теги перевода.
+			Tags *[]string `json:"tags,omitempty"`
+
 			// ToUser Имя пользователя, которому отправлены монеты.
 			ToUser *string `json:"toUser,omitempty"`
 		} `json:"sent,omitempty"`
@@ -63,38 +257,675 @@ type InfoResponse struct {
 
 		// Type Тип предмета.
 		Type *string `json:"type,omitempty"`
+
+		// Variant Артикул варианта предмета, если у товара есть варианты.
+		Variant *string `json:"variant,omitempty"`
 	} `json:"inventory,omitempty"`
 }
 
+// JWK defines model for JWK.
+type JWK struct {
+	// Alg Алгоритм подписи (RS256 или EdDSA).
+	Alg string `json:"alg"`
+
+	// Crv Кривая OKP-ключа (Ed25519).
+	Crv *string `json:"crv,omitempty"`
+
+	// E Открытая экспонента RSA в base64url.
+	E *string `json:"e,omitempty"`
+
+	// Kid ID ключа, совпадает с заголовком kid токена.
+	Kid string `json:"kid"`
+
+	// Kty Тип ключа (RSA или OKP).
+	Kty string `json:"kty"`
+
+	// N Модуль RSA в base64url.
+	N *string `json:"n,omitempty"`
+
+	// Use Назначение ключа.
+	Use string `json:"use"`
+
+	// X Открытый ключ Ed25519 в base64url.
+	X *string `json:"x,omitempty"`
+}
+
+// JWKSResponse defines model for JWKSResponse.
+type JWKSResponse struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LedgerAuditResponse defines model for LedgerAuditResponse.
+type LedgerAuditResponse struct {
+	// Consistent Сходится ли журнал.
+	Consistent bool `json:"consistent"`
+
+	// Discrepancies Количество счетов, баланс которых расходится с проводками.
+	Discrepancies int `json:"discrepancies"`
+
+	// Issued Сколько монет выпущено.
+	Issued int64 `json:"issued"`
+
+	// ShopBalance Сколько монет потрачено в магазине.
+	ShopBalance int64 `json:"shopBalance"`
+
+	// UnbalancedEntries Количество записей, проводки которых не сходятся в ноль.
+	UnbalancedEntries int `json:"unbalancedEntries"`
+
+	// UserBalances Сколько монет на счетах пользователей.
+	UserBalances int64 `json:"userBalances"`
+}
+
+// LogoutRequest defines model for LogoutRequest.
+type LogoutRequest struct {
+	// AllSessions Завершить все сеансы пользователя.
+	AllSessions *bool `json:"allSessions,omitempty"`
+
+	// RefreshToken Refresh-токен сеанса, который нужно завершить.
+	RefreshToken *string `json:"refreshToken,omitempty"`
+}
+
+// LowStockItem defines model for LowStockItem.
+type LowStockItem struct {
+	// Id ID товара.
+	Id int `json:"id"`
+
+	// Name Название товара.
+	Name string `json:"name"`
+
+	// Price Цена товара или варианта в монетах.
+	Price int `json:"price"`
+
+	// Sku Артикул варианта. Отсутствует, если остаток ведется у самого товара.
+	Sku *string `json:"sku,omitempty"`
+
+	// Stock Остаток на складе.
+	Stock int `json:"stock"`
+}
+
+// MerchAuditEntry defines model for MerchAuditEntry.
+type MerchAuditEntry struct {
+	// Action Действие - create, update, deactivate, activate или restock.
+	Action string `json:"action"`
+
+	// Active Продается ли товар после изменения.
+	Active bool `json:"active"`
+
+	// Actor Администратор, изменивший товар.
+	Actor *string `json:"actor,omitempty"`
+
+	// ChangedAt Время изменения.
+	ChangedAt time.Time `json:"changedAt"`
+
+	// Name Название после изменения.
+	Name string `json:"name"`
+
+	// Price Цена после изменения.
+	Price int `json:"price"`
+
+	// Stock Остаток после изменения, если он учитывается.
+	Stock *int `json:"stock,omitempty"`
+
+	// Variant Артикул варианта, если изменение относится к варианту.
+	Variant *string `json:"variant,omitempty"`
+}
+
+// MerchItem defines model for MerchItem.
+type MerchItem struct {
+	// Active Продается ли товар.
+	Active bool `json:"active"`
+
+	// Id ID товара.
+	Id int `json:"id"`
+
+	// Name Название товара.
+	Name string `json:"name"`
+
+	// Price Цена в монетах.
+	Price int `json:"price"`
+
+	// Stock Остаток на складе. Отсутствует, если остаток товара не учитывается.
+	Stock *int `json:"stock,omitempty"`
+}
+
+// MerchRequest defines model for MerchRequest.
+type MerchRequest struct {
+	// Name Название товара.
+	Name string `json:"name"`
+
+	// Price Цена в монетах.
+	Price int `json:"price"`
+}
+
+// MerchUpdateRequest defines model for MerchUpdateRequest.
+type MerchUpdateRequest struct {
+	// Active Вернуть товар в продажу (true) или снять с продажи (false).
+	Active *bool `json:"active,omitempty"`
+
+	// Name Новое название товара.
+	Name *string `json:"name,omitempty"`
+
+	// Price Новая цена в монетах.
+	Price *int `json:"price,omitempty"`
+}
+
+// MerchVariant defines model for MerchVariant.
+type MerchVariant struct {
+	// Active Продается ли вариант.
+	Active bool `json:"active"`
+
+	// Color Цвет.
+	Color *string `json:"color,omitempty"`
+
+	// Id ID варианта.
+	Id int `json:"id"`
+
+	// Price Цена варианта. Отсутствует, если действует цена товара.
+	Price *int `json:"price,omitempty"`
+
+	// Size Размер.
+	Size *string `json:"size,omitempty"`
+
+	// Sku Артикул варианта.
+	Sku string `json:"sku"`
+
+	// Stock Остаток на складе. Отсутствует, если остаток варианта не учитывается.
+	Stock *int `json:"stock,omitempty"`
+}
+
+// MerchVariantRequest defines model for MerchVariantRequest.
+type MerchVariantRequest struct {
+	// Color Цвет.
+	Color *string `json:"color,omitempty"`
+
+	// Price Цена варианта, если она отличается от цены товара.
+	Price *int `json:"price,omitempty"`
+
+	// Size Размер.
+	Size *string `json:"size,omitempty"`
+
+	// Sku Артикул варианта - латинские буквы, цифры, '.', '_', '-'.
+	Sku string `json:"sku"`
+
+	// Stock Начальный остаток. Без него остаток варианта не учитывается.
+	Stock *int `json:"stock,omitempty"`
+}
+
+// MerchVariantUpdateRequest defines model for MerchVariantUpdateRequest.
+type MerchVariantUpdateRequest struct {
+	// Active Вернуть вариант в продажу (true) или снять с продажи (false).
+	Active *bool `json:"active,omitempty"`
+
+	// Price Новая цена варианта.
+	Price *int `json:"price,omitempty"`
+}
+
+// Order defines model for Order.
+type Order struct {
+	// CreatedAt Время оформления.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// History История статусов (только при запросе одного заказа).
+	History *[]OrderEvent `json:"history,omitempty"`
+
+	// Id ID заказа.
+	Id int `json:"id"`
+
+	// Items Состав заказа (только при запросе одного заказа).
+	Items *[]OrderItem `json:"items,omitempty"`
+
+	// Notes Комментарий покупателя.
+	Notes *string `json:"notes,omitempty"`
+
+	// PickupLocation Место получения.
+	PickupLocation *string `json:"pickupLocation,omitempty"`
+
+	// Status Статус заказа - placed, approved, ready_for_pickup, delivered или cancelled.
+	Status string `json:"status"`
+
+	// Total Списанная сумма.
+	Total int `json:"total"`
+
+	// UpdatedAt Время последней смены статуса.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// OrderEvent defines model for OrderEvent.
+type OrderEvent struct {
+	// Actor Кто изменил статус.
+	Actor *string `json:"actor,omitempty"`
+
+	// CreatedAt Время смены статуса.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Note Заметка к смене статуса.
+	Note *string `json:"note,omitempty"`
+
+	// Status Статус, в который перешел заказ.
+	Status string `json:"status"`
+}
+
+// OrderItem defines model for OrderItem.
+type OrderItem struct {
+	// MerchId ID товара.
+	MerchId int `json:"merchId"`
+
+	// Name Название товара.
+	Name string `json:"name"`
+
+	// Price Цена единицы при покупке.
+	Price int `json:"price"`
+
+	// Quantity Количество.
+	Quantity int `json:"quantity"`
+
+	// Variant Артикул варианта.
+	Variant *string `json:"variant,omitempty"`
+}
+
+// OrderStatusRequest defines model for OrderStatusRequest.
+type OrderStatusRequest struct {
+	// Note Заметка к смене статуса.
+	Note *string `json:"note,omitempty"`
+
+	// PickupLocation Новое место получения, например при переходе в ready_for_pickup.
+	PickupLocation *string `json:"pickupLocation,omitempty"`
+
+	// Status Новый статус - approved, ready_for_pickup, delivered или cancelled.
+	Status string `json:"status"`
+}
+
+// ReadinessResponse defines model for ReadinessResponse.
+type ReadinessResponse struct {
+	Checks []HealthCheck `json:"checks"`
+
+	// Status ok, если пройдены все проверки, иначе fail.
+	Status string `json:"status"`
+}
+
+// Recognition defines model for Recognition.
+type Recognition struct {
+	// Amount Сумма перевода.
+	Amount int `json:"amount"`
+
+	// CreatedAt Время перевода.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// FromUser Отправитель.
+	FromUser string `json:"fromUser"`
+
+	// Id ID перевода.
+	Id int `json:"id"`
+
+	// Message Сообщение отправителя.
+	Message *string `json:"message,omitempty"`
+
+	// Tags Теги перевода.
+	Tags []string `json:"tags"`
+
+	// ToUser Получатель.
+	ToUser string `json:"toUser"`
+}
+
+// RefreshRequest defines model for RefreshRequest.
+type RefreshRequest struct {
+	// RefreshToken Refresh-токен, полученный при входе или предыдущем обмене.
+	RefreshToken string `json:"refreshToken"`
+}
+
+// RegisterRequest defines model for RegisterRequest.
+type RegisterRequest struct {
+	// InviteCode Код приглашения, если регистрация доступна только по приглашениям.
+	InviteCode *string `json:"inviteCode,omitempty"`
+
+	// Password Пароль.
+	Password string `json:"password"`
+
+	// Username Имя пользователя - латинские буквы, цифры, '.', '_', '-' и необязательный @домен.
+	Username string `json:"username"`
+}
+
+// Reservation defines model for Reservation.
+type Reservation struct {
+	// ExpiresAt Когда резерв истечет и товар вернется на склад.
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// Id ID резерва, передается в /api/buy/{item}?reservation=.
+	Id int `json:"id"`
+
+	// MerchId ID товара.
+	MerchId int `json:"merchId"`
+
+	// Variant Артикул зарезервированного варианта.
+	Variant *string `json:"variant,omitempty"`
+}
+
+// ReservationRequest defines model for ReservationRequest.
+type ReservationRequest struct {
+	// MerchId ID товара.
+	MerchId int `json:"merchId"`
+
+	// Variant Артикул варианта. Обязателен, если у товара есть варианты.
+	Variant *string `json:"variant,omitempty"`
+}
+
+// RestockRequest defines model for RestockRequest.
+type RestockRequest struct {
+	// Quantity Сколько единиц добавить на склад.
+	Quantity int `json:"quantity"`
+}
+
+// Reversal defines model for Reversal.
+type Reversal struct {
+	// Amount Сумма сторно. В истории /api/info - изменение баланса пользователя, отрицательное, если монеты списаны.
+	Amount int `json:"amount"`
+
+	// CreatedAt Время сторно.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Id ID сторно.
+	Id int `json:"id"`
+
+	// OriginalId ID перевода или покупки.
+	OriginalId int `json:"originalId"`
+
+	// Reason Причина сторно.
+	Reason string `json:"reason"`
+
+	// Type Что сторнировано - transfer (перевод) или purchase (покупка).
+	Type string `json:"type"`
+}
+
+// ReversalRequest defines model for ReversalRequest.
+type ReversalRequest struct {
+	// Amount Сколько монет вернуть. По умолчанию - весь несторнированный остаток операции.
+	Amount *int `json:"amount,omitempty"`
+
+	// Reason Причина сторно.
+	Reason string `json:"reason"`
+}
+
+// RolesResponse defines model for RolesResponse.
+type RolesResponse struct {
+	// Roles Роли пользователя.
+	Roles []string `json:"roles"`
+
+	// Username Имя пользователя.
+	Username string `json:"username"`
+}
+
 // SendCoinRequest defines model for SendCoinRequest.
 type SendCoinRequest struct {
 	// Amount Количество монет, которые необходимо отправить.
 	Amount int `json:"amount"`
 
+	// Message Необязательное сообщение получателю, до 280 символов. Управляющие символы удаляются.
+	Message *string `json:"message,omitempty"`
+
+	// Public Показать перевод в ленте благодарностей /api/feed. По умолчанию перевод виден только участникам.
+	Public *bool `json:"public,omitempty"`
+
+	// Tags Необязательные теги, например ценности компании - до 5 тегов из строчных латинских букв, цифр, '-' и '_', до 32 символов.
+	Tags *[]string `json:"tags,omitempty"`
+
 	// ToUser Имя пользователя, которому нужно отправить монеты.
 	ToUser string `json:"toUser"`
 }
 
+// GetApiAdminOrdersParams defines parameters for GetApiAdminOrders.
+type GetApiAdminOrdersParams struct {
+	// Status Показать только заказы в этом статусе - placed, approved, ready_for_pickup, delivered или cancelled.
+	Status *string `form:"status,omitempty" json:"status,omitempty"`
+}
+
+// GetApiBuyItemParams defines parameters for GetApiBuyItem.
+type GetApiBuyItemParams struct {
+	// Variant Артикул варианта. Обязателен, если у товара есть варианты.
+	Variant *string `form:"variant,omitempty" json:"variant,omitempty"`
+
+	// Reservation ID резерва, созданного через /api/reservations. Остаток уже списан при резервировании.
+	Reservation *int `form:"reservation,omitempty" json:"reservation,omitempty"`
+
+	// IdempotencyKey Ключ идемпотентности. Повторный запрос с тем же ключом вернет сохраненный ответ, а не выполнит покупку еще раз.
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// PostApiCartCheckoutParams defines parameters for PostApiCartCheckout.
+type PostApiCartCheckoutParams struct {
+	// IdempotencyKey Ключ идемпотентности. Повторный запрос с тем же ключом вернет сохраненный ответ, а не оформит заказ еще раз.
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// GetApiFeedParams defines parameters for GetApiFeed.
+type GetApiFeedParams struct {
+	// Tag Только переводы с этим тегом.
+	Tag *string `form:"tag,omitempty" json:"tag,omitempty"`
+
+	// Limit Количество записей, от 1 до 100. По умолчанию 20.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// GetApiHistoryParams defines parameters for GetApiHistory.
+type GetApiHistoryParams struct {
+	// Type Только переводы (transfer) или только покупки (purchase).
+	Type *string `form:"type,omitempty" json:"type,omitempty"`
+
+	// Direction Только полученные (incoming) или только списанные (outgoing) монеты. Покупки всегда outgoing.
+	Direction *string `form:"direction,omitempty" json:"direction,omitempty"`
+
+	// Counterparty Только переводы с этим пользователем.
+	Counterparty *string `form:"counterparty,omitempty" json:"counterparty,omitempty"`
+
+	// MinAmount Наименьшая сумма операции включительно.
+	MinAmount *int `form:"minAmount,omitempty" json:"minAmount,omitempty"`
+
+	// MaxAmount Наибольшая сумма операции включительно.
+	MaxAmount *int `form:"maxAmount,omitempty" json:"maxAmount,omitempty"`
+
+	// From Начало периода включительно.
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To Конец периода, не включая его.
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Limit Размер страницы, от 1 до 100. По умолчанию 20.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor Курсор следующей страницы из поля nextCursor предыдущего ответа.
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+}
+
+// PostApiSendCoinParams defines parameters for PostApiSendCoin.
+type PostApiSendCoinParams struct {
+	// IdempotencyKey Ключ идемпотентности. Повторный запрос с тем же ключом вернет сохраненный ответ, а не выполнит операцию еще раз.
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// PostApiAdminMerchJSONRequestBody defines body for PostApiAdminMerch for application/json ContentType.
+type PostApiAdminMerchJSONRequestBody = MerchRequest
+
+// PatchApiAdminMerchIdJSONRequestBody defines body for PatchApiAdminMerchId for application/json ContentType.
+type PatchApiAdminMerchIdJSONRequestBody = MerchUpdateRequest
+
+// PostApiAdminMerchIdRestockJSONRequestBody defines body for PostApiAdminMerchIdRestock for application/json ContentType.
+type PostApiAdminMerchIdRestockJSONRequestBody = RestockRequest
+
+// PostApiAdminMerchIdVariantsJSONRequestBody defines body for PostApiAdminMerchIdVariants for application/json ContentType.
+type PostApiAdminMerchIdVariantsJSONRequestBody = MerchVariantRequest
+
+// PatchApiAdminMerchIdVariantsSkuJSONRequestBody defines body for PatchApiAdminMerchIdVariantsSku for application/json ContentType.
+type PatchApiAdminMerchIdVariantsSkuJSONRequestBody = MerchVariantUpdateRequest
+
+// PostApiAdminMerchIdVariantsSkuRestockJSONRequestBody defines body for PostApiAdminMerchIdVariantsSkuRestock for application/json ContentType.
+type PostApiAdminMerchIdVariantsSkuRestockJSONRequestBody = RestockRequest
+
+// PatchApiAdminOrdersIdJSONRequestBody defines body for PatchApiAdminOrdersId for application/json ContentType.
+type PatchApiAdminOrdersIdJSONRequestBody = OrderStatusRequest
+
+// PostApiAdminPurchasesIdRefundJSONRequestBody defines body for PostApiAdminPurchasesIdRefund for application/json ContentType.
+type PostApiAdminPurchasesIdRefundJSONRequestBody = ReversalRequest
+
+// PostApiAdminTransactionsIdReverseJSONRequestBody defines body for PostApiAdminTransactionsIdReverse for application/json ContentType.
+type PostApiAdminTransactionsIdReverseJSONRequestBody = ReversalRequest
+
+// PutApiAdminUsersUsernameBalanceJSONRequestBody defines body for PutApiAdminUsersUsernameBalance for application/json ContentType.
+type PutApiAdminUsersUsernameBalanceJSONRequestBody = BalanceRequest
+
 // PostApiAuthJSONRequestBody defines body for PostApiAuth for application/json ContentType.
 type PostApiAuthJSONRequestBody = AuthRequest
 
+// PostApiAuthRefreshJSONRequestBody defines body for PostApiAuthRefresh for application/json ContentType.
+type PostApiAuthRefreshJSONRequestBody = RefreshRequest
+
+// PostApiCartJSONRequestBody defines body for PostApiCart for application/json ContentType.
+type PostApiCartJSONRequestBody = CartItemRequest
+
+// PostApiCartCheckoutJSONRequestBody defines body for PostApiCartCheckout for application/json ContentType.
+type PostApiCartCheckoutJSONRequestBody = CheckoutRequest
+
+// PostApiLogoutJSONRequestBody defines body for PostApiLogout for application/json ContentType.
+type PostApiLogoutJSONRequestBody = LogoutRequest
+
+// PostApiRegisterJSONRequestBody defines body for PostApiRegister for application/json ContentType.
+type PostApiRegisterJSONRequestBody = RegisterRequest
+
+// PostApiReservationsJSONRequestBody defines body for PostApiReservations for application/json ContentType.
+type PostApiReservationsJSONRequestBody = ReservationRequest
+
 // PostApiSendCoinJSONRequestBody defines body for PostApiSendCoin for application/json ContentType.
 type PostApiSendCoinJSONRequestBody = SendCoinRequest
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
-	// Аутентификация и получение JWT-токена. При первой аутентификации пользователь создается автоматически.
+	// Открытые ключи для проверки JWT-токенов сервиса (JWK Set). Секреты HS256 не публикуются.
+	// (GET /.well-known/jwks.json)
+	GetWellKnownJWKSJSON(ctx echo.Context) error
+	// Сверка журнала проводок с балансами (право ledger:audit).
+	// (GET /api/admin/ledger/audit)
+	GetAPIAdminLedgerAudit(ctx echo.Context) error
+	// Каталог мерча, включая снятые с продажи товары (право merch:manage).
+	// (GET /api/admin/merch)
+	GetAPIAdminMerch(ctx echo.Context) error
+	// Добавить товар в каталог (право merch:manage).
+	// (POST /api/admin/merch)
+	PostAPIAdminMerch(ctx echo.Context) error
+	// Товары в продаже, остаток которых не больше порога LOW_STOCK_THRESHOLD (право merch:manage).
+	// (GET /api/admin/merch/low-stock)
+	GetAPIAdminMerchLowStock(ctx echo.Context) error
+	// Снять товар с продажи (право merch:manage). Товар остается в каталоге и в истории покупок.
+	// (DELETE /api/admin/merch/{id})
+	DeleteAPIAdminMerchID(ctx echo.Context, id int) error
+	// Изменить название, цену или вернуть товар в продажу (право merch:manage).
+	// (PATCH /api/admin/merch/{id})
+	PatchAPIAdminMerchID(ctx echo.Context, id int) error
+	// Журнал изменений товара (право merch:manage).
+	// (GET /api/admin/merch/{id}/audit)
+	GetAPIAdminMerchIDAudit(ctx echo.Context, id int) error
+	// Пополнить склад (право merch:manage). Товар без учета остатка начинает учитываться с переданного количества.
+	// (POST /api/admin/merch/{id}/restock)
+	PostAPIAdminMerchIDRestock(ctx echo.Context, id int) error
+	// Варианты товара, включая снятые с продажи (право merch:manage).
+	// (GET /api/admin/merch/{id}/variants)
+	GetAPIAdminMerchIDVariants(ctx echo.Context, id int) error
+	// Добавить вариант товара (право merch:manage). Товар с вариантами покупается только с указанием варианта.
+	// (POST /api/admin/merch/{id}/variants)
+	PostAPIAdminMerchIDVariants(ctx echo.Context, id int) error
+	// Изменить цену варианта, снять его с продажи или вернуть в продажу (право merch:manage).
+	// (PATCH /api/admin/merch/{id}/variants/{sku})
+	PatchAPIAdminMerchIDVariantsSku(ctx echo.Context, id int, sku string) error
+	// Пополнить склад варианта (право merch:manage).
+	// (POST /api/admin/merch/{id}/variants/{sku}/restock)
+	PostAPIAdminMerchIDVariantsSkuRestock(ctx echo.Context, id int, sku string) error
+	// Заказы для выдачи, от старых к новым (право orders:fulfill).
+	// (GET /api/admin/orders)
+	GetAPIAdminOrders(ctx echo.Context, params GetApiAdminOrdersParams) error
+	// Заказ с составом и историей статусов (право orders:fulfill).
+	// (GET /api/admin/orders/{id})
+	GetAPIAdminOrdersID(ctx echo.Context, id int) error
+	// Перевести заказ в следующий статус (право orders:fulfill) - placed, approved, ready_for_pickup, delivered. Невыданный заказ можно отменить, монеты вернутся покупателю, товары - на склад.
+	// (PATCH /api/admin/orders/{id})
+	PatchAPIAdminOrdersID(ctx echo.Context, id int) error
+	// Вернуть монеты за покупку полностью или частично (право refunds:issue). Полностью возвращенная покупка пропадает из инвентаря, товар возвращается на склад.
+	// (POST /api/admin/purchases/{id}/refund)
+	PostAPIAdminPurchasesIDRefund(ctx echo.Context, id int) error
+	// Сторнировать перевод полностью или частично (право refunds:issue). Монеты возвращаются отправителю со счета получателя.
+	// (POST /api/admin/transactions/{id}/reverse)
+	PostAPIAdminTransactionsIDReverse(ctx echo.Context, id int) error
+	// Установить баланс пользователя корректирующей проводкой (право balance:adjust).
+	// (PUT /api/admin/users/{username}/balance)
+	PutAPIAdminUsersUsernameBalance(ctx echo.Context, username string) error
+	// Роли пользователя (право users:read).
+	// (GET /api/admin/users/{username}/roles)
+	GetAPIAdminUsersUsernameRoles(ctx echo.Context, username string) error
+	// Отозвать роль у пользователя (право roles:manage). Ранее выпущенные токены пользователя отзываются.
+	// (DELETE /api/admin/users/{username}/roles/{role})
+	DeleteAPIAdminUsersUsernameRolesRole(ctx echo.Context, username string, role string) error
+	// Выдать роль пользователю (право roles:manage). Ранее выпущенные токены пользователя отзываются.
+	// (PUT /api/admin/users/{username}/roles/{role})
+	PutAPIAdminUsersUsernameRolesRole(ctx echo.Context, username string, role string) error
+	// Аутентификация и получение JWT-токена. Новые пользователи регистрируются через /api/register.
 	// (POST /api/auth)
 	PostAPIAuth(ctx echo.Context) error
+	// Обмен refresh-токена на новую пару токенов. Каждый refresh-токен одноразовый, повторное использование отзывает все токены сеанса.
+	// (POST /api/auth/refresh)
+	PostAPIAuthRefresh(ctx echo.Context) error
 	// Купить предмет за монеты.
 	// (GET /api/buy/{item})
-	GetAPIBuyItem(ctx echo.Context, item string) error
-	// Получить информацию о монетах, инвентаре и истории транзакций.
+	GetAPIBuyItem(ctx echo.Context, item string, params GetApiBuyItemParams) error
+	// Корзина пользователя с ценами на момент добавления товаров.
+	// (GET /api/cart)
+	GetAPICart(ctx echo.Context) error
+	// Добавить товар в корзину по текущей цене. Повторное добавление увеличивает количество.
+	// (POST /api/cart)
+	PostAPICart(ctx echo.Context) error
+	// Оформить заказ. Остатки, оплата всей корзины и покупки записываются одной транзакцией, после оформления корзина очищается.
+	// (POST /api/cart/checkout)
+	PostAPICartCheckout(ctx echo.Context, params PostApiCartCheckoutParams) error
+	// Удалить позицию из корзины.
+	// (DELETE /api/cart/{id})
+	DeleteAPICartID(ctx echo.Context, id int) error
+	// Лента благодарностей - последние открытые переводы компании, новые первыми.
+	// (GET /api/feed)
+	GetAPIFeed(ctx echo.Context, params GetApiFeedParams) error
+	// История переводов и покупок пользователя постранично, от новых к старым.
+	// (GET /api/history)
+	GetAPIHistory(ctx echo.Context, params GetApiHistoryParams) error
+	// Получить информацию о монетах, инвентаре и последних переводах. Полная история - в /api/history.
 	// (GET /api/info)
 	GetAPIInfo(ctx echo.Context) error
+	// Выход. Отзывает текущий access-токен, сеанс переданного refresh-токена или все сеансы пользователя.
+	// (POST /api/logout)
+	PostAPILogout(ctx echo.Context) error
+	// Заказы пользователя со статусами, новые первыми.
+	// (GET /api/orders)
+	GetAPIOrders(ctx echo.Context) error
+	// Заказ пользователя с составом и историей статусов.
+	// (GET /api/orders/{id})
+	GetAPIOrdersID(ctx echo.Context, id int) error
+	// Отменить свой заказ, пока его не одобрили. Монеты возвращаются на баланс, товары - на склад.
+	// (POST /api/orders/{id}/cancel)
+	PostAPIOrdersIDCancel(ctx echo.Context, id int) error
+	// Регистрация нового пользователя и получение JWT-токена.
+	// (POST /api/register)
+	PostAPIRegister(ctx echo.Context) error
+	// Зарезервировать единицу товара на время подтверждения покупки. Резерв сразу списывает остаток и возвращает его при отмене или истечении.
+	// (POST /api/reservations)
+	PostAPIReservations(ctx echo.Context) error
+	// Отменить резерв и вернуть товар на склад.
+	// (DELETE /api/reservations/{id})
+	DeleteAPIReservationsID(ctx echo.Context, id int) error
 	// Отправить монеты другому пользователю.
 	// (POST /api/sendCoin)
-	PostAPISendCoin(ctx echo.Context) error
+	PostAPISendCoin(ctx echo.Context, params PostApiSendCoinParams) error
+	// Процесс жив. Зависимости не проверяются.
+	// (GET /healthz)
+	GetHealthz(ctx echo.Context) error
+	// Метрики в формате Prometheus - HTTP-запросы, переводы, покупки, пул подключений к БД и монеты в обращении.
+	// (GET /metrics)
+	GetMetrics(ctx echo.Context) error
+	// Готовность принимать запросы - БД отвечает, применены все миграции, в пуле есть свободные подключения, сервис не останавливается.
+	// (GET /readyz)
+	GetReadyz(ctx echo.Context) error
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -102,54 +933,802 @@ type ServerInterfaceWrapper struct {
 	Handler ServerInterface
 }
 
-// PostApiAuth converts echo context to params.
-func (w *ServerInterfaceWrapper) PostApiAuth(ctx echo.Context) error {
+// GetWellKnownJwksJson converts echo context to params.
+func (w *ServerInterfaceWrapper) GetWellKnownJwksJson(ctx echo.Context) error {
 	var err error
 
-	ctx.Set(BearerAuthScopes, []string{})
-
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.PostAPIAuth(ctx)
+	err = w.Handler.GetWellKnownJWKSJSON(ctx)
 	return err
 }
 
-// GetApiBuyItem converts echo context to params.
-func (w *ServerInterfaceWrapper) GetApiBuyItem(ctx echo.Context) error {
+// GetApiAdminLedgerAudit converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiAdminLedgerAudit(ctx echo.Context) error {
 	var err error
-	// ------------- Path parameter "item" -------------
-	var item string
-
-	err = runtime.BindStyledParameterWithLocation("simple", false, "item", runtime.ParamLocationPath, ctx.Param("item"), &item)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter item: %s", err))
-	}
 
 	ctx.Set(BearerAuthScopes, []string{})
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetAPIBuyItem(ctx, item)
+	err = w.Handler.GetAPIAdminLedgerAudit(ctx)
 	return err
 }
 
-// GetApiInfo converts echo context to params.
-func (w *ServerInterfaceWrapper) GetApiInfo(ctx echo.Context) error {
+// GetApiAdminMerch converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiAdminMerch(ctx echo.Context) error {
 	var err error
 
 	ctx.Set(BearerAuthScopes, []string{})
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetAPIInfo(ctx)
+	err = w.Handler.GetAPIAdminMerch(ctx)
 	return err
 }
 
-// PostApiSendCoin converts echo context to params.
-func (w *ServerInterfaceWrapper) PostApiSendCoin(ctx echo.Context) error {
+// PostApiAdminMerch converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiAdminMerch(ctx echo.Context) error {
 	var err error
 
 	ctx.Set(BearerAuthScopes, []string{})
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.PostAPISendCoin(ctx)
+	err = w.Handler.PostAPIAdminMerch(ctx)
+	return err
+}
+
+// GetApiAdminMerchLowStock converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiAdminMerchLowStock(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPIAdminMerchLowStock(ctx)
+	return err
+}
+
+// DeleteApiAdminMerchId converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteApiAdminMerchId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteAPIAdminMerchID(ctx, id)
+	return err
+}
+
+// PatchApiAdminMerchId converts echo context to params.
+func (w *ServerInterfaceWrapper) PatchApiAdminMerchId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PatchAPIAdminMerchID(ctx, id)
+	return err
+}
+
+// GetApiAdminMerchIdAudit converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiAdminMerchIdAudit(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPIAdminMerchIDAudit(ctx, id)
+	return err
+}
+
+// PostApiAdminMerchIdRestock converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiAdminMerchIdRestock(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPIAdminMerchIDRestock(ctx, id)
+	return err
+}
+
+// GetApiAdminMerchIdVariants converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiAdminMerchIdVariants(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPIAdminMerchIDVariants(ctx, id)
+	return err
+}
+
+// PostApiAdminMerchIdVariants converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiAdminMerchIdVariants(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPIAdminMerchIDVariants(ctx, id)
+	return err
+}
+
+// PatchApiAdminMerchIdVariantsSku converts echo context to params.
+func (w *ServerInterfaceWrapper) PatchApiAdminMerchIdVariantsSku(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+	// ------------- Path parameter "sku" -------------
+	var sku string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "sku", runtime.ParamLocationPath, ctx.Param("sku"), &sku)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter sku: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PatchAPIAdminMerchIDVariantsSku(ctx, id, sku)
+	return err
+}
+
+// PostApiAdminMerchIdVariantsSkuRestock converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiAdminMerchIdVariantsSkuRestock(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+	// ------------- Path parameter "sku" -------------
+	var sku string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "sku", runtime.ParamLocationPath, ctx.Param("sku"), &sku)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter sku: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPIAdminMerchIDVariantsSkuRestock(ctx, id, sku)
+	return err
+}
+
+// GetApiAdminOrders converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiAdminOrders(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetApiAdminOrdersParams
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", ctx.QueryParams(), &params.Status)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter status: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPIAdminOrders(ctx, params)
+	return err
+}
+
+// GetApiAdminOrdersId converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiAdminOrdersId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPIAdminOrdersID(ctx, id)
+	return err
+}
+
+// PatchApiAdminOrdersId converts echo context to params.
+func (w *ServerInterfaceWrapper) PatchApiAdminOrdersId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PatchAPIAdminOrdersID(ctx, id)
+	return err
+}
+
+// PostApiAdminPurchasesIdRefund converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiAdminPurchasesIdRefund(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPIAdminPurchasesIDRefund(ctx, id)
+	return err
+}
+
+// PostApiAdminTransactionsIdReverse converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiAdminTransactionsIdReverse(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPIAdminTransactionsIDReverse(ctx, id)
+	return err
+}
+
+// PutApiAdminUsersUsernameBalance converts echo context to params.
+func (w *ServerInterfaceWrapper) PutApiAdminUsersUsernameBalance(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "username" -------------
+	var username string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "username", runtime.ParamLocationPath, ctx.Param("username"), &username)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter username: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PutAPIAdminUsersUsernameBalance(ctx, username)
+	return err
+}
+
+// GetApiAdminUsersUsernameRoles converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiAdminUsersUsernameRoles(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "username" -------------
+	var username string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "username", runtime.ParamLocationPath, ctx.Param("username"), &username)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter username: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPIAdminUsersUsernameRoles(ctx, username)
+	return err
+}
+
+// DeleteApiAdminUsersUsernameRolesRole converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteApiAdminUsersUsernameRolesRole(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "username" -------------
+	var username string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "username", runtime.ParamLocationPath, ctx.Param("username"), &username)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter username: %s", err))
+	}
+	// ------------- Path parameter "role" -------------
+	var role string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "role", runtime.ParamLocationPath, ctx.Param("role"), &role)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter role: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteAPIAdminUsersUsernameRolesRole(ctx, username, role)
+	return err
+}
+
+// PutApiAdminUsersUsernameRolesRole converts echo context to params.
+func (w *ServerInterfaceWrapper) PutApiAdminUsersUsernameRolesRole(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "username" -------------
+	var username string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "username", runtime.ParamLocationPath, ctx.Param("username"), &username)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter username: %s", err))
+	}
+	// ------------- Path parameter "role" -------------
+	var role string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "role", runtime.ParamLocationPath, ctx.Param("role"), &role)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter role: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PutAPIAdminUsersUsernameRolesRole(ctx, username, role)
+	return err
+}
+
+// PostApiAuth converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiAuth(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPIAuth(ctx)
+	return err
+}
+
+// PostApiAuthRefresh converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiAuthRefresh(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPIAuthRefresh(ctx)
+	return err
+}
+
+// GetApiBuyItem converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiBuyItem(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "item" -------------
+	var item string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "item", runtime.ParamLocationPath, ctx.Param("item"), &item)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter item: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetApiBuyItemParams
+	// ------------- Optional query parameter "variant" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "variant", ctx.QueryParams(), &params.Variant)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter variant: %s", err))
+	}
+
+	// ------------- Optional query parameter "reservation" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "reservation", ctx.QueryParams(), &params.Reservation)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter reservation: %s", err))
+	}
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPIBuyItem(ctx, item, params)
+	return err
+}
+
+// GetApiCart converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiCart(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPICart(ctx)
+	return err
+}
+
+// PostApiCart converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiCart(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPICart(ctx)
+	return err
+}
+
+// PostApiCartCheckout converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiCartCheckout(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostApiCartCheckoutParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPICartCheckout(ctx, params)
+	return err
+}
+
+// DeleteApiCartId converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteApiCartId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteAPICartID(ctx, id)
+	return err
+}
+
+// GetApiFeed converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiFeed(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetApiFeedParams
+	// ------------- Optional query parameter "tag" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "tag", ctx.QueryParams(), &params.Tag)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter tag: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPIFeed(ctx, params)
+	return err
+}
+
+// GetApiHistory converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiHistory(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetApiHistoryParams
+	// ------------- Optional query parameter "type" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "type", ctx.QueryParams(), &params.Type)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter type: %s", err))
+	}
+
+	// ------------- Optional query parameter "direction" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "direction", ctx.QueryParams(), &params.Direction)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter direction: %s", err))
+	}
+
+	// ------------- Optional query parameter "counterparty" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "counterparty", ctx.QueryParams(), &params.Counterparty)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter counterparty: %s", err))
+	}
+
+	// ------------- Optional query parameter "minAmount" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "minAmount", ctx.QueryParams(), &params.MinAmount)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter minAmount: %s", err))
+	}
+
+	// ------------- Optional query parameter "maxAmount" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "maxAmount", ctx.QueryParams(), &params.MaxAmount)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter maxAmount: %s", err))
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", ctx.QueryParams(), &params.From)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter from: %s", err))
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", ctx.QueryParams(), &params.To)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter to: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", ctx.QueryParams(), &params.Cursor)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter cursor: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPIHistory(ctx, params)
+	return err
+}
+
+// GetApiInfo converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiInfo(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPIInfo(ctx)
+	return err
+}
+
+// PostApiLogout converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiLogout(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPILogout(ctx)
+	return err
+}
+
+// GetApiOrders converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiOrders(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPIOrders(ctx)
+	return err
+}
+
+// GetApiOrdersId converts echo context to params.
+func (w *ServerInterfaceWrapper) GetApiOrdersId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetAPIOrdersID(ctx, id)
+	return err
+}
+
+// PostApiOrdersIdCancel converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiOrdersIdCancel(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPIOrdersIDCancel(ctx, id)
+	return err
+}
+
+// PostApiRegister converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiRegister(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPIRegister(ctx)
+	return err
+}
+
+// PostApiReservations converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiReservations(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPIReservations(ctx)
+	return err
+}
+
+// DeleteApiReservationsId converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteApiReservationsId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteAPIReservationsID(ctx, id)
+	return err
+}
+
+// PostApiSendCoin converts echo context to params.
+func (w *ServerInterfaceWrapper) PostApiSendCoin(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostApiSendCoinParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostAPISendCoin(ctx, params)
+	return err
+}
+
+// GetHealthz converts echo context to params.
+func (w *ServerInterfaceWrapper) GetHealthz(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetHealthz(ctx)
+	return err
+}
+
+// GetMetrics converts echo context to params.
+func (w *ServerInterfaceWrapper) GetMetrics(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetMetrics(ctx)
+	return err
+}
+
+// GetReadyz converts echo context to params.
+func (w *ServerInterfaceWrapper) GetReadyz(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetReadyz(ctx)
 	return err
 }
 
@@ -181,9 +1760,48 @@ func RegisterHandlersWithBaseURL(publicRouter, protectedRouter EchoRouter, si Se
 		Handler: si,
 	}
 
+	publicRouter.GET(baseURL+"/.well-known/jwks.json", wrapper.GetWellKnownJwksJson)
+	protectedRouter.GET(baseURL+"/api/admin/ledger/audit", wrapper.GetApiAdminLedgerAudit)
+	protectedRouter.GET(baseURL+"/api/admin/merch", wrapper.GetApiAdminMerch)
+	protectedRouter.POST(baseURL+"/api/admin/merch", wrapper.PostApiAdminMerch)
+	protectedRouter.GET(baseURL+"/api/admin/merch/low-stock", wrapper.GetApiAdminMerchLowStock)
+	protectedRouter.DELETE(baseURL+"/api/admin/merch/:id", wrapper.DeleteApiAdminMerchId)
+	protectedRouter.PATCH(baseURL+"/api/admin/merch/:id", wrapper.PatchApiAdminMerchId)
+	protectedRouter.GET(baseURL+"/api/admin/merch/:id/audit", wrapper.GetApiAdminMerchIdAudit)
+	protectedRouter.POST(baseURL+"/api/admin/merch/:id/restock", wrapper.PostApiAdminMerchIdRestock)
+	protectedRouter.GET(baseURL+"/api/admin/merch/:id/variants", wrapper.GetApiAdminMerchIdVariants)
+	protectedRouter.POST(baseURL+"/api/admin/merch/:id/variants", wrapper.PostApiAdminMerchIdVariants)
+	protectedRouter.PATCH(baseURL+"/api/admin/merch/:id/variants/:sku", wrapper.PatchApiAdminMerchIdVariantsSku)
+	protectedRouter.POST(baseURL+"/api/admin/merch/:id/variants/:sku/restock", wrapper.PostApiAdminMerchIdVariantsSkuRestock)
+	protectedRouter.GET(baseURL+"/api/admin/orders", wrapper.GetApiAdminOrders)
+	protectedRouter.GET(baseURL+"/api/admin/orders/:id", wrapper.GetApiAdminOrdersId)
+	protectedRouter.PATCH(baseURL+"/api/admin/orders/:id", wrapper.PatchApiAdminOrdersId)
+	protectedRouter.POST(baseURL+"/api/admin/purchases/:id/refund", wrapper.PostApiAdminPurchasesIdRefund)
+	protectedRouter.POST(baseURL+"/api/admin/transactions/:id/reverse", wrapper.PostApiAdminTransactionsIdReverse)
+	protectedRouter.PUT(baseURL+"/api/admin/users/:username/balance", wrapper.PutApiAdminUsersUsernameBalance)
+	protectedRouter.GET(baseURL+"/api/admin/users/:username/roles", wrapper.GetApiAdminUsersUsernameRoles)
+	protectedRouter.DELETE(baseURL+"/api/admin/users/:username/roles/:role", wrapper.DeleteApiAdminUsersUsernameRolesRole)
+	protectedRouter.PUT(baseURL+"/api/admin/users/:username/roles/:role", wrapper.PutApiAdminUsersUsernameRolesRole)
 	publicRouter.POST(baseURL+"/api/auth", wrapper.PostApiAuth)
+	publicRouter.POST(baseURL+"/api/auth/refresh", wrapper.PostApiAuthRefresh)
 	protectedRouter.GET(baseURL+"/api/buy/:item", wrapper.GetApiBuyItem)
+	protectedRouter.GET(baseURL+"/api/cart", wrapper.GetApiCart)
+	protectedRouter.POST(baseURL+"/api/cart", wrapper.PostApiCart)
+	protectedRouter.POST(baseURL+"/api/cart/checkout", wrapper.PostApiCartCheckout)
+	protectedRouter.DELETE(baseURL+"/api/cart/:id", wrapper.DeleteApiCartId)
+	protectedRouter.GET(baseURL+"/api/feed", wrapper.GetApiFeed)
+	protectedRouter.GET(baseURL+"/api/history", wrapper.GetApiHistory)
 	protectedRouter.GET(baseURL+"/api/info", wrapper.GetApiInfo)
+	protectedRouter.POST(baseURL+"/api/logout", wrapper.PostApiLogout)
+	protectedRouter.GET(baseURL+"/api/orders", wrapper.GetApiOrders)
+	protectedRouter.GET(baseURL+"/api/orders/:id", wrapper.GetApiOrdersId)
+	protectedRouter.POST(baseURL+"/api/orders/:id/cancel", wrapper.PostApiOrdersIdCancel)
+	publicRouter.POST(baseURL+"/api/register", wrapper.PostApiRegister)
+	protectedRouter.POST(baseURL+"/api/reservations", wrapper.PostApiReservations)
+	protectedRouter.DELETE(baseURL+"/api/reservations/:id", wrapper.DeleteApiReservationsId)
 	protectedRouter.POST(baseURL+"/api/sendCoin", wrapper.PostApiSendCoin)
+	publicRouter.GET(baseURL+"/healthz", wrapper.GetHealthz)
+	publicRouter.GET(baseURL+"/metrics", wrapper.GetMetrics)
+	publicRouter.GET(baseURL+"/readyz", wrapper.GetReadyz)
 
 }
@@ -0,0 +1,105 @@
+// Команда catalog загружает каталог мерча из файла YAML или JSON и печатает, что изменилось.
+//
+//	go run ./cmd/catalog -file merch_catalog.yaml [-dry-run] [-actor admin]
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"avito_coin/internal/catalog"
+	"avito_coin/internal/config"
+	"avito_coin/internal/db"
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	// Флаги команды свои, поэтому конфигурация берется только из файла и окружения
+	cfg, err := config.LoadConfig(nil)
+	if err != nil {
+		logrus.Fatalf("Failed to load config: %v", err)
+	}
+
+	file := flag.String("file", cfg.MerchCatalogFile, "файл каталога (.yaml, .yml или .json)")
+	dryRun := flag.Bool("dry-run", false, "показать изменения, не применяя их")
+	actor := flag.String("actor", "", "имя администратора для журнала изменений каталога")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	items, err := catalog.Load(*file)
+	if err != nil {
+		logrus.Fatalf("Failed to load catalog: %v", err)
+	}
+
+	// Миграции применяет сервис: команда только проверяет, что схема не отстает от кода
+	database, err := db.ConnectPostgresDB(cfg)
+	if err != nil {
+		logrus.Fatalf("Failed to connect to DB: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	if err := checkSchema(ctx, database); err != nil {
+		logrus.Fatalf("Failed to check DB schema: %v", err)
+	}
+	coinService := service.NewCoinService(repository.NewRepository(database))
+
+	var actorID int32
+
+	if *actor != "" {
+		user, err := coinService.UserExists(ctx, *actor)
+		if err != nil {
+			logrus.Fatalf("Failed to find actor %q: %v", *actor, err)
+		}
+
+		actorID = user.ID
+	}
+
+	report, err := coinService.SyncCatalog(ctx, actorID, items, *dryRun)
+	if err != nil {
+		logrus.Fatalf("Failed to sync catalog: %v", err)
+	}
+
+	printReport(report, *dryRun)
+}
+
+// checkSchema - ошибка, если к БД применены не все миграции, встроенные в сервис.
+func checkSchema(ctx context.Context, database *sql.DB) error {
+	latest, err := db.LatestMigrationVersion()
+	if err != nil {
+		return err
+	}
+
+	current, err := db.MigrationVersion(ctx, database)
+	if err != nil {
+		return err
+	}
+
+	if current < latest {
+		return fmt.Errorf("schema version %d is behind %d: start the service to apply migrations", current, latest)
+	}
+
+	return nil
+}
+
+// printReport - вывод изменений каталога.
+func printReport(report *service.CatalogReport, dryRun bool) {
+	if dryRun {
+		fmt.Println("Dry run, no changes applied")
+	}
+
+	fmt.Printf("Created (%d): %s\n", len(report.Created), strings.Join(report.Created, ", "))
+	fmt.Printf("Updated (%d): %s\n", len(report.Updated), strings.Join(report.Updated, ", "))
+	fmt.Printf("Unchanged: %d\n", len(report.Unchanged))
+}
@@ -1,29 +1,58 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"math"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	_ "time"
+	"time"
 
+	"avito_coin/internal/catalog"
 	"avito_coin/internal/config"
 	"avito_coin/internal/db"
 	"avito_coin/internal/handler"
+	"avito_coin/internal/health"
+	"avito_coin/internal/lifecycle"
+	"avito_coin/internal/metrics"
+	"avito_coin/internal/password"
 	"avito_coin/internal/repository"
 	"avito_coin/internal/service"
+	"avito_coin/internal/tracing"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
 	// Загрузка конфигурации
-	cfg, err := config.LoadConfig()
+	cfg, err := config.LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+
 	if err != nil {
-		logrus.Infof(".env file not found: %v", err)
+		logrus.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Уровень проверен при загрузке конфигурации
+	logLevel, _ := logrus.ParseLevel(cfg.LogLevel)
+	logrus.SetLevel(logLevel)
+
+	logEffectiveConfig(cfg)
 	logrus.Info("Config has been successfully loaded")
 
+	// Трейсы; в записи лога с контекстом запроса попадают trace_id и span_id
+	logrus.AddHook(tracing.LogHook{})
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		logrus.Fatalf("Failed to configure tracing: %v", err)
+	}
+
 	// Подключение к БД
 	DB, err := db.NewPostgresDB(cfg)
 	if err != nil {
@@ -33,29 +62,272 @@ func main() {
 	logrus.Info("Database has been successfully connected")
 
 	// Создание слоя репозитория
-	repo := repository.NewRepository(DB)
+	repo := repository.NewRepository(DB, repository.WithInitialGrant(int32(cfg.InitialBalance)))
+
+	// Хеширование паролей
+	passwords, err := newPasswordHasher(cfg)
+	if err != nil {
+		logrus.Fatalf("Failed to configure password hashing: %v", err)
+	}
+
+	// Роли, выдаваемые при запуске
+	rolesSeed, err := service.ParseRolesSeed(cfg.RolesSeed)
+	if err != nil {
+		logrus.Fatalf("Failed to parse roles seed: %v", err)
+	}
+
+	// Лимиты переводов
+	limits, err := newLimitPolicy(cfg)
+	if err != nil {
+		logrus.Fatalf("Failed to parse transfer limits: %v", err)
+	}
+
+	// Метрики
+	appMetrics := metrics.New()
+	appMetrics.RegisterDB(DB)
 
 	// Создание слоя сервиса
-	service := service.NewCoinService(repo)
+	service := service.NewCoinService(repo,
+		service.WithIdempotencyTTL(cfg.IdempotencyTTL),
+		service.WithIdempotencyLease(cfg.IdempotencyLease),
+		service.WithPasswordHasher(passwords),
+		service.WithRefreshTokenTTL(cfg.RefreshTokenTTL),
+		service.WithRegistrationPolicy(newRegistrationPolicy(cfg)),
+		service.WithReservationTTL(cfg.ReservationTTL),
+		service.WithLowStockAlert(int32(min(cfg.LowStockThreshold, math.MaxInt32)), nil),
+		service.WithTransferLimits(limits),
+		service.WithMetrics(appMetrics),
+	)
+
+	appMetrics.RegisterCirculation(service.CoinsInCirculation)
+
+	// Выдача ролей из конфигурации
+	if err := service.SeedRoles(context.Background(), rolesSeed); err != nil {
+		logrus.Fatalf("Failed to seed roles: %v", err)
+	}
+
+	// Загрузка каталога мерча
+	if cfg.MerchCatalogFile != "" {
+		if err := syncCatalog(context.Background(), service, cfg.MerchCatalogFile); err != nil {
+			logrus.Fatalf("Failed to load merch catalog: %v", err)
+		}
+	}
+
+	// Фоновая очистка просроченных ключей идемпотентности, токенов и резервов
+	lc := lifecycle.New()
+
+	lc.Go("cleanup", func(ctx context.Context) {
+		service.RunCleanup(ctx, cfg.CleanupInterval)
+	})
 
 	// Новый экземрляр Echo и задаем sli времени ответа
 	e := echo.New()
 
+	// Ключи подписи JWT
+	keys, err := handler.NewKeyRing(handler.KeyRingConfig{
+		Dir:            cfg.JWTKeysDir,
+		Secret:         cfg.JWTSecret,
+		SecretKeyID:    cfg.JWTSecretKID,
+		ActiveKeyID:    cfg.JWTActiveKID,
+		AllowEphemeral: cfg.JWTAllowEphemeral,
+	})
+	if err != nil {
+		logrus.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	lc.Go("jwt keys reload", func(ctx context.Context) {
+		reloadKeysOnSIGHUP(ctx, keys)
+	})
+
 	// Создание слоя обработчика
-	handler.NewCoinHandler(e, service)
+	handler.NewCoinHandler(e, service, keys,
+		handler.WithAccessTokenTTL(cfg.AccessTokenTTL),
+		handler.WithAutoRegister(cfg.AuthAutoRegister),
+		handler.WithFeed(cfg.FeatureFeed),
+		handler.WithLogLevel(logLevel),
+		handler.WithMetrics(appMetrics),
+		handler.WithTracing(cfg.TracingServiceName),
+		handler.WithReadinessChecks(
+			health.Lifecycle(lc),
+			health.Database(DB),
+			health.Migrations(DB),
+			health.Pool(DB),
+		),
+	)
 
 	// Запускаем сервер
+	configureServer(e, cfg)
+
 	go func() {
-		if err := e.Start(":8080"); err != nil {
+		if err := startServer(e, cfg); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logrus.Fatalf("error starting server: %v", err)
 		}
 	}()
 
+	lc.SetReady(true)
+
 	// Канал для обработки сигналов завершения
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
-	// Ожидание сигнала завершения
+	// Ожидание сигнала завершения; повторный сигнал завершает процесс сразу
 	<-stop
+	signal.Stop(stop)
 	logrus.Info("Received shutdown signal. Gracefully shutting down...")
+
+	shutdown(e, lc, DB, shutdownTracing, cfg)
+}
+
+// shutdown - остановка сервиса: снятие готовности, завершение текущих запросов,
+// остановка фоновых задач, закрытие пула подключений к БД и отправка оставшихся спанов.
+// Общий дедлайн - ShutdownTimeout.
+func shutdown(
+	e *echo.Echo,
+	lc *lifecycle.Lifecycle,
+	database *sql.DB,
+	shutdownTracing func(context.Context) error,
+	cfg config.Config,
+) {
+	lc.SetReady(false)
+
+	// Балансировщик успевает увидеть, что сервис не готов, пока тот еще принимает запросы
+	time.Sleep(cfg.ShutdownDrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// Новые подключения не принимаются, текущие запросы дорабатывают
+	if err := e.Shutdown(ctx); err != nil {
+		logrus.Errorf("Failed to drain in-flight requests: %v", err)
+	}
+
+	if err := lc.StopTasks(ctx); err != nil {
+		logrus.Errorf("Failed to stop background tasks: %v", err)
+	}
+
+	if err := database.Close(); err != nil {
+		logrus.Errorf("Failed to close database: %v", err)
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		logrus.Errorf("Failed to flush traces: %v", err)
+	}
+
+	logrus.Info("Server stopped")
+}
+
+// logEffectiveConfig - вывод действующей конфигурации в лог; секреты скрыты.
+func logEffectiveConfig(cfg config.Config) {
+	fields := logrus.Fields{}
+	for _, setting := range cfg.Effective() {
+		fields[setting.Key] = setting.Value + " (" + setting.Source + ")"
+	}
+
+	logrus.WithFields(fields).Info("Effective configuration")
+}
+
+// configureServer - таймауты HTTP-сервера из конфигурации.
+func configureServer(e *echo.Echo, cfg config.Config) {
+	for _, server := range []*http.Server{e.Server, e.TLSServer} {
+		server.ReadTimeout = cfg.HTTPReadTimeout
+		server.ReadHeaderTimeout = cfg.HTTPReadHeaderTimeout
+		server.WriteTimeout = cfg.HTTPWriteTimeout
+		server.IdleTimeout = cfg.HTTPIdleTimeout
+	}
+}
+
+// startServer - запуск HTTP-сервера или HTTPS-сервера, если заданы сертификат и ключ.
+func startServer(e *echo.Echo, cfg config.Config) error {
+	if cfg.TLSCertFile != "" {
+		return e.StartTLS(cfg.HTTPAddr, cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+
+	return e.Start(cfg.HTTPAddr)
+}
+
+// reloadKeysOnSIGHUP - перечитывает ключи подписи JWT по сигналу SIGHUP (ротация без перезапуска).
+func reloadKeysOnSIGHUP(ctx context.Context, keys *handler.KeyRing) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			// При ошибке продолжаем работать со старыми ключами
+			if err := keys.Reload(); err != nil {
+				logrus.Errorf("Failed to reload JWT signing keys: %v", err)
+			}
+		}
+	}
+}
+
+// syncCatalog - загрузка каталога мерча из файла.
+func syncCatalog(ctx context.Context, coinService *service.CoinService, path string) error {
+	items, err := catalog.Load(path)
+	if err != nil {
+		return err
+	}
+
+	report, err := coinService.SyncCatalog(ctx, 0, items, false)
+	if err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"file":      path,
+		"created":   report.Created,
+		"updated":   report.Updated,
+		"unchanged": len(report.Unchanged),
+	}).Info("Merch catalog loaded")
+
+	return nil
+}
+
+// newRegistrationPolicy - правила регистрации из конфигурации.
+func newRegistrationPolicy(cfg config.Config) service.RegistrationPolicy {
+	policy := service.DefaultRegistrationPolicy()
+	policy.MinUsernameLength = cfg.UsernameMinLength
+	policy.MaxUsernameLength = cfg.UsernameMaxLength
+	policy.MinPasswordLength = cfg.PasswordMinLength
+	policy.InviteCodes = cfg.RegistrationInviteCodes
+	policy.AllowedDomains = cfg.RegistrationAllowedDomains
+
+	if len(cfg.ReservedUsernames) > 0 {
+		policy.ReservedUsernames = cfg.ReservedUsernames
+	}
+
+	return policy
+}
+
+// newLimitPolicy - глобальные лимиты переводов и лимиты ролей из конфигурации.
+func newLimitPolicy(cfg config.Config) (service.LimitPolicy, error) {
+	limits := service.TransferLimits{
+		MinAmount:       int64(cfg.TransferMinAmount),
+		MaxAmount:       int64(cfg.TransferMaxAmount),
+		DailyOutgoing:   int64(cfg.TransferDailyOutgoing),
+		WeeklyOutgoing:  int64(cfg.TransferWeeklyOutgoing),
+		DailyIncoming:   int64(cfg.TransferDailyIncoming),
+		WeeklyIncoming:  int64(cfg.TransferWeeklyIncoming),
+		DailyRecipients: int64(cfg.TransferDailyRecipients),
+	}
+
+	roles, err := service.ParseRoleLimits(cfg.TransferRoleLimits, limits)
+	if err != nil {
+		return service.LimitPolicy{}, err
+	}
+
+	return service.LimitPolicy{Default: limits, Roles: roles}, nil
+}
+
+// newPasswordHasher - хешер паролей с алгоритмом и параметрами из конфигурации.
+func newPasswordHasher(cfg config.Config) (*password.Hasher, error) {
+	argon2id := password.DefaultArgon2id()
+	argon2id.Memory = uint32(cfg.Argon2Memory)
+	argon2id.Iterations = uint32(cfg.Argon2Iterations)
+	argon2id.Parallelism = uint8(min(cfg.Argon2Parallelism, math.MaxUint8))
+
+	return password.New(cfg.PasswordAlgorithm, argon2id, password.Bcrypt{Cost: cfg.BcryptCost})
 }
@@ -0,0 +1,88 @@
+// Package catalog - загрузка каталога мерча из файла YAML или JSON.
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidCatalog - файл каталога не соответствует формату.
+var ErrInvalidCatalog = errors.New("invalid catalog")
+
+// Item - товар каталога. Товары сопоставляются с базой по названию.
+type Item struct {
+	Name  string `json:"name" yaml:"name"`
+	Price int32  `json:"price" yaml:"price"`
+	// Active - продается ли товар; если не задан, состояние в базе не меняется (новый товар продается).
+	Active *bool `json:"active,omitempty" yaml:"active,omitempty"`
+}
+
+// Catalog - содержимое файла каталога.
+type Catalog struct {
+	Items []Item `json:"items" yaml:"items"`
+}
+
+// Load - чтение каталога из файла. Формат определяется по расширению: .yaml, .yml или .json.
+func Load(path string) ([]Item, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+
+	var catalog Catalog
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&catalog)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&catalog)
+	default:
+		return nil, fmt.Errorf("%w: unsupported file extension %q", ErrInvalidCatalog, ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCatalog, err)
+	}
+
+	if err := catalog.Validate(); err != nil {
+		return nil, err
+	}
+
+	return catalog.Items, nil
+}
+
+// Validate - проверка названий и цен товаров.
+func (c Catalog) Validate() error {
+	seen := make(map[string]bool, len(c.Items))
+
+	for i, item := range c.Items {
+		name := strings.TrimSpace(item.Name)
+		if name == "" {
+			return fmt.Errorf("%w: item %d has no name", ErrInvalidCatalog, i+1)
+		}
+
+		if item.Price <= 0 {
+			return fmt.Errorf("%w: item %q must have a positive price", ErrInvalidCatalog, name)
+		}
+
+		if seen[name] {
+			return fmt.Errorf("%w: item %q is listed twice", ErrInvalidCatalog, name)
+		}
+
+		seen[name] = true
+		c.Items[i].Name = name
+	}
+
+	return nil
+}
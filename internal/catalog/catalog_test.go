@@ -0,0 +1,69 @@
+package catalog_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"avito_coin/internal/catalog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCatalog - записывает файл каталога во временный каталог.
+func writeCatalog(t *testing.T, name, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	yamlPath := writeCatalog(t, "merch.yaml", `
+items:
+  - name: " cup "
+    price: 20
+  - name: pen
+    price: 10
+    active: false
+`)
+
+	items, err := catalog.Load(yamlPath)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "cup", items[0].Name)
+	assert.Nil(t, items[0].Active)
+	require.NotNil(t, items[1].Active)
+	assert.False(t, *items[1].Active)
+
+	jsonPath := writeCatalog(t, "merch.json", `{"items": [{"name": "cup", "price": 20}]}`)
+
+	items, err = catalog.Load(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, []catalog.Item{{Name: "cup", Price: 20}}, items)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"merch.yaml": "items:\n  - name: cup\n    price: 0\n",
+		"dup.yaml":   "items:\n  - name: cup\n    price: 20\n  - name: cup\n    price: 30\n",
+		"typo.json":  `{"items": [{"name": "cup", "prise": 20}]}`,
+		"merch.txt":  "cup=20",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := catalog.Load(writeCatalog(t, name, data))
+			assert.ErrorIs(t, err, catalog.ErrInvalidCatalog)
+		})
+	}
+}
+
+func TestDefaultCatalog(t *testing.T) {
+	// Каталог в репозитории совпадает с сидирующей миграцией
+	items, err := catalog.Load("../../merch_catalog.yaml")
+	require.NoError(t, err)
+	assert.Len(t, items, 10)
+}
@@ -1,27 +1,355 @@
 package config
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"slices"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// SSLModes - допустимые значения DB_SSL_MODE.
+var SSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// TracingExporters - допустимые значения TRACING_EXPORTER.
+var TracingExporters = []string{"none", "stdout", "otlp"}
+
 type Config struct {
+	// HTTPAddr - адрес, на котором сервис принимает запросы.
+	HTTPAddr string
+	// HTTPReadTimeout, HTTPReadHeaderTimeout, HTTPWriteTimeout и HTTPIdleTimeout - таймауты HTTP-сервера.
+	HTTPReadTimeout       time.Duration
+	HTTPReadHeaderTimeout time.Duration
+	HTTPWriteTimeout      time.Duration
+	HTTPIdleTimeout       time.Duration
+	// TLSCertFile и TLSKeyFile - сертификат и ключ; если заданы, сервис принимает только HTTPS.
+	TLSCertFile string
+	TLSKeyFile  string
+	// ShutdownDrainDelay - сколько сервис при остановке отвечает на запросы, уже не будучи готовым,
+	// чтобы балансировщик успел перестать направлять на него трафик.
+	ShutdownDrainDelay time.Duration
+	// ShutdownTimeout - сколько при остановке ждать завершения запросов и фоновых задач.
+	ShutdownTimeout time.Duration
+
+	// DBURL - строка подключения к БД; если задана, DBHost, DBPort, DBUser, DBPassword, DBName и DBSSLMode не используются.
+	DBURL      string
 	DBHost     string
 	DBPort     string
 	DBUser     string
 	DBPassword string
 	DBName     string
+	// DBSSLMode - режим SSL подключения к БД, одно из SSLModes.
+	DBSSLMode string
+	// DBMaxOpenConns и DBMaxIdleConns - размер пула подключений к БД.
+	DBMaxOpenConns int
+	DBMaxIdleConns int
+	// DBConnMaxLifetime и DBConnMaxIdleTime - сколько живет подключение и сколько оно может простаивать (0 - без ограничения).
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+
+	// LogLevel - уровень логирования: debug, info, warn, error.
+	LogLevel string
+
+	// TracingExporter - куда отправлять трейсы, одно из TracingExporters.
+	TracingExporter string
+	// TracingOTLPEndpoint - URL коллектора OTLP/HTTP; если не задан, используются стандартные переменные OTEL_EXPORTER_OTLP_*.
+	TracingOTLPEndpoint string
+	// TracingServiceName - имя сервиса в трейсах.
+	TracingServiceName string
+
+	// IdempotencyTTL - сколько хранятся ответы на запросы с ключом идемпотентности.
+	IdempotencyTTL time.Duration
+	// IdempotencyLease - сколько ключ идемпотентности без ответа считается занятым выполняющимся запросом.
+	IdempotencyLease time.Duration
+	// CleanupInterval - период фоновой очистки просроченных данных.
+	CleanupInterval time.Duration
+
+	// PasswordAlgorithm - алгоритм хеширования паролей: argon2id или bcrypt.
+	PasswordAlgorithm string
+	// Argon2Memory - память argon2id в КиБ.
+	Argon2Memory int
+	// Argon2Iterations - количество итераций argon2id.
+	Argon2Iterations int
+	// Argon2Parallelism - количество потоков argon2id.
+	Argon2Parallelism int
+	// BcryptCost - стоимость bcrypt.
+	BcryptCost int
+
+	// JWTKeysDir - каталог с ключами подписи JWT.
+	JWTKeysDir string
+	// JWTSecret - секрет HS256 для подписи JWT.
+	JWTSecret string
+	// JWTSecretKID - ID ключа JWTSecret.
+	JWTSecretKID string
+	// JWTActiveKID - ID ключа, которым подписываются новые токены.
+	JWTActiveKID string
+	// JWTAllowEphemeral - без ключей подписывать токены случайным ключом (только для разработки).
+	JWTAllowEphemeral bool
+	// AccessTokenTTL - время жизни access-токена.
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL - время жизни refresh-токена.
+	RefreshTokenTTL time.Duration
+
+	// InitialBalance - сколько монет начисляется сотруднику при регистрации.
+	InitialBalance int
+
+	// AuthAutoRegister - регистрировать неизвестных пользователей при входе (старое поведение для нагрузочных тестов).
+	AuthAutoRegister bool
+	// FeatureFeed - доступна ли лента благодарностей /api/feed.
+	FeatureFeed bool
+	// UsernameMinLength и UsernameMaxLength - допустимая длина имени пользователя.
+	UsernameMinLength int
+	UsernameMaxLength int
+	// PasswordMinLength - минимальная длина пароля при регистрации.
+	PasswordMinLength int
+	// ReservedUsernames - имена, которые нельзя занять при регистрации.
+	ReservedUsernames []string
+	// RegistrationInviteCodes - коды приглашения; если заданы, без кода зарегистрироваться нельзя.
+	RegistrationInviteCodes []string
+	// RegistrationAllowedDomains - домены, в которых разрешена регистрация; если заданы, имя должно быть адресом.
+	RegistrationAllowedDomains []string
+
+	// RolesSeed - роли, выдаваемые при запуске, в формате "alice=admin,shop-admin;bob=finance-admin".
+	RolesSeed string
+
+	// MerchCatalogFile - файл каталога мерча (YAML или JSON), загружаемый при запуске.
+	MerchCatalogFile string
+	// LowStockThreshold - остаток товара, начиная с которого в лог пишется предупреждение.
+	LowStockThreshold int
+	// ReservationTTL - сколько держится резерв товара.
+	ReservationTTL time.Duration
+
+	// TransferMinAmount и TransferMaxAmount - границы суммы одного перевода (0 - без верхней границы).
+	TransferMinAmount int
+	TransferMaxAmount int
+	// TransferDailyOutgoing и TransferWeeklyOutgoing - сколько монет можно отправить за день и за неделю (0 - без ограничения).
+	TransferDailyOutgoing  int
+	TransferWeeklyOutgoing int
+	// TransferDailyIncoming и TransferWeeklyIncoming - сколько монет можно получить за день и за неделю (0 - без ограничения).
+	TransferDailyIncoming  int
+	TransferWeeklyIncoming int
+	// TransferDailyRecipients - скольким разным получателям можно отправить монеты за день (0 - без ограничения).
+	TransferDailyRecipients int
+	// TransferRoleLimits - лимиты ролей в формате "manager:max_amount=500,daily_outgoing=2000;admin:daily_outgoing=0".
+	TransferRoleLimits string
+
+	// effective - действующие значения параметров и их источники.
+	effective []Setting
+}
+
+// LoadConfig - загрузка конфигурации. Значения берутся по убыванию приоритета из флагов командной строки args,
+// переменных окружения (и файла .env), файла конфигурации (-config или CONFIG_FILE) и значений по умолчанию.
+// Ошибка содержит все неверные параметры сразу.
+func LoadConfig(args []string) (Config, error) {
+	l, err := newLoader(args)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := Config{
+		HTTPAddr:              l.string("HTTP_ADDR", ":8080"),
+		HTTPReadTimeout:       l.duration("HTTP_READ_TIMEOUT", 30*time.Second),
+		HTTPReadHeaderTimeout: l.duration("HTTP_READ_HEADER_TIMEOUT", 10*time.Second),
+		HTTPWriteTimeout:      l.duration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		HTTPIdleTimeout:       l.duration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		TLSCertFile:           l.string("TLS_CERT_FILE", ""),
+		TLSKeyFile:            l.string("TLS_KEY_FILE", ""),
+		ShutdownDrainDelay:    l.duration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+		ShutdownTimeout:       l.duration("SHUTDOWN_TIMEOUT", 30*time.Second),
+
+		DBURL:             l.string("DATABASE_URL", ""),
+		DBHost:            l.string("DB_HOST", "localhost"),
+		DBPort:            l.string("DB_PORT", "5432"),
+		DBUser:            l.string("DB_USER", ""),
+		DBPassword:        l.string("DB_PASSWORD", ""),
+		DBName:            l.string("DB_NAME", ""),
+		DBSSLMode:         l.string("DB_SSL_MODE", "disable"),
+		DBMaxOpenConns:    l.int("DB_MAX_OPEN_CONNS", 1000),
+		DBMaxIdleConns:    l.int("DB_MAX_IDLE_CONNS", 100),
+		DBConnMaxLifetime: l.duration("DB_CONN_MAX_LIFETIME", 0),
+		DBConnMaxIdleTime: l.duration("DB_CONN_MAX_IDLE_TIME", 0),
+
+		LogLevel: l.string("LOG_LEVEL", "info"),
+
+		TracingExporter:     l.string("TRACING_EXPORTER", "none"),
+		TracingOTLPEndpoint: l.string("TRACING_OTLP_ENDPOINT", ""),
+		TracingServiceName:  l.string("TRACING_SERVICE_NAME", "coin_service"),
+
+		IdempotencyTTL:   l.duration("IDEMPOTENCY_TTL", 24*time.Hour),
+		IdempotencyLease: l.duration("IDEMPOTENCY_LEASE", 2*time.Minute),
+		CleanupInterval:  l.duration("CLEANUP_INTERVAL", 10*time.Minute),
+
+		PasswordAlgorithm: l.string("PASSWORD_ALGORITHM", ""),
+		Argon2Memory:      l.int("ARGON2_MEMORY_KIB", 64*1024),
+		Argon2Iterations:  l.int("ARGON2_ITERATIONS", 3),
+		Argon2Parallelism: l.int("ARGON2_PARALLELISM", 2),
+		BcryptCost:        l.int("BCRYPT_COST", 12),
+
+		JWTKeysDir:        l.string("JWT_KEYS_DIR", ""),
+		JWTSecret:         l.string("JWT_SECRET", ""),
+		JWTSecretKID:      l.string("JWT_SECRET_KID", ""),
+		JWTActiveKID:      l.string("JWT_ACTIVE_KID", ""),
+		JWTAllowEphemeral: l.bool("JWT_ALLOW_EPHEMERAL", false),
+
+		AccessTokenTTL:  l.duration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: l.duration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		InitialBalance: l.int("INITIAL_BALANCE", 1000),
+
+		AuthAutoRegister:           l.bool("AUTH_AUTO_REGISTER", false),
+		FeatureFeed:                l.bool("FEATURE_FEED", true),
+		UsernameMinLength:          l.int("USERNAME_MIN_LENGTH", 3),
+		UsernameMaxLength:          l.int("USERNAME_MAX_LENGTH", 32),
+		PasswordMinLength:          l.int("PASSWORD_MIN_LENGTH", 8),
+		ReservedUsernames:          l.list("RESERVED_USERNAMES"),
+		RegistrationInviteCodes:    l.list("REGISTRATION_INVITE_CODES"),
+		RegistrationAllowedDomains: l.list("REGISTRATION_ALLOWED_DOMAINS"),
+
+		RolesSeed: l.string("ROLES_SEED", ""),
+
+		MerchCatalogFile:  l.string("MERCH_CATALOG_FILE", ""),
+		LowStockThreshold: l.int("LOW_STOCK_THRESHOLD", 5),
+		ReservationTTL:    l.duration("RESERVATION_TTL", 5*time.Minute),
+
+		TransferMinAmount:       l.int("TRANSFER_MIN_AMOUNT", 1),
+		TransferMaxAmount:       l.int("TRANSFER_MAX_AMOUNT", 0),
+		TransferDailyOutgoing:   l.int("TRANSFER_DAILY_OUTGOING", 0),
+		TransferWeeklyOutgoing:  l.int("TRANSFER_WEEKLY_OUTGOING", 0),
+		TransferDailyIncoming:   l.int("TRANSFER_DAILY_INCOMING", 0),
+		TransferWeeklyIncoming:  l.int("TRANSFER_WEEKLY_INCOMING", 0),
+		TransferDailyRecipients: l.int("TRANSFER_DAILY_RECIPIENTS", 0),
+		TransferRoleLimits:      l.string("TRANSFER_ROLE_LIMITS", ""),
+
+		effective: l.effective,
+	}
+
+	if err := errors.Join(append(l.finish(), cfg.Validate())...); err != nil {
+		return cfg, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return cfg, nil
 }
 
-func LoadConfig() (Config, error) {
-	err := godotenv.Load()
+// Validate - проверка согласованности параметров.
+func (c Config) Validate() error {
+	var errs []error
+
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.HTTPAddr == "" {
+		invalid("HTTP_ADDR: must not be empty")
+	}
+
+	// Параметры, для которых 0 не имеет смысла; 0 в остальных означает "без ограничения" или "выключено"
+	for _, setting := range []struct {
+		key   string
+		value int64
+	}{
+		{"SHUTDOWN_TIMEOUT", int64(c.ShutdownTimeout)},
+		{"IDEMPOTENCY_TTL", int64(c.IdempotencyTTL)},
+		{"IDEMPOTENCY_LEASE", int64(c.IdempotencyLease)},
+		{"CLEANUP_INTERVAL", int64(c.CleanupInterval)},
+		{"ARGON2_MEMORY_KIB", int64(c.Argon2Memory)},
+		{"ARGON2_ITERATIONS", int64(c.Argon2Iterations)},
+		{"ARGON2_PARALLELISM", int64(c.Argon2Parallelism)},
+		{"ACCESS_TOKEN_TTL", int64(c.AccessTokenTTL)},
+		{"REFRESH_TOKEN_TTL", int64(c.RefreshTokenTTL)},
+		{"USERNAME_MAX_LENGTH", int64(c.UsernameMaxLength)},
+		{"RESERVATION_TTL", int64(c.ReservationTTL)},
+	} {
+		if setting.value == 0 {
+			invalid("%s: must be positive", setting.key)
+		}
+	}
+
+	if c.BcryptCost < bcrypt.MinCost || c.BcryptCost > bcrypt.MaxCost {
+		invalid("BCRYPT_COST: %d is not between %d and %d", c.BcryptCost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	// Пока запрос может выполняться, его ключ идемпотентности не должен освобождаться для повтора
+	if c.HTTPWriteTimeout > 0 && c.IdempotencyLease > 0 && c.IdempotencyLease <= c.HTTPWriteTimeout {
+		invalid("IDEMPOTENCY_LEASE: %s is not longer than HTTP_WRITE_TIMEOUT %s", c.IdempotencyLease, c.HTTPWriteTimeout)
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		invalid("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if c.DBURL == "" {
+		if c.DBName == "" {
+			invalid("DB_NAME: must be set unless DATABASE_URL is set")
+		}
+
+		if !slices.Contains(SSLModes, c.DBSSLMode) {
+			invalid("DB_SSL_MODE: %q is not one of %v", c.DBSSLMode, SSLModes)
+		}
+	} else if _, err := url.Parse(c.DBURL); err != nil {
+		invalid("DATABASE_URL: malformed URL")
+	}
+
+	if c.DBMaxIdleConns > c.DBMaxOpenConns {
+		invalid("DB_MAX_IDLE_CONNS: %d is greater than DB_MAX_OPEN_CONNS %d", c.DBMaxIdleConns, c.DBMaxOpenConns)
+	}
+
+	if _, err := logrus.ParseLevel(c.LogLevel); err != nil {
+		invalid("LOG_LEVEL: %q is not a valid level", c.LogLevel)
+	}
+
+	if !slices.Contains(TracingExporters, c.TracingExporter) {
+		invalid("TRACING_EXPORTER: %q is not one of %v", c.TracingExporter, TracingExporters)
+	}
+
+	if c.TracingOTLPEndpoint != "" {
+		if endpoint, err := url.Parse(c.TracingOTLPEndpoint); err != nil || endpoint.Host == "" {
+			invalid("TRACING_OTLP_ENDPOINT: %q is not an absolute URL", c.TracingOTLPEndpoint)
+		}
+	}
+
+	if c.InitialBalance > math.MaxInt32 {
+		invalid("INITIAL_BALANCE: %d is too large", c.InitialBalance)
+	}
+
+	if c.UsernameMinLength > c.UsernameMaxLength {
+		invalid("USERNAME_MIN_LENGTH: %d is greater than USERNAME_MAX_LENGTH %d", c.UsernameMinLength, c.UsernameMaxLength)
+	}
+
+	if c.TransferMaxAmount > 0 && c.TransferMinAmount > c.TransferMaxAmount {
+		invalid("TRANSFER_MIN_AMOUNT: %d is greater than TRANSFER_MAX_AMOUNT %d", c.TransferMinAmount, c.TransferMaxAmount)
+	}
+
+	return errors.Join(errs...)
+}
+
+// DSN - строка подключения к БД: DBURL или URL из отдельных параметров.
+func (c Config) DSN() string {
+	if c.DBURL != "" {
+		return c.DBURL
+	}
+
+	host := c.DBHost
+	if c.DBPort != "" {
+		host = net.JoinHostPort(c.DBHost, c.DBPort)
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.DBUser, c.DBPassword),
+		Host:     host,
+		Path:     "/" + c.DBName,
+		RawQuery: url.Values{"sslmode": {c.DBSSLMode}}.Encode(),
+	}
+
+	return dsn.String()
+}
 
-	return Config{
-		DBHost:     os.Getenv("DB_HOST"),
-		DBPort:     os.Getenv("DB_PORT"),
-		DBUser:     os.Getenv("DB_USER"),
-		DBPassword: os.Getenv("DB_PASSWORD"),
-		DBName:     os.Getenv("DB_NAME"),
-	}, err
+// Effective - действующие значения всех параметров с источниками; секреты скрыты.
+func (c Config) Effective() []Setting {
+	return c.effective
 }
@@ -0,0 +1,149 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"avito_coin/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfigFile - файл конфигурации во временном каталоге.
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+// effective - действующие значения параметров по имени.
+func effective(cfg config.Config) map[string]config.Setting {
+	settings := make(map[string]config.Setting)
+	for _, setting := range cfg.Effective() {
+		settings[setting.Key] = setting
+	}
+
+	return settings
+}
+
+// Тест: флаги важнее окружения, окружение важнее файла, файл важнее значений по умолчанию.
+func TestLoadConfigLayers(t *testing.T) {
+	path := writeConfigFile(t, `
+http:
+  addr: ":9000"
+  write_timeout: 1m
+db:
+  name: coins
+  password: from-file
+  ssl_mode: require
+log_level: debug
+reserved_usernames: [root, admin]
+`)
+
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("HTTP_ADDR", ":9001")
+	t.Setenv("DB_HOST", "db.internal")
+
+	cfg, err := config.LoadConfig([]string{"-http-addr", ":9002", "-feature-feed=false"})
+	require.NoError(t, err)
+
+	assert.Equal(t, ":9002", cfg.HTTPAddr)
+	assert.Equal(t, time.Minute, cfg.HTTPWriteTimeout)
+	assert.Equal(t, 30*time.Second, cfg.HTTPReadTimeout)
+	assert.Equal(t, 5*time.Second, cfg.ShutdownDrainDelay)
+	assert.Equal(t, "db.internal", cfg.DBHost)
+	assert.Equal(t, "require", cfg.DBSSLMode)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, []string{"root", "admin"}, cfg.ReservedUsernames)
+	assert.False(t, cfg.FeatureFeed)
+	assert.Equal(t, 1000, cfg.InitialBalance)
+	assert.Equal(t, "postgres://:from-file@db.internal:5432/coins?sslmode=require", cfg.DSN())
+
+	settings := effective(cfg)
+	assert.Equal(t, config.Setting{Key: "HTTP_ADDR", Value: ":9002", Source: config.SourceFlag}, settings["HTTP_ADDR"])
+	assert.Equal(t, config.SourceEnv, settings["DB_HOST"].Source)
+	assert.Equal(t, config.SourceFile, settings["DB_SSL_MODE"].Source)
+	assert.Equal(t, config.SourceDefault, settings["DB_PORT"].Source)
+	assert.Equal(t, "******", settings["DB_PASSWORD"].Value)
+}
+
+// Тест: все ошибки конфигурации возвращаются сразу и называют параметр и источник.
+func TestLoadConfigValidation(t *testing.T) {
+	path := writeConfigFile(t, `
+db_name: coins
+db_ssl_mode: sometimes
+http_adr: ":9000"
+`)
+
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("DB_MAX_OPEN_CONNS", "ten")
+	t.Setenv("TLS_CERT_FILE", "cert.pem")
+	t.Setenv("TRACING_EXPORTER", "jaeger")
+	t.Setenv("IDEMPOTENCY_LEASE", "10s")
+
+	_, err := config.LoadConfig([]string{"-log-level", "loud"})
+	require.Error(t, err)
+
+	for _, message := range []string{
+		`DB_MAX_OPEN_CONNS (env): "ten" is not a non-negative integer`,
+		`HTTP_ADR (file): unknown setting`,
+		`DB_SSL_MODE: "sometimes" is not one of`,
+		`TLS_CERT_FILE and TLS_KEY_FILE must be set together`,
+		`LOG_LEVEL: "loud" is not a valid level`,
+		`TRACING_EXPORTER: "jaeger" is not one of`,
+		`IDEMPOTENCY_LEASE: 10s is not longer than HTTP_WRITE_TIMEOUT 30s`,
+	} {
+		assert.Contains(t, err.Error(), message)
+	}
+}
+
+// Тест: в строке подключения скрывается только пароль.
+func TestLoadConfigDatabaseURL(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://coin:secret@db:5432/coins?sslmode=verify-full")
+
+	cfg, err := config.LoadConfig(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "postgres://coin:secret@db:5432/coins?sslmode=verify-full", cfg.DSN())
+	assert.Equal(t, "postgres://coin:xxxxx@db:5432/coins?sslmode=verify-full", effective(cfg)["DATABASE_URL"].Value)
+}
+
+// Тест: явно заданный 0 не заменяется значением по умолчанию, а там, где 0 не имеет смысла, отклоняется.
+func TestLoadConfigExplicitZero(t *testing.T) {
+	t.Setenv("DB_NAME", "coins")
+	t.Setenv("INITIAL_BALANCE", "0")
+	t.Setenv("LOW_STOCK_THRESHOLD", "0")
+	t.Setenv("DB_MAX_IDLE_CONNS", "0")
+	t.Setenv("HTTP_IDLE_TIMEOUT", "0s")
+	t.Setenv("SHUTDOWN_DRAIN_DELAY", "0s")
+
+	cfg, err := config.LoadConfig(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, cfg.InitialBalance)
+	assert.Equal(t, 0, cfg.LowStockThreshold)
+	assert.Equal(t, 0, cfg.DBMaxIdleConns)
+	assert.Equal(t, time.Duration(0), cfg.HTTPIdleTimeout)
+	assert.Equal(t, time.Duration(0), cfg.ShutdownDrainDelay)
+	assert.Equal(t, config.Setting{Key: "INITIAL_BALANCE", Value: "0", Source: config.SourceEnv}, effective(cfg)["INITIAL_BALANCE"])
+
+	t.Setenv("CLEANUP_INTERVAL", "0")
+	t.Setenv("ARGON2_ITERATIONS", "0")
+	t.Setenv("BCRYPT_COST", "0")
+
+	_, err = config.LoadConfig(nil)
+	require.Error(t, err)
+
+	for _, message := range []string{
+		"CLEANUP_INTERVAL: must be positive",
+		"ARGON2_ITERATIONS: must be positive",
+		"BCRYPT_COST: 0 is not between 4 and 31",
+	} {
+		assert.Contains(t, err.Error(), message)
+	}
+}
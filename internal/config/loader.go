@@ -0,0 +1,385 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Источники значений параметров по возрастанию приоритета.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// redacted - значение секрета в выводе конфигурации.
+const redacted = "******"
+
+// Setting - действующее значение параметра конфигурации и его источник.
+type Setting struct {
+	// Key - имя переменной окружения параметра.
+	Key    string
+	Value  string
+	Source string
+}
+
+// param - параметр конфигурации: имя переменной окружения, описание флага и признак секрета.
+// Имя флага - имя переменной в нижнем регистре через дефис (HTTP_ADDR -> -http-addr),
+// ключ файла конфигурации - имя в нижнем регистре (http_addr) или вложенные секции (http: addr:).
+type param struct {
+	key    string
+	usage  string
+	secret bool
+}
+
+// params - все параметры конфигурации.
+var params = []param{
+	{key: "HTTP_ADDR", usage: "адрес HTTP-сервера"},
+	{key: "HTTP_READ_TIMEOUT", usage: "таймаут чтения запроса"},
+	{key: "HTTP_READ_HEADER_TIMEOUT", usage: "таймаут чтения заголовков запроса"},
+	{key: "HTTP_WRITE_TIMEOUT", usage: "таймаут записи ответа"},
+	{key: "HTTP_IDLE_TIMEOUT", usage: "таймаут простоя keep-alive подключения"},
+	{key: "TLS_CERT_FILE", usage: "файл сертификата TLS"},
+	{key: "TLS_KEY_FILE", usage: "файл ключа TLS"},
+	{key: "SHUTDOWN_DRAIN_DELAY", usage: "задержка остановки после снятия готовности"},
+	{key: "SHUTDOWN_TIMEOUT", usage: "время на завершение запросов при остановке"},
+
+	{key: "DATABASE_URL", usage: "строка подключения к БД", secret: true},
+	{key: "DB_HOST", usage: "хост БД"},
+	{key: "DB_PORT", usage: "порт БД"},
+	{key: "DB_USER", usage: "пользователь БД"},
+	{key: "DB_PASSWORD", usage: "пароль БД", secret: true},
+	{key: "DB_NAME", usage: "имя БД"},
+	{key: "DB_SSL_MODE", usage: "режим SSL подключения к БД"},
+	{key: "DB_MAX_OPEN_CONNS", usage: "наибольшее число подключений к БД"},
+	{key: "DB_MAX_IDLE_CONNS", usage: "наибольшее число простаивающих подключений к БД"},
+	{key: "DB_CONN_MAX_LIFETIME", usage: "время жизни подключения к БД"},
+	{key: "DB_CONN_MAX_IDLE_TIME", usage: "время простоя подключения к БД"},
+
+	{key: "LOG_LEVEL", usage: "уровень логирования"},
+
+	{key: "TRACING_EXPORTER", usage: "экспорт трейсов: none, stdout или otlp"},
+	{key: "TRACING_OTLP_ENDPOINT", usage: "URL коллектора OTLP/HTTP"},
+	{key: "TRACING_SERVICE_NAME", usage: "имя сервиса в трейсах"},
+
+	{key: "IDEMPOTENCY_TTL", usage: "время хранения ответов по ключу идемпотентности"},
+	{key: "IDEMPOTENCY_LEASE", usage: "сколько ключ идемпотентности без ответа занят выполняющимся запросом"},
+	{key: "CLEANUP_INTERVAL", usage: "период фоновой очистки"},
+
+	{key: "PASSWORD_ALGORITHM", usage: "алгоритм хеширования паролей"},
+	{key: "ARGON2_MEMORY_KIB", usage: "память argon2id в КиБ"},
+	{key: "ARGON2_ITERATIONS", usage: "итерации argon2id"},
+	{key: "ARGON2_PARALLELISM", usage: "потоки argon2id"},
+	{key: "BCRYPT_COST", usage: "стоимость bcrypt"},
+
+	{key: "JWT_KEYS_DIR", usage: "каталог ключей подписи JWT"},
+	{key: "JWT_SECRET", usage: "секрет HS256", secret: true},
+	{key: "JWT_SECRET_KID", usage: "ID ключа JWT_SECRET"},
+	{key: "JWT_ACTIVE_KID", usage: "ID ключа для подписи новых токенов"},
+	{key: "JWT_ALLOW_EPHEMERAL", usage: "без ключей подписывать токены случайным ключом (только для разработки)"},
+	{key: "ACCESS_TOKEN_TTL", usage: "время жизни access-токена"},
+	{key: "REFRESH_TOKEN_TTL", usage: "время жизни refresh-токена"},
+
+	{key: "INITIAL_BALANCE", usage: "стартовый баланс сотрудника"},
+
+	{key: "AUTH_AUTO_REGISTER", usage: "регистрировать неизвестных пользователей при входе"},
+	{key: "FEATURE_FEED", usage: "включить ленту благодарностей"},
+	{key: "USERNAME_MIN_LENGTH", usage: "минимальная длина имени пользователя"},
+	{key: "USERNAME_MAX_LENGTH", usage: "максимальная длина имени пользователя"},
+	{key: "PASSWORD_MIN_LENGTH", usage: "минимальная длина пароля"},
+	{key: "RESERVED_USERNAMES", usage: "запрещенные имена через запятую"},
+	{key: "REGISTRATION_INVITE_CODES", usage: "коды приглашения через запятую", secret: true},
+	{key: "REGISTRATION_ALLOWED_DOMAINS", usage: "разрешенные домены через запятую"},
+
+	{key: "ROLES_SEED", usage: "роли, выдаваемые при запуске"},
+
+	{key: "MERCH_CATALOG_FILE", usage: "файл каталога мерча"},
+	{key: "LOW_STOCK_THRESHOLD", usage: "порог предупреждения об остатке товара"},
+	{key: "RESERVATION_TTL", usage: "время жизни резерва товара"},
+
+	{key: "TRANSFER_MIN_AMOUNT", usage: "наименьшая сумма перевода"},
+	{key: "TRANSFER_MAX_AMOUNT", usage: "наибольшая сумма перевода"},
+	{key: "TRANSFER_DAILY_OUTGOING", usage: "лимит отправки монет за день"},
+	{key: "TRANSFER_WEEKLY_OUTGOING", usage: "лимит отправки монет за неделю"},
+	{key: "TRANSFER_DAILY_INCOMING", usage: "лимит получения монет за день"},
+	{key: "TRANSFER_WEEKLY_INCOMING", usage: "лимит получения монет за неделю"},
+	{key: "TRANSFER_DAILY_RECIPIENTS", usage: "лимит получателей за день"},
+	{key: "TRANSFER_ROLE_LIMITS", usage: "лимиты переводов ролей"},
+}
+
+// loader - чтение параметров из флагов, окружения и файла конфигурации с накоплением ошибок.
+type loader struct {
+	flags map[string]string
+	file  map[string]string
+	// secrets - параметры, значения которых скрываются в выводе конфигурации.
+	secrets   map[string]bool
+	effective []Setting
+	errs      []error
+}
+
+// newLoader - разбор флагов args, загрузка файла .env и файла конфигурации.
+func newLoader(args []string) (*loader, error) {
+	l := &loader{
+		flags:   make(map[string]string),
+		file:    make(map[string]string),
+		secrets: make(map[string]bool),
+	}
+
+	fs := flag.NewFlagSet("avito_coin", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configFile := fs.String("config", "", "файл конфигурации (.yaml, .yml или .json)")
+
+	values := make(map[string]*string, len(params))
+	for _, p := range params {
+		values[p.key] = fs.String(flagName(p.key), "", p.usage+" ("+p.key+")")
+		l.secrets[p.key] = p.secret
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(os.Stderr)
+			fs.PrintDefaults()
+		}
+
+		return nil, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		for key, value := range values {
+			if flagName(key) == f.Name {
+				l.flags[key] = *value
+			}
+		}
+	})
+
+	// Файл .env не обязателен, переменные окружения в нем не переопределяются
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load .env: %w", err)
+	}
+
+	path := *configFile
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+
+	if path != "" {
+		if err := l.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// flagName - имя флага параметра: HTTP_ADDR -> http-addr.
+func flagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// readFile - чтение файла конфигурации YAML или JSON. Вложенные секции склеиваются в имя параметра
+// через "_": db: {ssl_mode: require} - то же, что DB_SSL_MODE=require; списки - через запятую.
+func (l *loader) readFile(path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		return fmt.Errorf("unsupported config file format %q", ext)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	flatten("", raw, l.file)
+
+	return nil
+}
+
+// flatten - значения вложенных секций файла конфигурации под именами параметров.
+func flatten(prefix string, raw map[string]interface{}, values map[string]string) {
+	for name, value := range raw {
+		key := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch value := value.(type) {
+		case map[string]interface{}:
+			flatten(key, value, values)
+		case []interface{}:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// lookup - значение параметра из самого приоритетного источника. Пустая переменная окружения считается незаданной.
+func (l *loader) lookup(key string) (string, string, bool) {
+	if value, ok := l.flags[key]; ok {
+		return value, SourceFlag, true
+	}
+
+	if value := os.Getenv(key); value != "" {
+		return value, SourceEnv, true
+	}
+
+	if value, ok := l.file[key]; ok {
+		return value, SourceFile, true
+	}
+
+	return "", SourceDefault, false
+}
+
+// record - запоминание действующего значения параметра.
+func (l *loader) record(key, value, source string) {
+	if l.secrets[key] && value != "" {
+		// В строке подключения скрывается только пароль
+		if dsn, err := url.Parse(value); err == nil && dsn.User != nil {
+			value = dsn.Redacted()
+		} else {
+			value = redacted
+		}
+	}
+
+	l.effective = append(l.effective, Setting{Key: key, Value: value, Source: source})
+}
+
+// invalid - ошибка в значении параметра.
+func (l *loader) invalid(key, source, format string, args ...interface{}) {
+	l.errs = append(l.errs, fmt.Errorf("%s (%s): %s", key, source, fmt.Sprintf(format, args...)))
+}
+
+// string - строковый параметр.
+func (l *loader) string(key, def string) string {
+	value, source, ok := l.lookup(key)
+	if !ok {
+		value = def
+	}
+
+	l.record(key, value, source)
+
+	return value
+}
+
+// duration - неотрицательная длительность (например, "24h"); значение по умолчанию - только если параметр не задан.
+func (l *loader) duration(key string, def time.Duration) time.Duration {
+	raw, source, ok := l.lookup(key)
+	if !ok {
+		l.record(key, def.String(), source)
+		return def
+	}
+
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 {
+		l.invalid(key, source, "%q is not a non-negative duration like 30s or 24h", raw)
+		return def
+	}
+
+	l.record(key, value.String(), source)
+
+	return value
+}
+
+// int - целое неотрицательное число; значение по умолчанию - только если параметр не задан.
+func (l *loader) int(key string, def int) int {
+	raw, source, ok := l.lookup(key)
+	if !ok {
+		l.record(key, strconv.Itoa(def), source)
+		return def
+	}
+
+	value, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || value < 0 {
+		l.invalid(key, source, "%q is not a non-negative integer", raw)
+		return def
+	}
+
+	l.record(key, strconv.Itoa(value), source)
+
+	return value
+}
+
+// bool - логическое значение (true, false, 1, 0).
+func (l *loader) bool(key string, def bool) bool {
+	raw, source, ok := l.lookup(key)
+	if !ok {
+		l.record(key, strconv.FormatBool(def), source)
+		return def
+	}
+
+	value, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		l.invalid(key, source, "%q is not a boolean", raw)
+		return def
+	}
+
+	l.record(key, strconv.FormatBool(value), source)
+
+	return value
+}
+
+// list - список через запятую, пустые элементы отбрасываются.
+func (l *loader) list(key string) []string {
+	raw, source, _ := l.lookup(key)
+
+	var list []string
+
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	l.record(key, strings.Join(list, ","), source)
+
+	return list
+}
+
+// finish - ошибки разбора параметров, включая неизвестные параметры в файле конфигурации.
+func (l *loader) finish() []error {
+	known := make(map[string]bool, len(l.effective))
+	for _, setting := range l.effective {
+		known[setting.Key] = true
+	}
+
+	var unknown []string
+
+	for key := range l.file {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+
+	sort.Strings(unknown)
+
+	for _, key := range unknown {
+		l.errs = append(l.errs, fmt.Errorf("%s (%s): unknown setting", key, SourceFile))
+	}
+
+	return l.errs
+}
@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- Баланс не может уйти в минус даже при ошибке в коде сервиса
+ALTER TABLE users
+ADD CONSTRAINT users_balance_non_negative CHECK (balance >= 0);
+
+-- +goose Down
+
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_balance_non_negative;
@@ -0,0 +1,114 @@
+-- +goose Up
+
+-- Счета: пользовательские, магазин и эмиссия (источник всех монет)
+CREATE TABLE accounts (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('user', 'shop', 'issuance')),
+    user_id INT UNIQUE REFERENCES users(id),
+    balance INT NOT NULL DEFAULT 0, -- Кэш суммы проводок, ведется только для пользовательских счетов
+    CONSTRAINT accounts_user_id_matches_kind CHECK ((kind = 'user') = (user_id IS NOT NULL)),
+    CONSTRAINT accounts_balance_non_negative CHECK (balance >= 0)
+);
+
+-- Системные счета существуют в единственном экземпляре
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_system_kind
+ON accounts (kind) WHERE user_id IS NULL;
+
+-- Журнальные записи (одна запись - одна бизнес-операция)
+CREATE TABLE journal_entries (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('opening', 'issuance', 'transfer', 'purchase', 'adjustment')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Проводки: движение монет по счетам, сумма проводок одной записи равна нулю
+CREATE TABLE postings (
+    id SERIAL PRIMARY KEY,
+    entry_id INT NOT NULL REFERENCES journal_entries(id),
+    account_id INT NOT NULL REFERENCES accounts(id),
+    amount INT NOT NULL CHECK (amount <> 0)
+);
+
+-- Индекс для выписки по счету
+CREATE INDEX IF NOT EXISTS idx_postings_account_entry
+ON postings (account_id, entry_id);
+
+-- Индекс для проверки баланса записи
+CREATE INDEX IF NOT EXISTS idx_postings_entry_id
+ON postings (entry_id);
+
+-- Проверка на коммите: проводки каждой записи в сумме дают ноль
+-- +goose StatementBegin
+CREATE FUNCTION check_journal_entry_balanced() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT SUM(amount) FROM postings WHERE entry_id = NEW.entry_id) <> 0 THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE CONSTRAINT TRIGGER postings_balanced
+AFTER INSERT ON postings
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
+
+-- Переводы и покупки ссылаются на журнальную запись, которая их провела
+ALTER TABLE transactions ADD COLUMN entry_id INT REFERENCES journal_entries(id);
+ALTER TABLE purchases ADD COLUMN entry_id INT REFERENCES journal_entries(id);
+
+-- Системные счета и счета существующих пользователей
+INSERT INTO accounts (kind) VALUES ('issuance'), ('shop');
+INSERT INTO accounts (kind, user_id) SELECT 'user', id FROM users;
+
+-- Текущие балансы переносятся входящими остатками со счета эмиссии
+-- +goose StatementBegin
+DO $$
+DECLARE
+    v_issuance_id INT := (SELECT id FROM accounts WHERE kind = 'issuance');
+    v_entry_id INT;
+    opening RECORD;
+BEGIN
+    FOR opening IN
+        SELECT a.id AS account_id, u.balance
+        FROM users u
+        JOIN accounts a ON a.user_id = u.id
+        WHERE u.balance > 0
+    LOOP
+        INSERT INTO journal_entries (kind) VALUES ('opening') RETURNING id INTO v_entry_id;
+        INSERT INTO postings (entry_id, account_id, amount)
+        VALUES (v_entry_id, v_issuance_id, -opening.balance), (v_entry_id, opening.account_id, opening.balance);
+    END LOOP;
+END $$;
+-- +goose StatementEnd
+
+UPDATE accounts a
+SET balance = u.balance
+FROM users u
+WHERE a.user_id = u.id;
+
+-- Баланс больше не хранится в таблице пользователей
+ALTER TABLE users DROP COLUMN balance;
+
+-- +goose Down
+
+ALTER TABLE users ADD COLUMN balance INT NOT NULL DEFAULT 1000;
+
+UPDATE users u
+SET balance = a.balance
+FROM accounts a
+WHERE a.user_id = u.id;
+
+ALTER TABLE users
+ADD CONSTRAINT users_balance_non_negative CHECK (balance >= 0);
+
+ALTER TABLE purchases DROP COLUMN IF EXISTS entry_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS entry_id;
+
+DROP TRIGGER IF EXISTS postings_balanced ON postings;
+DROP FUNCTION IF EXISTS check_journal_entry_balanced();
+
+DROP TABLE IF EXISTS postings;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS accounts;
@@ -0,0 +1,21 @@
+-- +goose Up
+
+-- Ключи идемпотентности: повторный запрос с тем же ключом получает сохраненный ответ
+CREATE TABLE idempotency_keys (
+    user_id INT NOT NULL REFERENCES users(id),
+    key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL, -- SHA-256 метода, пути и тела запроса
+    status_code INT,               -- NULL, пока запрос выполняется
+    response_body BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+-- Индекс для очистки просроченных ключей
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at
+ON idempotency_keys (expires_at);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS idempotency_keys;
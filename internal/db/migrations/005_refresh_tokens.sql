@@ -0,0 +1,44 @@
+-- +goose Up
+
+-- Токены, выпущенные раньше этого момента, недействительны (выход со всех устройств)
+ALTER TABLE users
+ADD COLUMN tokens_valid_after TIMESTAMP;
+
+-- Refresh-токены: хранится только SHA-256, каждый токен одноразовый и при обмене заменяется новым из той же цепочки
+CREATE TABLE refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    family_id VARCHAR(64) NOT NULL,                                   -- цепочка токенов одного входа
+    parent_id INT REFERENCES refresh_tokens(id) ON DELETE SET NULL,   -- токен, в обмен на который выдан этот
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,                                                -- когда токен обменян на новый
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id
+ON refresh_tokens (family_id);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id
+ON refresh_tokens (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at
+ON refresh_tokens (expires_at);
+
+-- Отозванные access-токены; запись нужна только до истечения токена
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at
+ON revoked_tokens (expires_at);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS tokens_valid_after;
@@ -0,0 +1,19 @@
+-- +goose Up
+
+-- Роли пользователей; права ролей описаны в коде сервиса
+CREATE TABLE user_roles (
+    user_id INT NOT NULL REFERENCES users(id),
+    role VARCHAR(32) NOT NULL CHECK (role IN ('employee', 'manager', 'shop-admin', 'finance-admin', 'admin')),
+    granted_by INT REFERENCES users(id), -- NULL, если роль выдана при регистрации или из конфигурации
+    granted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role)
+);
+
+-- Все существующие пользователи - сотрудники
+INSERT INTO user_roles (user_id, role)
+SELECT id, 'employee'
+FROM users;
+
+-- +goose Down
+
+DROP TABLE IF EXISTS user_roles;
@@ -0,0 +1,27 @@
+-- +goose Up
+
+-- Снятый с продажи мерч не удаляется, чтобы история покупок ссылалась на существующие товары
+ALTER TABLE merch
+ADD COLUMN active BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Журнал изменений каталога: состояние товара после каждого изменения
+CREATE TABLE merch_audit (
+    id SERIAL PRIMARY KEY,
+    merch_id INT NOT NULL REFERENCES merch(id),
+    action VARCHAR(16) NOT NULL CHECK (action IN ('create', 'update', 'deactivate', 'activate')),
+    actor_id INT REFERENCES users(id), -- NULL, если изменение сделано не администратором
+    name VARCHAR(255) NOT NULL,
+    price INT NOT NULL,
+    active BOOLEAN NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_merch_audit_merch_id
+ON merch_audit (merch_id, changed_at);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS merch_audit;
+
+ALTER TABLE merch
+DROP COLUMN IF EXISTS active;
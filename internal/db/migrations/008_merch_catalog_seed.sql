@@ -0,0 +1,31 @@
+-- +goose Up
+
+-- Стандартный каталог магазина. Товары, добавленные вручную до миграции, не меняются
+INSERT INTO merch (name, price)
+VALUES
+    ('t-shirt', 80),
+    ('cup', 20),
+    ('book', 50),
+    ('pen', 10),
+    ('powerbank', 200),
+    ('hoody', 300),
+    ('umbrella', 200),
+    ('socks', 10),
+    ('wallet', 50),
+    ('pink-hoody', 500)
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+
+-- Удаляются только товары, которые никто не покупал
+DELETE FROM merch_audit
+WHERE merch_id IN (
+    SELECT m.id
+    FROM merch m
+    WHERE m.name IN ('t-shirt', 'cup', 'book', 'pen', 'powerbank', 'hoody', 'umbrella', 'socks', 'wallet', 'pink-hoody')
+      AND NOT EXISTS (SELECT 1 FROM purchases p WHERE p.merch_id = m.id)
+);
+
+DELETE FROM merch m
+WHERE m.name IN ('t-shirt', 'cup', 'book', 'pen', 'powerbank', 'hoody', 'umbrella', 'socks', 'wallet', 'pink-hoody')
+  AND NOT EXISTS (SELECT 1 FROM purchases p WHERE p.merch_id = m.id);
@@ -0,0 +1,47 @@
+-- +goose Up
+
+-- Остаток товара на складе; NULL - остаток не ведется и товар не заканчивается
+ALTER TABLE merch
+ADD COLUMN stock INT CHECK (stock >= 0);
+
+-- Остаток после изменения и пополнение склада в журнале изменений каталога
+ALTER TABLE merch_audit
+ADD COLUMN stock INT;
+
+ALTER TABLE merch_audit
+DROP CONSTRAINT IF EXISTS merch_audit_action_check;
+
+ALTER TABLE merch_audit
+ADD CONSTRAINT merch_audit_action_check CHECK (action IN ('create', 'update', 'deactivate', 'activate', 'restock'));
+
+-- Резервы товара: единица товара списывается со склада при резервировании
+-- и возвращается, если резерв отменен или истек до покупки
+CREATE TABLE merch_reservations (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    merch_id INT NOT NULL REFERENCES merch(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_merch_reservations_expires_at
+ON merch_reservations (expires_at);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS merch_reservations;
+
+DELETE FROM merch_audit
+WHERE action = 'restock';
+
+ALTER TABLE merch_audit
+DROP CONSTRAINT IF EXISTS merch_audit_action_check;
+
+ALTER TABLE merch_audit
+ADD CONSTRAINT merch_audit_action_check CHECK (action IN ('create', 'update', 'deactivate', 'activate'));
+
+ALTER TABLE merch_audit
+DROP COLUMN IF EXISTS stock;
+
+ALTER TABLE merch
+DROP COLUMN IF EXISTS stock;
@@ -0,0 +1,43 @@
+-- +goose Up
+
+-- Варианты товара (размер, цвет) со своим артикулом, остатком и необязательной ценой.
+-- Товар с вариантами покупается только с указанием варианта.
+CREATE TABLE merch_variants (
+    id SERIAL PRIMARY KEY,
+    merch_id INT NOT NULL REFERENCES merch(id),
+    sku VARCHAR(64) UNIQUE NOT NULL,
+    size VARCHAR(16),
+    color VARCHAR(32),
+    price INT CHECK (price > 0), -- NULL - действует цена товара
+    stock INT CHECK (stock >= 0), -- NULL - остаток не ведется
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE INDEX IF NOT EXISTS idx_merch_variants_merch_id
+ON merch_variants (merch_id);
+
+ALTER TABLE purchases
+ADD COLUMN variant_id INT REFERENCES merch_variants(id);
+
+ALTER TABLE merch_reservations
+ADD COLUMN variant_id INT REFERENCES merch_variants(id);
+
+-- Артикул варианта, если изменение относится к варианту, а не к самому товару
+ALTER TABLE merch_audit
+ADD COLUMN variant VARCHAR(64);
+
+-- +goose Down
+
+DELETE FROM merch_audit
+WHERE variant IS NOT NULL;
+
+ALTER TABLE merch_audit
+DROP COLUMN IF EXISTS variant;
+
+ALTER TABLE merch_reservations
+DROP COLUMN IF EXISTS variant_id;
+
+ALTER TABLE purchases
+DROP COLUMN IF EXISTS variant_id;
+
+DROP TABLE IF EXISTS merch_variants;
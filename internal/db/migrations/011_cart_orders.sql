@@ -0,0 +1,48 @@
+-- +goose Up
+
+-- Корзина: позиции с ценой единицы на момент добавления.
+-- Если цена изменилась, оформление заказа отклоняется, чтобы пользователь увидел новую сумму
+CREATE TABLE cart_items (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    merch_id INT NOT NULL REFERENCES merch(id),
+    variant_id INT REFERENCES merch_variants(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    price INT NOT NULL,
+    added_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Одна позиция на товар или вариант: повторное добавление увеличивает количество
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cart_items_user_item
+ON cart_items (user_id, merch_id, COALESCE(variant_id, 0));
+
+-- Заказы: оплата всей корзины одной журнальной записью
+CREATE TABLE orders (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    entry_id INT NOT NULL REFERENCES journal_entries(id),
+    total INT NOT NULL CHECK (total > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id
+ON orders (user_id);
+
+-- Покупки заказа и цена единицы, по которой они оплачены
+ALTER TABLE purchases
+ADD COLUMN order_id INT REFERENCES orders(id);
+
+ALTER TABLE purchases
+ADD COLUMN price INT;
+
+-- +goose Down
+
+ALTER TABLE purchases
+DROP COLUMN IF EXISTS price;
+
+ALTER TABLE purchases
+DROP COLUMN IF EXISTS order_id;
+
+DROP TABLE IF EXISTS orders;
+
+DROP TABLE IF EXISTS cart_items;
@@ -0,0 +1,66 @@
+-- +goose Up
+
+-- Статус выдачи заказа, место получения и комментарий покупателя.
+-- Покупки до появления заказов остаются без заказа и считаются выданными
+ALTER TABLE orders
+ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT 'placed'
+    CHECK (status IN ('placed', 'approved', 'ready_for_pickup', 'delivered', 'cancelled'));
+
+ALTER TABLE orders
+ADD COLUMN pickup_location VARCHAR(128);
+
+ALTER TABLE orders
+ADD COLUMN notes VARCHAR(500);
+
+ALTER TABLE orders
+ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- Журнальная запись возврата монет за отмененный заказ
+ALTER TABLE orders
+ADD COLUMN refund_entry_id INT REFERENCES journal_entries(id);
+
+CREATE INDEX IF NOT EXISTS idx_orders_status
+ON orders (status);
+
+ALTER TABLE journal_entries
+DROP CONSTRAINT IF EXISTS journal_entries_kind_check;
+
+ALTER TABLE journal_entries
+ADD CONSTRAINT journal_entries_kind_check CHECK (kind IN ('opening', 'issuance', 'transfer', 'purchase', 'adjustment', 'refund'));
+
+-- История статусов заказа: кто и когда перевел заказ в статус
+CREATE TABLE order_events (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES orders(id),
+    status VARCHAR(32) NOT NULL,
+    actor_id INT REFERENCES users(id),
+    note VARCHAR(500),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_events_order_id
+ON order_events (order_id);
+
+INSERT INTO order_events (order_id, status, actor_id, created_at)
+SELECT id, 'placed', user_id, created_at
+FROM orders;
+
+-- +goose Down
+
+-- Записи возврата остаются в журнале: без них не сойдутся балансы, поэтому тип 'refund' не удаляется
+DROP TABLE IF EXISTS order_events;
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS refund_entry_id;
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS notes;
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS pickup_location;
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS status;
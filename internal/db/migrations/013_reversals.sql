@@ -0,0 +1,46 @@
+-- +goose Up
+
+ALTER TABLE journal_entries
+DROP CONSTRAINT IF EXISTS journal_entries_kind_check;
+
+ALTER TABLE journal_entries
+ADD CONSTRAINT journal_entries_kind_check CHECK (kind IN ('opening', 'issuance', 'transfer', 'purchase', 'adjustment', 'refund', 'reversal'));
+
+-- Сторно перевода или возврат покупки: компенсирующая запись, причина и администратор.
+-- Частичных сторно может быть несколько, но в сумме не больше исходной операции
+CREATE TABLE reversals (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT REFERENCES transactions(id),
+    purchase_id INT REFERENCES purchases(id),
+    entry_id INT NOT NULL REFERENCES journal_entries(id),
+    amount INT NOT NULL CHECK (amount > 0),
+    reason VARCHAR(500) NOT NULL,
+    actor_id INT REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT reversals_single_target CHECK ((transaction_id IS NULL) <> (purchase_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_reversals_transaction_id
+ON reversals (transaction_id);
+
+CREATE INDEX IF NOT EXISTS idx_reversals_purchase_id
+ON reversals (purchase_id);
+
+-- Полностью возвращенная покупка не входит в инвентарь
+ALTER TABLE purchases
+ADD COLUMN returned_at TIMESTAMP;
+
+-- Цена покупок до появления заказов берется из их проводки по счету пользователя
+UPDATE purchases p
+SET price = -po.amount
+FROM postings po
+JOIN accounts a ON a.id = po.account_id AND a.kind = 'user'
+WHERE p.price IS NULL AND p.order_id IS NULL AND po.entry_id = p.entry_id;
+
+-- +goose Down
+
+-- Записи сторно остаются в журнале: без них не сойдутся балансы, поэтому тип 'reversal' не удаляется
+ALTER TABLE purchases
+DROP COLUMN IF EXISTS returned_at;
+
+DROP TABLE IF EXISTS reversals;
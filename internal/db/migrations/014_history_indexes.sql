@@ -0,0 +1,32 @@
+-- +goose Up
+
+-- Индексы для постраничной истории /api/history: строки пользователя читаются от новых к старым,
+-- поэтому время и ID входят в индекс в порядке сортировки, а курсор продолжает чтение с места остановки
+
+-- Отправленные и полученные переводы
+CREATE INDEX IF NOT EXISTS idx_transactions_from_user_time
+ON transactions (from_user, transaction_time DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_to_user_time
+ON transactions (to_user, transaction_time DESC, id DESC);
+
+-- Полученные переводы от конкретного отправителя; отправленные конкретному получателю
+-- покрывает idx_transactions_user_time (from_user, to_user, transaction_time)
+CREATE INDEX IF NOT EXISTS idx_transactions_to_from_user_time
+ON transactions (to_user, from_user, transaction_time DESC);
+
+-- Покупки пользователя; заменяет idx_purchases_user_id
+CREATE INDEX IF NOT EXISTS idx_purchases_user_time
+ON purchases (user_id, purchase_time DESC, id DESC);
+
+DROP INDEX IF EXISTS idx_purchases_user_id;
+
+-- +goose Down
+
+CREATE INDEX IF NOT EXISTS idx_purchases_user_id
+ON purchases (user_id);
+
+DROP INDEX IF EXISTS idx_purchases_user_time;
+DROP INDEX IF EXISTS idx_transactions_to_from_user_time;
+DROP INDEX IF EXISTS idx_transactions_to_user_time;
+DROP INDEX IF EXISTS idx_transactions_from_user_time;
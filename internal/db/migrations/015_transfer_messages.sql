@@ -0,0 +1,36 @@
+-- +goose Up
+
+-- Сообщение к переводу и видимость перевода в ленте благодарностей.
+-- По умолчанию перевод не публикуется: в ленту попадают только переводы, которые отправитель открыл сам
+ALTER TABLE transactions
+ADD COLUMN message VARCHAR(500);
+
+ALTER TABLE transactions
+ADD COLUMN public BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Теги перевода, например ценности компании
+CREATE TABLE transaction_tags (
+    transaction_id INT NOT NULL REFERENCES transactions(id),
+    tag VARCHAR(32) NOT NULL,
+    PRIMARY KEY (transaction_id, tag)
+);
+
+-- Лента благодарностей с фильтром по тегу
+CREATE INDEX IF NOT EXISTS idx_transaction_tags_tag
+ON transaction_tags (tag);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_public_time
+ON transactions (transaction_time DESC, id DESC)
+WHERE public;
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_transactions_public_time;
+
+DROP TABLE IF EXISTS transaction_tags;
+
+ALTER TABLE transactions
+DROP COLUMN IF EXISTS public;
+
+ALTER TABLE transactions
+DROP COLUMN IF EXISTS message;
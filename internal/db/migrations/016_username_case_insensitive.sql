@@ -0,0 +1,11 @@
+-- +goose Up
+
+-- Имена пользователей уникальны без учета регистра: Alice и alice - один пользователь.
+-- Если в базе уже есть имена, которые отличаются только регистром, миграция не применится,
+-- пока их не переименуют
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_lower_key
+ON users (lower(username));
+
+-- +goose Down
+
+DROP INDEX IF EXISTS users_username_lower_key;
@@ -6,12 +6,110 @@ package db
 
 import (
 	"database/sql"
+	"time"
 )
 
+type Account struct {
+	ID      int32
+	Kind    string
+	UserID  sql.NullInt32
+	Balance int32
+}
+
+type CartItem struct {
+	ID        int32
+	UserID    int32
+	MerchID   int32
+	VariantID sql.NullInt32
+	Quantity  int32
+	Price     int32
+	AddedAt   time.Time
+}
+
+type IdempotencyKey struct {
+	UserID       int32
+	Key          string
+	Fingerprint  string
+	StatusCode   sql.NullInt32
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+type JournalEntry struct {
+	ID        int32
+	Kind      string
+	CreatedAt time.Time
+}
+
 type Merch struct {
-	ID    int32
-	Name  string
-	Price int32
+	ID     int32
+	Name   string
+	Price  int32
+	Active bool
+	Stock  sql.NullInt32
+}
+
+type MerchAudit struct {
+	ID        int32
+	MerchID   int32
+	Action    string
+	ActorID   sql.NullInt32
+	Name      string
+	Price     int32
+	Active    bool
+	ChangedAt time.Time
+	Stock     sql.NullInt32
+	Variant   sql.NullString
+}
+
+type MerchReservation struct {
+	ID        int32
+	UserID    int32
+	MerchID   int32
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	VariantID sql.NullInt32
+}
+
+type MerchVariant struct {
+	ID      int32
+	MerchID int32
+	Sku     string
+	Size    sql.NullString
+	Color   sql.NullString
+	Price   sql.NullInt32
+	Stock   sql.NullInt32
+	Active  bool
+}
+
+type Order struct {
+	ID             int32
+	UserID         int32
+	EntryID        int32
+	Total          int32
+	CreatedAt      time.Time
+	Status         string
+	PickupLocation sql.NullString
+	Notes          sql.NullString
+	UpdatedAt      time.Time
+	RefundEntryID  sql.NullInt32
+}
+
+type OrderEvent struct {
+	ID        int32
+	OrderID   int32
+	Status    string
+	ActorID   sql.NullInt32
+	Note      sql.NullString
+	CreatedAt time.Time
+}
+
+type Posting struct {
+	ID        int32
+	EntryID   int32
+	AccountID int32
+	Amount    int32
 }
 
 type Purchase struct {
@@ -19,6 +117,39 @@ type Purchase struct {
 	UserID       sql.NullInt32
 	MerchID      sql.NullInt32
 	PurchaseTime sql.NullTime
+	EntryID      sql.NullInt32
+	VariantID    sql.NullInt32
+	OrderID      sql.NullInt32
+	Price        sql.NullInt32
+	ReturnedAt   sql.NullTime
+}
+
+type RefreshToken struct {
+	ID        int32
+	UserID    int32
+	FamilyID  string
+	ParentID  sql.NullInt32
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	RevokedAt sql.NullTime
+}
+
+type Reversal struct {
+	ID            int32
+	TransactionID sql.NullInt32
+	PurchaseID    sql.NullInt32
+	EntryID       int32
+	Amount        int32
+	Reason        string
+	ActorID       sql.NullInt32
+	CreatedAt     time.Time
+}
+
+type RevokedToken struct {
+	Jti       string
+	ExpiresAt time.Time
 }
 
 type Transaction struct {
@@ -27,11 +158,26 @@ type Transaction struct {
 	ToUser          sql.NullInt32
 	Amount          int32
 	TransactionTime sql.NullTime
+	EntryID         sql.NullInt32
+	Message         sql.NullString
+	Public          bool
+}
+
+type TransactionTag struct {
+	TransactionID int32
+	Tag           string
 }
 
 type User struct {
-	ID       int32
-	Username string
-	Password string
-	Balance  int32
+	ID               int32
+	Username         string
+	Password         string
+	TokensValidAfter sql.NullTime
+}
+
+type UserRole struct {
+	UserID    int32
+	Role      string
+	GrantedBy sql.NullInt32
+	GrantedAt time.Time
 }
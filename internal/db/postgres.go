@@ -1,38 +1,74 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"embed"
-	"fmt"
+	"io/fs"
+	"strings"
 
 	"avito_coin/internal/config"
+	"github.com/XSAM/otelsql"
 	// Импортируем драйвер для работы с PostgreSQL через database/sql.
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 //go:embed migrations/*.sql
 var EmbedMigrations embed.FS
 
+// NewPostgresDB - подключение к БД с настройками пула из конфигурации и применение миграций.
 func NewPostgresDB(cfg config.Config) (*sql.DB, error) {
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName,
-	)
-
-	db, err := sql.Open("pgx", dsn)
+	db, err := ConnectPostgresDB(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	db.SetMaxOpenConns(1000)
-	db.SetMaxIdleConns(100)
-
 	if err = MigrateDB(db); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	return db, err
+	return db, nil
+}
+
+// ConnectPostgresDB - подключение к БД с настройками пула из конфигурации без применения миграций.
+// Каждый запрос и транзакция записываются в трейс отдельным спаном.
+func ConnectPostgresDB(cfg config.Config) (*sql.DB, error) {
+	db, err := otelsql.Open("pgx", cfg.DSN(),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanNameFormatter(querySpanName),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+
+	return db, nil
+}
+
+// querySpanName - имя спана запроса: имя запроса sqlc из комментария "-- name: GetUserBalance :one"
+// или операция database/sql для остальных запросов, транзакций и подключений.
+func querySpanName(_ context.Context, method otelsql.Method, query string) string {
+	name, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return string(method)
+	}
+
+	name, _, _ = strings.Cut(name, " ")
+
+	return "db." + name
 }
 
 func MigrateDB(db *sql.DB) error {
@@ -48,3 +84,60 @@ func MigrateDB(db *sql.DB) error {
 
 	return nil
 }
+
+// LatestMigrationVersion - версия последней миграции, встроенной в сервис.
+func LatestMigrationVersion() (int64, error) {
+	entries, err := fs.ReadDir(EmbedMigrations, "migrations")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+
+	for _, entry := range entries {
+		version, err := goose.NumericComponent(entry.Name())
+		if err != nil {
+			return 0, err
+		}
+
+		latest = max(latest, version)
+	}
+
+	return latest, nil
+}
+
+// MigrationVersion - версия миграций, примененных к БД. В отличие от goose.GetDBVersion
+// не создает таблицу версий, если ее нет.
+func MigrationVersion(ctx context.Context, db *sql.DB) (int64, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version_id, is_applied FROM goose_db_version ORDER BY id DESC")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	// Откаченная миграция помечается строкой с is_applied = false поверх строки применения
+	rolledBack := make(map[int64]bool)
+
+	for rows.Next() {
+		var (
+			version int64
+			applied bool
+		)
+
+		if err := rows.Scan(&version, &applied); err != nil {
+			return 0, err
+		}
+
+		if rolledBack[version] {
+			continue
+		}
+
+		if applied {
+			return version, nil
+		}
+
+		rolledBack[version] = true
+	}
+
+	return 0, rows.Err()
+}
@@ -10,22 +10,6 @@ import (
 	"database/sql"
 )
 
-const buyMerch = `-- name: BuyMerch :exec
-INSERT INTO purchases (user_id, merch_id)
-VALUES ($1, $2)
-`
-
-type BuyMerchParams struct {
-	UserID  sql.NullInt32
-	MerchID sql.NullInt32
-}
-
-// Покупка товара пользователем
-func (q *Queries) BuyMerch(ctx context.Context, arg BuyMerchParams) error {
-	_, err := q.db.ExecContext(ctx, buyMerch, arg.UserID, arg.MerchID)
-	return err
-}
-
 const createMerch = `-- name: CreateMerch :exec
 INSERT INTO merch (name, price)
 VALUES ($1, $2)
@@ -62,9 +46,10 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (int32,
 const getMerchPrice = `-- name: GetMerchPrice :one
 SELECT price 
 FROM merch 
-WHERE id = $1
+WHERE id = $1 AND active
 `
 
+// Цена товара в продаже: снятый с продажи товар купить нельзя
 func (q *Queries) GetMerchPrice(ctx context.Context, id int32) (int32, error) {
 	row := q.db.QueryRowContext(ctx, getMerchPrice, id)
 	var price int32
@@ -73,22 +58,36 @@ func (q *Queries) GetMerchPrice(ctx context.Context, id int32) (int32, error) {
 }
 
 const getTransactions = `-- name: GetTransactions :many
-SELECT t.from_user, t.to_user, t.amount, t.transaction_time
+SELECT t.id, t.from_user, fu.username AS from_username, t.to_user, tu.username AS to_username, t.amount, t.transaction_time,
+       t.message, COALESCE((SELECT string_agg(tt.tag, ',' ORDER BY tt.tag) FROM transaction_tags tt WHERE tt.transaction_id = t.id), '')::varchar AS tags
 FROM transactions t
+JOIN users fu ON fu.id = t.from_user
+JOIN users tu ON tu.id = t.to_user
 WHERE t.from_user = $1 OR t.to_user = $1
-ORDER BY t.transaction_time DESC
+ORDER BY t.transaction_time DESC, t.id DESC
+LIMIT $2
 `
 
+type GetTransactionsParams struct {
+	FromUser sql.NullInt32
+	Limit    int32
+}
+
 type GetTransactionsRow struct {
+	ID              int32
 	FromUser        sql.NullInt32
+	FromUsername    string
 	ToUser          sql.NullInt32
+	ToUsername      string
 	Amount          int32
 	TransactionTime sql.NullTime
+	Message         sql.NullString
+	Tags            string
 }
 
-// Получение списка транзакций для пользователя (кто кому передавал монеты и в каком количестве)
-func (q *Queries) GetTransactions(ctx context.Context, fromUser sql.NullInt32) ([]GetTransactionsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getTransactions, fromUser)
+// Последние транзакции пользователя с именами отправителя и получателя, сообщением и тегами через запятую
+func (q *Queries) GetTransactions(ctx context.Context, arg GetTransactionsParams) ([]GetTransactionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTransactions, arg.FromUser, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -97,10 +96,15 @@ func (q *Queries) GetTransactions(ctx context.Context, fromUser sql.NullInt32) (
 	for rows.Next() {
 		var i GetTransactionsRow
 		if err := rows.Scan(
+			&i.ID,
 			&i.FromUser,
+			&i.FromUsername,
 			&i.ToUser,
+			&i.ToUsername,
 			&i.Amount,
 			&i.TransactionTime,
+			&i.Message,
+			&i.Tags,
 		); err != nil {
 			return nil, err
 		}
@@ -116,32 +120,76 @@ func (q *Queries) GetTransactions(ctx context.Context, fromUser sql.NullInt32) (
 }
 
 const getUserBalance = `-- name: GetUserBalance :one
-SELECT balance 
-FROM users 
-WHERE id = $1
+SELECT balance
+FROM accounts
+WHERE user_id = $1
 `
 
-func (q *Queries) GetUserBalance(ctx context.Context, id int32) (int32, error) {
-	row := q.db.QueryRowContext(ctx, getUserBalance, id)
+func (q *Queries) GetUserBalance(ctx context.Context, userID sql.NullInt32) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getUserBalance, userID)
 	var balance int32
 	err := row.Scan(&balance)
 	return balance, err
 }
 
+const getUserInventory = `-- name: GetUserInventory :many
+SELECT m.name, v.sku AS variant, COUNT(*)::int AS quantity
+FROM purchases p
+JOIN merch m ON p.merch_id = m.id
+LEFT JOIN merch_variants v ON p.variant_id = v.id
+LEFT JOIN orders o ON p.order_id = o.id
+WHERE p.user_id = $1 AND p.returned_at IS NULL AND (o.status IS NULL OR o.status <> 'cancelled')
+GROUP BY m.name, v.sku
+ORDER BY m.name, v.sku
+`
+
+type GetUserInventoryRow struct {
+	Name     string
+	Variant  sql.NullString
+	Quantity int32
+}
+
+// Инвентарь пользователя: количество каждого товара и варианта без возвращенных покупок и отмененных заказов
+func (q *Queries) GetUserInventory(ctx context.Context, userID sql.NullInt32) ([]GetUserInventoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserInventory, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserInventoryRow
+	for rows.Next() {
+		var i GetUserInventoryRow
+		if err := rows.Scan(&i.Name, &i.Variant, &i.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserPurchases = `-- name: GetUserPurchases :many
-SELECT m.name, p.purchase_time 
+SELECT m.name, v.sku AS variant, p.purchase_time
 FROM purchases p
 JOIN merch m ON p.merch_id = m.id
-WHERE p.user_id = $1
+LEFT JOIN merch_variants v ON p.variant_id = v.id
+LEFT JOIN orders o ON p.order_id = o.id
+WHERE p.user_id = $1 AND p.returned_at IS NULL AND (o.status IS NULL OR o.status <> 'cancelled')
 ORDER BY p.purchase_time DESC
 `
 
 type GetUserPurchasesRow struct {
 	Name         string
+	Variant      sql.NullString
 	PurchaseTime sql.NullTime
 }
 
-// Получение списка всех покупок пользователя
+// Получение списка покупок пользователя с артикулом варианта без возвращенных покупок и отмененных заказов
 func (q *Queries) GetUserPurchases(ctx context.Context, userID sql.NullInt32) ([]GetUserPurchasesRow, error) {
 	rows, err := q.db.QueryContext(ctx, getUserPurchases, userID)
 	if err != nil {
@@ -151,7 +199,7 @@ func (q *Queries) GetUserPurchases(ctx context.Context, userID sql.NullInt32) ([
 	var items []GetUserPurchasesRow
 	for rows.Next() {
 		var i GetUserPurchasesRow
-		if err := rows.Scan(&i.Name, &i.PurchaseTime); err != nil {
+		if err := rows.Scan(&i.Name, &i.Variant, &i.PurchaseTime); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -165,43 +213,57 @@ func (q *Queries) GetUserPurchases(ctx context.Context, userID sql.NullInt32) ([
 	return items, nil
 }
 
-const transferCoins = `-- name: TransferCoins :exec
-INSERT INTO transactions (from_user, to_user, amount)
-VALUES ($1, $2, $3)
+const transferCoins = `-- name: TransferCoins :one
+INSERT INTO transactions (from_user, to_user, amount, entry_id, message, public)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id
 `
 
 type TransferCoinsParams struct {
 	FromUser sql.NullInt32
 	ToUser   sql.NullInt32
 	Amount   int32
+	EntryID  sql.NullInt32
+	Message  sql.NullString
+	Public   bool
 }
 
-// Перевод монет от одного пользователя к другому
-func (q *Queries) TransferCoins(ctx context.Context, arg TransferCoinsParams) error {
-	_, err := q.db.ExecContext(ctx, transferCoins, arg.FromUser, arg.ToUser, arg.Amount)
-	return err
+// Перевод монет от одного пользователя к другому с необязательным сообщением
+func (q *Queries) TransferCoins(ctx context.Context, arg TransferCoinsParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, transferCoins,
+		arg.FromUser,
+		arg.ToUser,
+		arg.Amount,
+		arg.EntryID,
+		arg.Message,
+		arg.Public,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
 }
 
-const updateUserBalance = `-- name: UpdateUserBalance :exec
+const updateUserPassword = `-- name: UpdateUserPassword :exec
 UPDATE users
-SET balance = $1
-WHERE id = $2
+SET password = $2
+WHERE id = $1
 `
 
-type UpdateUserBalanceParams struct {
-	Balance int32
-	ID      int32
+type UpdateUserPasswordParams struct {
+	ID       int32
+	Password string
 }
 
-func (q *Queries) UpdateUserBalance(ctx context.Context, arg UpdateUserBalanceParams) error {
-	_, err := q.db.ExecContext(ctx, updateUserBalance, arg.Balance, arg.ID)
+// Замена хеша пароля (перехеширование при входе)
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.Password)
 	return err
 }
 
 const userExists = `-- name: UserExists :one
 SELECT id, password
 FROM users
-WHERE username = $1
+WHERE lower(username) = lower($1)
 `
 
 type UserExistsRow struct {
@@ -209,6 +271,7 @@ type UserExistsRow struct {
 	Password string
 }
 
+// Поиск пользователя по имени без учета регистра
 func (q *Queries) UserExists(ctx context.Context, username string) (UserExistsRow, error) {
 	row := q.db.QueryRowContext(ctx, userExists, username)
 	var i UserExistsRow
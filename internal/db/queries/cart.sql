@@ -0,0 +1,45 @@
+-- name: AddCartItem :exec
+-- Добавление товара в корзину; повторное добавление увеличивает количество и обновляет цену
+INSERT INTO cart_items (user_id, merch_id, variant_id, quantity, price)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, merch_id, COALESCE(variant_id, 0)) DO UPDATE
+SET quantity = cart_items.quantity + EXCLUDED.quantity,
+    price = EXCLUDED.price;
+
+-- name: ListCartItems :many
+SELECT c.id, c.merch_id, m.name, v.sku AS variant, c.quantity, c.price
+FROM cart_items c
+JOIN merch m ON m.id = c.merch_id
+LEFT JOIN merch_variants v ON v.id = c.variant_id
+WHERE c.user_id = $1
+ORDER BY c.id;
+
+-- name: GetCartForCheckout :many
+-- Блокировка корзины на время оформления заказа
+SELECT c.id, c.merch_id, v.sku AS variant, c.quantity, c.price
+FROM cart_items c
+LEFT JOIN merch_variants v ON v.id = c.variant_id
+WHERE c.user_id = $1
+ORDER BY c.id
+FOR UPDATE OF c;
+
+-- name: RepriceCart :execrows
+-- Обновление цен в корзине до текущих, возвращает число позиций, цена которых изменилась
+UPDATE cart_items c
+SET price = p.price
+FROM (
+    SELECT ci.id, COALESCE(v.price, m.price) AS price
+    FROM cart_items ci
+    JOIN merch m ON m.id = ci.merch_id
+    LEFT JOIN merch_variants v ON v.id = ci.variant_id
+    WHERE ci.user_id = $1
+) p
+WHERE c.id = p.id AND c.price <> p.price;
+
+-- name: RemoveCartItem :execrows
+DELETE FROM cart_items
+WHERE id = $1 AND user_id = $2;
+
+-- name: ClearCart :exec
+DELETE FROM cart_items
+WHERE user_id = $1;
@@ -0,0 +1,37 @@
+-- name: GetUserHistory :many
+-- Страница истории пользователя: переводы и покупки от новых к старым.
+-- Фильтры необязательны; курсор - время, тип и ID последней записи предыдущей страницы
+SELECT h.id, h.type, h.direction, h.amount, h.counterparty_id, h.counterparty, h.item, h.variant, h.message,
+       COALESCE((SELECT string_agg(tt.tag, ',' ORDER BY tt.tag) FROM transaction_tags tt
+                 WHERE h.type = 'transfer' AND tt.transaction_id = h.id), '')::varchar AS tags,
+       h.created_at
+FROM (
+    SELECT t.id, 'transfer'::varchar AS type, 'outgoing'::varchar AS direction, t.amount,
+           t.to_user AS counterparty_id, u.username AS counterparty,
+           NULL::varchar AS item, NULL::varchar AS variant, t.message, t.transaction_time AS created_at
+    FROM transactions t
+    JOIN users u ON u.id = t.to_user
+    WHERE t.from_user = sqlc.arg(user_id)
+    UNION ALL
+    SELECT t.id, 'transfer', 'incoming', t.amount, t.from_user, u.username, NULL, NULL, t.message, t.transaction_time
+    FROM transactions t
+    JOIN users u ON u.id = t.from_user
+    WHERE t.to_user = sqlc.arg(user_id)
+    UNION ALL
+    SELECT p.id, 'purchase', 'outgoing', COALESCE(p.price, 0), NULL, NULL, m.name, v.sku, NULL, p.purchase_time
+    FROM purchases p
+    JOIN merch m ON m.id = p.merch_id
+    LEFT JOIN merch_variants v ON v.id = p.variant_id
+    WHERE p.user_id = sqlc.arg(user_id)
+) h
+WHERE (sqlc.narg(type)::varchar IS NULL OR h.type = sqlc.narg(type))
+  AND (sqlc.narg(direction)::varchar IS NULL OR h.direction = sqlc.narg(direction))
+  AND (sqlc.narg(counterparty_id)::int IS NULL OR h.counterparty_id = sqlc.narg(counterparty_id))
+  AND (sqlc.narg(min_amount)::int IS NULL OR h.amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::int IS NULL OR h.amount <= sqlc.narg(max_amount))
+  AND (sqlc.narg(since)::timestamp IS NULL OR h.created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamp IS NULL OR h.created_at < sqlc.narg(until))
+  AND (sqlc.narg(cursor_time)::timestamp IS NULL
+       OR (h.created_at, h.type, h.id) < (sqlc.narg(cursor_time), sqlc.narg(cursor_type)::varchar, sqlc.narg(cursor_id)::int))
+ORDER BY h.created_at DESC, h.type DESC, h.id DESC
+LIMIT sqlc.arg(page_size);
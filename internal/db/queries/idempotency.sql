@@ -0,0 +1,34 @@
+-- name: CreateIdempotencyKey :execrows
+-- Резервирование ключа; просроченный ключ с тем же значением занимается заново. Ключ того же запроса
+-- без ответа, резерв которого старше lease_seconds (процесс упал посреди запроса), тоже занимается заново
+INSERT INTO idempotency_keys (user_id, key, fingerprint, expires_at)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP + sqlc.arg(ttl_seconds)::int * INTERVAL '1 second')
+ON CONFLICT (user_id, key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint,
+    status_code = NULL,
+    response_body = NULL,
+    created_at = CURRENT_TIMESTAMP,
+    expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at < CURRENT_TIMESTAMP
+   OR (idempotency_keys.status_code IS NULL
+       AND idempotency_keys.fingerprint = EXCLUDED.fingerprint
+       AND idempotency_keys.created_at < CURRENT_TIMESTAMP - sqlc.arg(lease_seconds)::int * INTERVAL '1 second');
+
+-- name: GetIdempotencyKey :one
+SELECT fingerprint, status_code, response_body
+FROM idempotency_keys
+WHERE user_id = $1 AND key = $2;
+
+-- name: CompleteIdempotencyKey :exec
+-- Сохранение ответа на выполненный запрос
+UPDATE idempotency_keys
+SET status_code = $3, response_body = $4
+WHERE user_id = $1 AND key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE user_id = $1 AND key = $2;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at < CURRENT_TIMESTAMP;
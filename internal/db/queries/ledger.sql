@@ -0,0 +1,86 @@
+-- name: CreateUserAccount :one
+-- Открытие счета пользователя
+INSERT INTO accounts (kind, user_id)
+VALUES ('user', $1)
+RETURNING id;
+
+-- name: GetUserAccountID :one
+SELECT id
+FROM accounts
+WHERE user_id = $1;
+
+-- name: GetSystemAccountID :one
+-- Получение системного счета (магазин или эмиссия)
+SELECT id
+FROM accounts
+WHERE kind = $1 AND user_id IS NULL;
+
+-- name: LockAccount :one
+-- Блокировка счета до конца транзакции
+SELECT balance
+FROM accounts
+WHERE id = $1
+FOR UPDATE;
+
+-- name: DebitAccount :execrows
+-- Списание со счета, только если на нем достаточно монет
+UPDATE accounts
+SET balance = balance - sqlc.arg(amount)
+WHERE id = sqlc.arg(id) AND balance >= sqlc.arg(amount);
+
+-- name: CreditAccount :execrows
+-- Зачисление на счет
+UPDATE accounts
+SET balance = balance + sqlc.arg(amount)
+WHERE id = sqlc.arg(id);
+
+-- name: CreateJournalEntry :one
+INSERT INTO journal_entries (kind)
+VALUES ($1)
+RETURNING id;
+
+-- name: CreatePosting :exec
+INSERT INTO postings (entry_id, account_id, amount)
+VALUES ($1, $2, $3);
+
+-- name: GetAccountPostings :many
+-- Выписка по счету пользователя с нарастающим итогом
+SELECT p.id, p.entry_id, e.kind, p.amount, e.created_at,
+       SUM(p.amount) OVER (ORDER BY p.entry_id, p.id)::int AS balance_after
+FROM postings p
+JOIN journal_entries e ON e.id = p.entry_id
+JOIN accounts a ON a.id = p.account_id
+WHERE a.user_id = $1
+ORDER BY p.entry_id, p.id;
+
+-- name: GetLedgerTotals :many
+-- Сумма проводок по каждому типу счетов
+SELECT a.kind, COALESCE(SUM(p.amount), 0)::bigint AS total
+FROM accounts a
+LEFT JOIN postings p ON p.account_id = a.id
+GROUP BY a.kind
+ORDER BY a.kind;
+
+-- name: GetUserBalancesTotal :one
+-- Сумма кэшированных балансов пользователей, без чтения журнала проводок
+SELECT COALESCE(SUM(balance), 0)::bigint AS total
+FROM accounts
+WHERE kind = 'user';
+
+-- name: GetUnbalancedEntries :many
+-- Журнальные записи, проводки которых не сходятся в ноль
+SELECT entry_id, SUM(amount)::bigint AS total
+FROM postings
+GROUP BY entry_id
+HAVING SUM(amount) <> 0
+ORDER BY entry_id;
+
+-- name: GetAccountDiscrepancies :many
+-- Пользовательские счета, кэш баланса которых расходится с проводками
+SELECT a.id, a.user_id, a.balance, COALESCE(SUM(p.amount), 0)::int AS posted
+FROM accounts a
+LEFT JOIN postings p ON p.account_id = a.id
+WHERE a.kind = 'user'
+GROUP BY a.id
+HAVING a.balance <> COALESCE(SUM(p.amount), 0)
+ORDER BY a.id;
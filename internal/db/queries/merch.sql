@@ -0,0 +1,82 @@
+-- name: AddMerch :one
+INSERT INTO merch (name, price)
+VALUES ($1, $2)
+RETURNING id, name, price, active, stock;
+
+-- name: GetMerch :one
+SELECT id, name, price, active, stock
+FROM merch
+WHERE id = $1;
+
+-- name: GetMerchForUpdate :one
+SELECT id, name, price, active, stock
+FROM merch
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetMerchForPurchase :one
+-- Блокировка товара в продаже на время списания остатка
+SELECT price, stock
+FROM merch
+WHERE id = $1 AND active
+FOR UPDATE;
+
+-- name: DecrementMerchStock :exec
+UPDATE merch
+SET stock = stock - 1
+WHERE id = $1 AND stock IS NOT NULL;
+
+-- name: IncrementMerchStock :exec
+UPDATE merch
+SET stock = stock + 1
+WHERE id = $1 AND stock IS NOT NULL;
+
+-- name: AddMerchStock :exec
+UPDATE merch
+SET stock = stock + sqlc.arg(quantity)::int
+WHERE id = sqlc.arg(id) AND stock IS NOT NULL;
+
+-- name: RestockMerch :one
+-- Пополнение склада; у товара без учета остатка учет начинается с нуля
+UPDATE merch
+SET stock = COALESCE(stock, 0) + sqlc.arg(quantity)::int
+WHERE id = sqlc.arg(id)
+RETURNING id, name, price, active, stock;
+
+-- name: UpdateMerch :one
+-- Изменение только переданных полей товара
+UPDATE merch
+SET name = COALESCE(sqlc.narg(name), name),
+    price = COALESCE(sqlc.narg(price), price),
+    active = COALESCE(sqlc.narg(active), active)
+WHERE id = sqlc.arg(id)
+RETURNING id, name, price, active, stock;
+
+-- name: ListMerch :many
+-- Весь каталог, включая снятые с продажи товары
+SELECT id, name, price, active, stock
+FROM merch
+ORDER BY id;
+
+-- name: ListLowStockMerch :many
+-- Товары и варианты в продаже, остаток которых не больше порога; у товара sku пустой
+SELECT id, name, NULL::text AS sku, price, stock::int AS stock
+FROM merch
+WHERE active AND stock IS NOT NULL AND stock <= sqlc.arg(threshold)::int
+UNION ALL
+SELECT m.id, m.name, v.sku, COALESCE(v.price, m.price)::int, v.stock
+FROM merch_variants v
+JOIN merch m ON m.id = v.merch_id
+WHERE m.active AND v.active AND v.stock IS NOT NULL AND v.stock <= sqlc.arg(threshold)::int
+ORDER BY stock, id, sku;
+
+-- name: AddMerchAudit :exec
+INSERT INTO merch_audit (merch_id, action, actor_id, name, price, active, stock, variant)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: GetMerchAudit :many
+SELECT a.action, u.username AS actor, a.name, a.variant, a.price, a.active, a.stock, a.changed_at
+FROM merch_audit a
+LEFT JOIN users u ON u.id = a.actor_id
+WHERE a.merch_id = $1
+ORDER BY a.changed_at, a.id;
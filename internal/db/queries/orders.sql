@@ -0,0 +1,63 @@
+-- name: CreateOrder :one
+INSERT INTO orders (user_id, entry_id, total, pickup_location, notes)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, entry_id, total, created_at, status, pickup_location, notes, updated_at, refund_entry_id;
+
+-- name: AddOrderPurchase :exec
+-- Покупка в составе заказа с ценой единицы
+INSERT INTO purchases (user_id, merch_id, variant_id, entry_id, order_id, price)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetOrder :one
+SELECT id, user_id, entry_id, total, created_at, status, pickup_location, notes, updated_at, refund_entry_id
+FROM orders
+WHERE id = $1;
+
+-- name: ListUserOrders :many
+SELECT id, user_id, entry_id, total, created_at, status, pickup_location, notes, updated_at, refund_entry_id
+FROM orders
+WHERE user_id = $1
+ORDER BY id DESC;
+
+-- name: ListOrders :many
+-- Заказы для выдачи, необязательный фильтр по статусу
+SELECT id, user_id, entry_id, total, created_at, status, pickup_location, notes, updated_at, refund_entry_id
+FROM orders
+WHERE sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)
+ORDER BY id;
+
+-- name: GetOrderItems :many
+-- Состав заказа: количество единиц каждого товара или варианта по цене покупки
+SELECT p.merch_id, m.name, p.variant_id, v.sku AS variant, COUNT(*)::int AS quantity, p.price
+FROM purchases p
+JOIN merch m ON m.id = p.merch_id
+LEFT JOIN merch_variants v ON v.id = p.variant_id
+WHERE p.order_id = $1
+GROUP BY p.merch_id, m.name, p.variant_id, v.sku, p.price
+ORDER BY MIN(p.id);
+
+-- name: UpdateOrderStatus :one
+-- Смена статуса заказа, если его не изменили с момента проверки перехода
+UPDATE orders
+SET status = sqlc.arg(status),
+    pickup_location = COALESCE(sqlc.narg(pickup_location), pickup_location),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status)
+RETURNING id, user_id, entry_id, total, created_at, status, pickup_location, notes, updated_at, refund_entry_id;
+
+-- name: SetOrderRefund :exec
+UPDATE orders
+SET refund_entry_id = $2
+WHERE id = $1;
+
+-- name: AddOrderEvent :exec
+INSERT INTO order_events (order_id, status, actor_id, note)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetOrderEvents :many
+-- История статусов заказа с именами тех, кто их менял
+SELECT e.status, u.username AS actor, e.note, e.created_at
+FROM order_events e
+LEFT JOIN users u ON u.id = e.actor_id
+WHERE e.order_id = $1
+ORDER BY e.id;
@@ -7,47 +7,63 @@ RETURNING id;
 INSERT INTO merch (name, price)
 VALUES ($1, $2);
 
+-- name: UpdateUserPassword :exec
+-- Замена хеша пароля (перехеширование при входе)
+UPDATE users
+SET password = $2
+WHERE id = $1;
+
 -- name: UserExists :one
+-- Поиск пользователя по имени без учета регистра
 SELECT id, password
 FROM users
-WHERE username = $1;
+WHERE lower(username) = lower($1);
 
 -- name: GetUserBalance :one
-SELECT balance 
-FROM users 
-WHERE id = $1;
+SELECT balance
+FROM accounts
+WHERE user_id = $1;
 
 -- name: GetMerchPrice :one
+-- Цена товара в продаже: снятый с продажи товар купить нельзя
 SELECT price 
 FROM merch 
-WHERE id = $1;
-
--- name: UpdateUserBalance :exec
-UPDATE users
-SET balance = $1
-WHERE id = $2;
+WHERE id = $1 AND active;
 
--- name: TransferCoins :exec
--- Перевод монет от одного пользователя к другому
-INSERT INTO transactions (from_user, to_user, amount)
-VALUES ($1, $2, $3);
-
--- name: BuyMerch :exec
--- Покупка товара пользователем
-INSERT INTO purchases (user_id, merch_id)
-VALUES ($1, $2);
+-- name: TransferCoins :one
+-- Перевод монет от одного пользователя к другому с необязательным сообщением
+INSERT INTO transactions (from_user, to_user, amount, entry_id, message, public)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id;
 
 -- name: GetUserPurchases :many
--- Получение списка всех покупок пользователя
-SELECT m.name, p.purchase_time 
+-- Получение списка покупок пользователя с артикулом варианта без возвращенных покупок и отмененных заказов
+SELECT m.name, v.sku AS variant, p.purchase_time
 FROM purchases p
 JOIN merch m ON p.merch_id = m.id
-WHERE p.user_id = $1
+LEFT JOIN merch_variants v ON p.variant_id = v.id
+LEFT JOIN orders o ON p.order_id = o.id
+WHERE p.user_id = $1 AND p.returned_at IS NULL AND (o.status IS NULL OR o.status <> 'cancelled')
 ORDER BY p.purchase_time DESC;
 
+-- name: GetUserInventory :many
+-- Инвентарь пользователя: количество каждого товара и варианта без возвращенных покупок и отмененных заказов
+SELECT m.name, v.sku AS variant, COUNT(*)::int AS quantity
+FROM purchases p
+JOIN merch m ON p.merch_id = m.id
+LEFT JOIN merch_variants v ON p.variant_id = v.id
+LEFT JOIN orders o ON p.order_id = o.id
+WHERE p.user_id = $1 AND p.returned_at IS NULL AND (o.status IS NULL OR o.status <> 'cancelled')
+GROUP BY m.name, v.sku
+ORDER BY m.name, v.sku;
+
 -- name: GetTransactions :many
--- Получение списка транзакций для пользователя (кто кому передавал монеты и в каком количестве)
-SELECT t.from_user, t.to_user, t.amount, t.transaction_time
+-- Последние транзакции пользователя с именами отправителя и получателя, сообщением и тегами через запятую
+SELECT t.id, t.from_user, fu.username AS from_username, t.to_user, tu.username AS to_username, t.amount, t.transaction_time,
+       t.message, COALESCE((SELECT string_agg(tt.tag, ',' ORDER BY tt.tag) FROM transaction_tags tt WHERE tt.transaction_id = t.id), '')::varchar AS tags
 FROM transactions t
+JOIN users fu ON fu.id = t.from_user
+JOIN users tu ON tu.id = t.to_user
 WHERE t.from_user = $1 OR t.to_user = $1
-ORDER BY t.transaction_time DESC;
\ No newline at end of file
+ORDER BY t.transaction_time DESC, t.id DESC
+LIMIT $2;
\ No newline at end of file
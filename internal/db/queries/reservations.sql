@@ -0,0 +1,29 @@
+-- name: CreateReservation :one
+INSERT INTO merch_reservations (user_id, merch_id, variant_id, expires_at)
+VALUES ($1, $2, $3, to_timestamp(sqlc.arg(expires_at)::bigint))
+RETURNING id;
+
+-- name: ConsumeReservation :one
+-- Использование действующего резерва при покупке
+DELETE FROM merch_reservations
+WHERE id = $1 AND user_id = $2 AND merch_id = $3 AND expires_at > CURRENT_TIMESTAMP
+RETURNING variant_id;
+
+-- name: DeleteReservation :one
+DELETE FROM merch_reservations
+WHERE id = $1 AND user_id = $2
+RETURNING merch_id, variant_id;
+
+-- name: ListExpiredReservationItems :many
+-- Товары и варианты, у которых есть истекшие резервы
+SELECT DISTINCT merch_id, variant_id
+FROM merch_reservations
+WHERE expires_at <= CURRENT_TIMESTAMP
+ORDER BY merch_id, variant_id;
+
+-- name: DeleteExpiredItemReservations :execrows
+-- Удаление истекших резервов товара без варианта (variant_id NULL) или варианта товара
+DELETE FROM merch_reservations
+WHERE merch_id = sqlc.arg(merch_id)
+  AND variant_id IS NOT DISTINCT FROM sqlc.narg(variant_id)
+  AND expires_at <= CURRENT_TIMESTAMP;
@@ -0,0 +1,49 @@
+-- name: LockTransaction :one
+-- Блокировка перевода, чтобы параллельные сторно не превысили его сумму
+SELECT id, from_user, to_user, amount, transaction_time, entry_id, message, public
+FROM transactions
+WHERE id = $1
+FOR UPDATE;
+
+-- name: LockPurchaseForRefund :one
+-- Блокировка покупки для возврата со статусом ее заказа
+SELECT p.id, p.user_id, p.merch_id, p.variant_id, p.price, p.returned_at, o.status AS order_status
+FROM purchases p
+LEFT JOIN orders o ON o.id = p.order_id
+WHERE p.id = $1
+FOR UPDATE OF p;
+
+-- name: GetTransactionReversedAmount :one
+SELECT COALESCE(SUM(amount), 0)::int
+FROM reversals
+WHERE transaction_id = $1;
+
+-- name: GetPurchaseRefundedAmount :one
+SELECT COALESCE(SUM(amount), 0)::int
+FROM reversals
+WHERE purchase_id = $1;
+
+-- name: CreateReversal :one
+INSERT INTO reversals (transaction_id, purchase_id, entry_id, amount, reason, actor_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, transaction_id, purchase_id, entry_id, amount, reason, actor_id, created_at;
+
+-- name: MarkPurchaseReturned :exec
+UPDATE purchases
+SET returned_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: GetUserReversals :many
+-- Последние сторно переводов и возвраты покупок пользователя: amount - изменение его баланса
+SELECT r.id,
+       (CASE WHEN r.transaction_id IS NULL THEN 'purchase' ELSE 'transfer' END)::varchar AS type,
+       COALESCE(r.transaction_id, r.purchase_id)::int AS original_id,
+       (CASE WHEN t.to_user = sqlc.arg(user_id) THEN -r.amount ELSE r.amount END)::int AS amount,
+       r.reason,
+       r.created_at
+FROM reversals r
+LEFT JOIN transactions t ON t.id = r.transaction_id
+LEFT JOIN purchases p ON p.id = r.purchase_id
+WHERE t.from_user = sqlc.arg(user_id) OR t.to_user = sqlc.arg(user_id) OR p.user_id = sqlc.arg(user_id)
+ORDER BY r.created_at DESC, r.id DESC
+LIMIT sqlc.arg(max_rows);
@@ -0,0 +1,14 @@
+-- name: AddUserRole :execrows
+INSERT INTO user_roles (user_id, role, granted_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, role) DO NOTHING;
+
+-- name: DeleteUserRole :execrows
+DELETE FROM user_roles
+WHERE user_id = $1 AND role = $2;
+
+-- name: GetUserRoles :many
+SELECT role
+FROM user_roles
+WHERE user_id = $1
+ORDER BY role;
@@ -0,0 +1,63 @@
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (user_id, family_id, parent_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP + sqlc.arg(ttl_seconds)::int * INTERVAL '1 second');
+
+-- name: GetRefreshTokenForUpdate :one
+-- Блокировка refresh-токена на время обмена
+SELECT id,
+       user_id,
+       family_id,
+       used_at IS NOT NULL AS used,
+       (revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP) AS active
+FROM refresh_tokens
+WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: MarkRefreshTokenUsed :exec
+UPDATE refresh_tokens
+SET used_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: RevokeRefreshTokenFamily :execrows
+-- Отзыв всей цепочки, к которой относится токен пользователя
+UPDATE refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE revoked_at IS NULL
+  AND family_id = (
+      SELECT t.family_id
+      FROM refresh_tokens t
+      WHERE t.token_hash = $1 AND t.user_id = $2
+  );
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: SetTokensValidAfter :exec
+-- Время округляется до секунды, как поле iat в токене
+UPDATE users
+SET tokens_valid_after = date_trunc('second', CURRENT_TIMESTAMP)
+WHERE id = $1;
+
+-- name: RevokeAccessToken :exec
+INSERT INTO revoked_tokens (jti, expires_at)
+VALUES ($1, to_timestamp(sqlc.arg(expires_at)::bigint))
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: IsTokenRevoked :one
+-- Токен отозван сам по себе или выпущен до выхода пользователя со всех устройств
+SELECT EXISTS (
+           SELECT 1 FROM revoked_tokens WHERE jti = $1
+       ) OR EXISTS (
+           SELECT 1 FROM users
+           WHERE id = $2 AND tokens_valid_after > to_timestamp(sqlc.arg(issued_at)::bigint)
+       ) AS revoked;
+
+-- name: DeleteExpiredRefreshTokens :execrows
+DELETE FROM refresh_tokens
+WHERE expires_at < CURRENT_TIMESTAMP;
+
+-- name: DeleteExpiredRevokedTokens :execrows
+DELETE FROM revoked_tokens
+WHERE expires_at < CURRENT_TIMESTAMP;
@@ -0,0 +1,34 @@
+-- name: AddTransactionTag :exec
+INSERT INTO transaction_tags (transaction_id, tag)
+VALUES ($1, $2);
+
+-- name: GetTransferTotals :one
+-- Суммы переводов отправителя и получателя с начала дня и недели за вычетом сторно
+-- и количество других получателей отправителя за день
+SELECT
+    COALESCE(SUM(t.amount - r.reversed) FILTER (WHERE t.from_user = sqlc.arg(sender_id)::int AND t.transaction_time >= sqlc.arg(day_start)::timestamp), 0)::bigint AS sent_today,
+    COALESCE(SUM(t.amount - r.reversed) FILTER (WHERE t.from_user = sqlc.arg(sender_id)::int), 0)::bigint AS sent_this_week,
+    COALESCE(SUM(t.amount - r.reversed) FILTER (WHERE t.to_user = sqlc.arg(recipient_id)::int AND t.transaction_time >= sqlc.arg(day_start)::timestamp), 0)::bigint AS received_today,
+    COALESCE(SUM(t.amount - r.reversed) FILTER (WHERE t.to_user = sqlc.arg(recipient_id)::int), 0)::bigint AS received_this_week,
+    COUNT(DISTINCT t.to_user) FILTER (WHERE t.from_user = sqlc.arg(sender_id)::int AND t.to_user <> sqlc.arg(recipient_id)::int
+                                        AND t.transaction_time >= sqlc.arg(day_start)::timestamp AND t.amount > r.reversed) AS other_recipients_today
+FROM transactions t
+CROSS JOIN LATERAL (
+    SELECT COALESCE(SUM(rv.amount), 0)::int AS reversed FROM reversals rv WHERE rv.transaction_id = t.id
+) r
+WHERE (t.from_user = sqlc.arg(sender_id)::int OR t.to_user = sqlc.arg(recipient_id)::int)
+  AND t.transaction_time >= sqlc.arg(week_start)::timestamp;
+
+-- name: ListPublicTransfers :many
+-- Лента благодарностей: открытые переводы от новых к старым с необязательным фильтром по тегу
+SELECT t.id, fu.username AS from_username, tu.username AS to_username, t.amount, t.message,
+       COALESCE((SELECT string_agg(tt.tag, ',' ORDER BY tt.tag) FROM transaction_tags tt WHERE tt.transaction_id = t.id), '')::varchar AS tags,
+       t.transaction_time
+FROM transactions t
+JOIN users fu ON fu.id = t.from_user
+JOIN users tu ON tu.id = t.to_user
+WHERE t.public
+  AND (sqlc.narg(tag)::varchar IS NULL
+       OR EXISTS (SELECT 1 FROM transaction_tags tt WHERE tt.transaction_id = t.id AND tt.tag = sqlc.narg(tag)))
+ORDER BY t.transaction_time DESC, t.id DESC
+LIMIT sqlc.arg(max_rows);
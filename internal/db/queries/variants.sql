@@ -0,0 +1,84 @@
+-- name: AddMerchVariant :one
+INSERT INTO merch_variants (merch_id, sku, size, color, price, stock)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, merch_id, sku, size, color, price, stock, active;
+
+-- name: ListMerchVariants :many
+SELECT id, merch_id, sku, size, color, price, stock, active
+FROM merch_variants
+WHERE merch_id = $1
+ORDER BY id;
+
+-- name: CountActiveMerchVariants :one
+SELECT COUNT(*)
+FROM merch_variants
+WHERE merch_id = $1 AND active;
+
+-- name: GetMerchVariant :one
+SELECT id, merch_id, sku, size, color, price, stock, active
+FROM merch_variants
+WHERE merch_id = $1 AND sku = $2;
+
+-- name: GetMerchVariantForUpdate :one
+SELECT id, merch_id, sku, size, color, price, stock, active
+FROM merch_variants
+WHERE merch_id = $1 AND sku = $2
+FOR UPDATE;
+
+-- name: GetMerchVariantPrice :one
+-- Цена варианта в продаже: своя или цена товара
+SELECT COALESCE(v.price, m.price)::int AS price
+FROM merch_variants v
+JOIN merch m ON m.id = v.merch_id
+WHERE v.merch_id = $1 AND v.sku = $2 AND v.active AND m.active;
+
+-- name: GetVariantPrice :one
+SELECT COALESCE(v.price, m.price)::int AS price
+FROM merch_variants v
+JOIN merch m ON m.id = v.merch_id
+WHERE v.id = $1 AND v.active AND m.active;
+
+-- name: GetMerchVariantForPurchase :one
+-- Блокировка варианта в продаже на время списания остатка
+SELECT v.id, COALESCE(v.price, m.price)::int AS price, v.stock
+FROM merch_variants v
+JOIN merch m ON m.id = v.merch_id
+WHERE v.merch_id = $1 AND v.sku = $2 AND v.active AND m.active
+FOR UPDATE OF v;
+
+-- name: DecrementVariantStock :exec
+UPDATE merch_variants
+SET stock = stock - 1
+WHERE id = $1 AND stock IS NOT NULL;
+
+-- name: IncrementVariantStock :exec
+UPDATE merch_variants
+SET stock = stock + 1
+WHERE id = $1 AND stock IS NOT NULL;
+
+-- name: AddVariantStock :exec
+UPDATE merch_variants
+SET stock = stock + sqlc.arg(quantity)::int
+WHERE id = sqlc.arg(id) AND stock IS NOT NULL;
+
+-- name: LockMerchVariant :exec
+-- Блокировка варианта, в том числе снятого с продажи, на время возврата резервов
+SELECT id
+FROM merch_variants
+WHERE id = $1
+FOR UPDATE;
+
+-- name: RestockMerchVariant :one
+-- Пополнение склада варианта; у варианта без учета остатка учет начинается с нуля
+UPDATE merch_variants
+SET stock = COALESCE(stock, 0) + sqlc.arg(quantity)::int
+WHERE merch_id = sqlc.arg(merch_id) AND sku = sqlc.arg(sku)
+RETURNING id, merch_id, sku, size, color, price, stock, active;
+
+-- name: UpdateMerchVariant :one
+-- Изменение только переданных полей варианта
+UPDATE merch_variants
+SET price = COALESCE(sqlc.narg(price), price),
+    active = COALESCE(sqlc.narg(active), active)
+WHERE merch_id = sqlc.arg(merch_id) AND sku = sqlc.arg(sku)
+RETURNING id, merch_id, sku, size, color, price, stock, active;
@@ -0,0 +1,593 @@
+package handler
+
+import (
+	"net/http"
+
+	"avito_coin/api"
+	"avito_coin/internal/db"
+	"avito_coin/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// GetApiAdminLedgerAudit - обработчик для сверки журнала проводок.
+func (h *CoinHandler) GetAPIAdminLedgerAudit(c echo.Context) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/ledger/audit",
+		"method":   "GET",
+	}).Info("GetApiAdminLedgerAudit request received")
+
+	audit, err := h.service.AuditLedger(c.Request().Context())
+	if err != nil {
+		return respondWithError(c, http.StatusInternalServerError, "Failed to audit ledger", err)
+	}
+
+	return respondWithSuccess(c, api.LedgerAuditResponse{
+		Consistent:        audit.Consistent(),
+		Issued:            audit.Issued,
+		UserBalances:      audit.UserBalances,
+		ShopBalance:       audit.ShopBalance,
+		UnbalancedEntries: len(audit.UnbalancedEntries),
+		Discrepancies:     len(audit.Discrepancies),
+	}, logrus.Fields{
+		"consistent": audit.Consistent(),
+	})
+}
+
+// GetApiAdminMerch - обработчик для получения каталога мерча.
+func (h *CoinHandler) GetAPIAdminMerch(c echo.Context) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/merch",
+		"method":   "GET",
+	}).Info("GetApiAdminMerch request received")
+
+	merch, err := h.service.ListMerch(c.Request().Context())
+	if err != nil {
+		return respondWithError(c, http.StatusInternalServerError, "Failed to list merch", err)
+	}
+
+	items := make([]api.MerchItem, 0, len(merch))
+	for _, m := range merch {
+		items = append(items, merchItem(m))
+	}
+
+	return respondWithSuccess(c, items, logrus.Fields{
+		"items": len(items),
+	})
+}
+
+// PostApiAdminMerch - обработчик для добавления товара в каталог.
+func (h *CoinHandler) PostAPIAdminMerch(c echo.Context) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/merch",
+		"method":   "POST",
+	}).Info("PostApiAdminMerch request received")
+
+	var request api.MerchRequest
+	if err := c.Bind(&request); err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	price, err := validateAmount(request.Price)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid price", err)
+	}
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	merch, err := h.service.AddMerch(c.Request().Context(), actorID, request.Name, price)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, merchItem(merch))
+}
+
+// GetApiAdminMerchLowStock - обработчик для получения заканчивающихся товаров.
+func (h *CoinHandler) GetAPIAdminMerchLowStock(c echo.Context) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/merch/low-stock",
+		"method":   "GET",
+	}).Info("GetApiAdminMerchLowStock request received")
+
+	merch, err := h.service.ListLowStockMerch(c.Request().Context())
+	if err != nil {
+		return respondWithError(c, http.StatusInternalServerError, "Failed to list low stock merch", err)
+	}
+
+	items := make([]api.LowStockItem, 0, len(merch))
+	for _, m := range merch {
+		items = append(items, lowStockItem(m))
+	}
+
+	return respondWithSuccess(c, items, logrus.Fields{
+		"items": len(items),
+	})
+}
+
+// PatchApiAdminMerchId - обработчик для изменения товара.
+func (h *CoinHandler) PatchAPIAdminMerchID(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/merch/:id",
+		"method":   "PATCH",
+	}).Info("PatchApiAdminMerchId request received")
+
+	merchID, err := validateMerchID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Merch not found", err)
+	}
+
+	var request api.MerchUpdateRequest
+	if err := c.Bind(&request); err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	update := service.MerchUpdate{Name: request.Name, Active: request.Active}
+
+	if request.Price != nil {
+		price, err := validateAmount(*request.Price)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid price", err)
+		}
+
+		update.Price = &price
+	}
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	merch, err := h.service.UpdateMerch(c.Request().Context(), actorID, merchID, update)
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, merchItem(merch), logrus.Fields{
+		"merch_id": merch.ID,
+		"actor_id": actorID,
+	})
+}
+
+// DeleteApiAdminMerchId - обработчик для снятия товара с продажи.
+func (h *CoinHandler) DeleteAPIAdminMerchID(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/merch/:id",
+		"method":   "DELETE",
+	}).Info("DeleteApiAdminMerchId request received")
+
+	merchID, err := validateMerchID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Merch not found", err)
+	}
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	merch, err := h.service.DeactivateMerch(c.Request().Context(), actorID, merchID)
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, merchItem(merch), logrus.Fields{
+		"merch_id": merch.ID,
+		"actor_id": actorID,
+	})
+}
+
+// GetApiAdminMerchIdAudit - обработчик для получения журнала изменений товара.
+func (h *CoinHandler) GetAPIAdminMerchIDAudit(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/merch/:id/audit",
+		"method":   "GET",
+	}).Info("GetApiAdminMerchIdAudit request received")
+
+	merchID, err := validateMerchID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Merch not found", err)
+	}
+
+	audit, err := h.service.GetMerchAudit(c.Request().Context(), merchID)
+	if err != nil {
+		return respondWithError(c, http.StatusInternalServerError, "Failed to get merch audit", err)
+	}
+
+	entries := make([]api.MerchAuditEntry, 0, len(audit))
+	for _, a := range audit {
+		entry := api.MerchAuditEntry{
+			Action:    a.Action,
+			Name:      a.Name,
+			Price:     int(a.Price),
+			Active:    a.Active,
+			ChangedAt: a.ChangedAt,
+		}
+
+		if a.Actor.Valid {
+			entry.Actor = &a.Actor.String
+		}
+
+		if a.Stock.Valid {
+			stock := int(a.Stock.Int32)
+			entry.Stock = &stock
+		}
+
+		if a.Variant.Valid {
+			entry.Variant = &a.Variant.String
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return respondWithSuccess(c, entries, logrus.Fields{
+		"merch_id": merchID,
+		"entries":  len(entries),
+	})
+}
+
+// PostApiAdminMerchIdRestock - обработчик для пополнения склада.
+func (h *CoinHandler) PostAPIAdminMerchIDRestock(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/merch/:id/restock",
+		"method":   "POST",
+	}).Info("PostApiAdminMerchIdRestock request received")
+
+	merchID, err := validateMerchID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Merch not found", err)
+	}
+
+	var request api.RestockRequest
+	if err := c.Bind(&request); err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	quantity, err := validateAmount(request.Quantity)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid quantity", err)
+	}
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	merch, err := h.service.RestockMerch(c.Request().Context(), actorID, merchID, quantity)
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, merchItem(merch), logrus.Fields{
+		"merch_id": merch.ID,
+		"quantity": quantity,
+		"actor_id": actorID,
+	})
+}
+
+// GetApiAdminMerchIdVariants - обработчик для получения вариантов товара.
+func (h *CoinHandler) GetAPIAdminMerchIDVariants(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/merch/:id/variants",
+		"method":   "GET",
+	}).Info("GetApiAdminMerchIdVariants request received")
+
+	merchID, err := validateMerchID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Merch not found", err)
+	}
+
+	variants, err := h.service.ListMerchVariants(c.Request().Context(), merchID)
+	if err != nil {
+		return respondWithError(c, http.StatusInternalServerError, "Failed to list merch variants", err)
+	}
+
+	items := make([]api.MerchVariant, 0, len(variants))
+	for _, v := range variants {
+		items = append(items, merchVariant(v))
+	}
+
+	return respondWithSuccess(c, items, logrus.Fields{
+		"merch_id": merchID,
+		"variants": len(items),
+	})
+}
+
+// PostApiAdminMerchIdVariants - обработчик для добавления варианта товара.
+func (h *CoinHandler) PostAPIAdminMerchIDVariants(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/merch/:id/variants",
+		"method":   "POST",
+	}).Info("PostApiAdminMerchIdVariants request received")
+
+	merchID, err := validateMerchID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Merch not found", err)
+	}
+
+	var request api.MerchVariantRequest
+	if err := c.Bind(&request); err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	input := service.MerchVariantInput{Sku: request.Sku, Size: request.Size, Color: request.Color}
+
+	if request.Price != nil {
+		price, err := validateAmount(*request.Price)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid price", err)
+		}
+
+		input.Price = &price
+	}
+
+	if request.Stock != nil {
+		stock, err := validateAmount(*request.Stock)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid stock", err)
+		}
+
+		input.Stock = &stock
+	}
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	variant, err := h.service.AddMerchVariant(c.Request().Context(), actorID, merchID, input)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, merchVariant(variant))
+}
+
+// PatchApiAdminMerchIdVariantsSku - обработчик для изменения варианта товара.
+func (h *CoinHandler) PatchAPIAdminMerchIDVariantsSku(c echo.Context, id int, sku string) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/merch/:id/variants/:sku",
+		"method":   "PATCH",
+	}).Info("PatchApiAdminMerchIdVariantsSku request received")
+
+	merchID, err := validateMerchID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Merch variant not found", err)
+	}
+
+	var request api.MerchVariantUpdateRequest
+	if err := c.Bind(&request); err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	update := service.VariantUpdate{Active: request.Active}
+
+	if request.Price != nil {
+		price, err := validateAmount(*request.Price)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid price", err)
+		}
+
+		update.Price = &price
+	}
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	variant, err := h.service.UpdateMerchVariant(c.Request().Context(), actorID, merchID, sku, update)
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, merchVariant(variant), logrus.Fields{
+		"merch_id": merchID,
+		"sku":      sku,
+		"actor_id": actorID,
+	})
+}
+
+// PostApiAdminMerchIdVariantsSkuRestock - обработчик для пополнения склада варианта.
+func (h *CoinHandler) PostAPIAdminMerchIDVariantsSkuRestock(c echo.Context, id int, sku string) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/merch/:id/variants/:sku/restock",
+		"method":   "POST",
+	}).Info("PostApiAdminMerchIdVariantsSkuRestock request received")
+
+	merchID, err := validateMerchID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Merch variant not found", err)
+	}
+
+	var request api.RestockRequest
+	if err := c.Bind(&request); err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	quantity, err := validateAmount(request.Quantity)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid quantity", err)
+	}
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	variant, err := h.service.RestockMerchVariant(c.Request().Context(), actorID, merchID, sku, quantity)
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, merchVariant(variant), logrus.Fields{
+		"merch_id": merchID,
+		"sku":      sku,
+		"quantity": quantity,
+		"actor_id": actorID,
+	})
+}
+
+// merchItem - товар в формате API.
+func merchItem(m db.Merch) api.MerchItem {
+	item := api.MerchItem{
+		Id:     int(m.ID),
+		Name:   m.Name,
+		Price:  int(m.Price),
+		Active: m.Active,
+	}
+
+	if m.Stock.Valid {
+		stock := int(m.Stock.Int32)
+		item.Stock = &stock
+	}
+
+	return item
+}
+
+// lowStockItem - заканчивающийся товар или вариант в формате API.
+func lowStockItem(m db.ListLowStockMerchRow) api.LowStockItem {
+	item := api.LowStockItem{
+		Id:    int(m.ID),
+		Name:  m.Name,
+		Price: int(m.Price),
+		Stock: int(m.Stock),
+	}
+
+	if m.Sku.Valid {
+		item.Sku = &m.Sku.String
+	}
+
+	return item
+}
+
+// merchVariant - вариант товара в формате API.
+func merchVariant(v db.MerchVariant) api.MerchVariant {
+	variant := api.MerchVariant{
+		Id:     int(v.ID),
+		Sku:    v.Sku,
+		Active: v.Active,
+	}
+
+	if v.Size.Valid {
+		variant.Size = &v.Size.String
+	}
+
+	if v.Color.Valid {
+		variant.Color = &v.Color.String
+	}
+
+	if v.Price.Valid {
+		price := int(v.Price.Int32)
+		variant.Price = &price
+	}
+
+	if v.Stock.Valid {
+		stock := int(v.Stock.Int32)
+		variant.Stock = &stock
+	}
+
+	return variant
+}
+
+// PutApiAdminUsersUsernameBalance - обработчик для установки баланса пользователя.
+func (h *CoinHandler) PutAPIAdminUsersUsernameBalance(c echo.Context, username string) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/users/:username/balance",
+		"method":   "PUT",
+	}).Info("PutApiAdminUsersUsernameBalance request received")
+
+	var request api.BalanceRequest
+	if err := c.Bind(&request); err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	balance, err := validateAmount(request.Coins)
+	if err != nil || balance < 0 {
+		return respondWithError(c, http.StatusBadRequest, "Invalid balance", err)
+	}
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	err = h.service.SetUserBalance(c.Request().Context(), actorID, username, balance)
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, "Balance updated successfully", logrus.Fields{
+		"username": username,
+		"balance":  balance,
+		"actor_id": actorID,
+	})
+}
+
+// GetApiAdminUsersUsernameRoles - обработчик для получения ролей пользователя.
+func (h *CoinHandler) GetAPIAdminUsersUsernameRoles(c echo.Context, username string) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/users/:username/roles",
+		"method":   "GET",
+	}).Info("GetApiAdminUsersUsernameRoles request received")
+
+	return h.respondWithRoles(c, username)
+}
+
+// PutApiAdminUsersUsernameRolesRole - обработчик для выдачи роли.
+func (h *CoinHandler) PutAPIAdminUsersUsernameRolesRole(c echo.Context, username string, role string) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/users/:username/roles/:role",
+		"method":   "PUT",
+	}).Info("PutApiAdminUsersUsernameRolesRole request received")
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	if err := h.service.GrantRole(c.Request().Context(), actorID, username, role); err != nil {
+		return err
+	}
+
+	return h.respondWithRoles(c, username)
+}
+
+// DeleteApiAdminUsersUsernameRolesRole - обработчик для отзыва роли.
+func (h *CoinHandler) DeleteAPIAdminUsersUsernameRolesRole(c echo.Context, username string, role string) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/users/:username/roles/:role",
+		"method":   "DELETE",
+	}).Info("DeleteApiAdminUsersUsernameRolesRole request received")
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	if err := h.service.RevokeRole(c.Request().Context(), actorID, username, role); err != nil {
+		return err
+	}
+
+	return h.respondWithRoles(c, username)
+}
+
+// respondWithRoles - ответ с текущими ролями пользователя.
+func (h *CoinHandler) respondWithRoles(c echo.Context, username string) error {
+	roles, err := h.service.GetUserRolesByName(c.Request().Context(), username)
+	if err != nil {
+		return err
+	}
+
+	if roles == nil {
+		roles = []string{}
+	}
+
+	return respondWithSuccess(c, api.RolesResponse{Username: username, Roles: roles}, logrus.Fields{
+		"username": username,
+		"roles":    roles,
+	})
+}
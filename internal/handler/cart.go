@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+
+	"avito_coin/api"
+	"avito_coin/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// GetApiCart - обработчик для получения корзины.
+func (h *CoinHandler) GetAPICart(c echo.Context) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/cart",
+		"method":   "GET",
+	}).Info("GetApiCart request received")
+
+	userID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	cart, err := h.service.GetCart(c.Request().Context(), userID)
+	if err != nil {
+		return respondWithError(c, http.StatusInternalServerError, "Failed to get cart", err)
+	}
+
+	return respondWithSuccess(c, cartResponse(cart), logrus.Fields{
+		"user_id": userID,
+		"items":   len(cart.Items),
+	})
+}
+
+// PostApiCart - обработчик для добавления товара в корзину.
+func (h *CoinHandler) PostAPICart(c echo.Context) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/cart",
+		"method":   "POST",
+	}).Info("PostApiCart request received")
+
+	var request api.CartItemRequest
+	if err := c.Bind(&request); err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	merchID, err := validateMerchID(request.MerchId)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Merch not found", err)
+	}
+
+	quantity := int32(1)
+
+	if request.Quantity != nil {
+		quantity, err = validateAmount(*request.Quantity)
+		if err != nil {
+			return respondWithError(c, http.StatusBadRequest, "Invalid quantity", err)
+		}
+	}
+
+	userID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	cart, err := h.service.AddToCart(c.Request().Context(), userID, merchID, variantSku(request.Variant), quantity)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, cartResponse(cart))
+}
+
+// PostApiCartCheckout - обработчик для оформления заказа.
+func (h *CoinHandler) PostAPICartCheckout(c echo.Context, _ api.PostApiCartCheckoutParams) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/cart/checkout",
+		"method":   "POST",
+	}).Info("PostApiCartCheckout request received")
+
+	// Тело запроса необязательно
+	var request api.CheckoutRequest
+	if err := c.Bind(&request); err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	userID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	order, err := h.service.Checkout(c.Request().Context(), userID, service.CheckoutInput{
+		PickupLocation: request.PickupLocation,
+		Notes:          request.Notes,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, orderResponse(order))
+}
+
+// DeleteApiCartId - обработчик для удаления позиции из корзины.
+func (h *CoinHandler) DeleteAPICartID(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/cart/:id",
+		"method":   "DELETE",
+	}).Info("DeleteApiCartId request received")
+
+	if id <= 0 || id > math.MaxInt32 {
+		return respondWithError(c, http.StatusNotFound, "Cart item not found", nil)
+	}
+
+	userID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	cart, err := h.service.RemoveFromCart(c.Request().Context(), userID, int32(id))
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, cartResponse(cart), logrus.Fields{
+		"user_id": userID,
+		"item_id": id,
+	})
+}
+
+// cartResponse - корзина в формате API.
+func cartResponse(cart service.Cart) api.Cart {
+	response := api.Cart{
+		Items: make([]api.CartItem, 0, len(cart.Items)),
+		Total: int(cart.Total),
+	}
+
+	for _, item := range cart.Items {
+		cartItem := api.CartItem{
+			Id:       int(item.ID),
+			MerchId:  int(item.MerchID),
+			Name:     item.Name,
+			Quantity: int(item.Quantity),
+			Price:    int(item.Price),
+		}
+
+		if item.Variant.Valid {
+			cartItem.Variant = &item.Variant.String
+		}
+
+		response.Items = append(response.Items, cartItem)
+	}
+
+	return response
+}
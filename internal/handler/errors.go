@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"avito_coin/api"
+	"avito_coin/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Общие коды ошибок, которые не связаны с конкретной ошибкой сервиса.
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeInternalError    = "internal_error"
+)
+
+// domainError - HTTP-статус и код ответа для ошибки сервиса.
+type domainError struct {
+	err    error
+	status int
+	code   string
+}
+
+// domainErrors - ошибки сервиса, которые центральный обработчик превращает в ответы клиенту.
+// Каждый код описан в api/schema.yaml (ErrorResponse.code).
+var domainErrors = []domainError{
+	{service.ErrInvalidUsername, http.StatusBadRequest, "invalid_username"},
+	{service.ErrInvalidPassword, http.StatusBadRequest, "invalid_password"},
+	{service.ErrSelfTransfer, http.StatusBadRequest, "self_transfer"},
+	{service.ErrInvalidTransferNote, http.StatusBadRequest, "invalid_transfer_note"},
+	{service.ErrInvalidHistoryFilter, http.StatusBadRequest, "invalid_history_filter"},
+	{service.ErrInvalidFeedFilter, http.StatusBadRequest, "invalid_feed_filter"},
+	{service.ErrInvalidMerch, http.StatusBadRequest, "invalid_merch"},
+	{service.ErrInvalidVariant, http.StatusBadRequest, "invalid_variant"},
+	{service.ErrInvalidQuantity, http.StatusBadRequest, "invalid_quantity"},
+	{service.ErrVariantRequired, http.StatusBadRequest, "variant_required"},
+	{service.ErrCartEmpty, http.StatusBadRequest, "cart_empty"},
+	{service.ErrInvalidOrder, http.StatusBadRequest, "invalid_order"},
+	{service.ErrInvalidOrderStatus, http.StatusBadRequest, "invalid_order_status"},
+	{service.ErrInvalidReversal, http.StatusBadRequest, "invalid_reversal"},
+	{service.ErrUnknownRole, http.StatusBadRequest, "unknown_role"},
+	{service.ErrRevokeOwnAdmin, http.StatusBadRequest, "revoke_own_admin"},
+
+	{service.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+	{service.ErrInvalidRefreshToken, http.StatusUnauthorized, "invalid_refresh_token"},
+
+	{service.ErrInsufficientBalance, http.StatusPaymentRequired, "insufficient_funds"},
+
+	{service.ErrRegistrationForbidden, http.StatusForbidden, "registration_forbidden"},
+
+	{service.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+	{service.ErrMerchNotFound, http.StatusNotFound, "merch_not_found"},
+	{service.ErrVariantNotFound, http.StatusNotFound, "variant_not_found"},
+	{service.ErrReservationNotFound, http.StatusNotFound, "reservation_not_found"},
+	{service.ErrCartItemNotFound, http.StatusNotFound, "cart_item_not_found"},
+	{service.ErrOrderNotFound, http.StatusNotFound, "order_not_found"},
+	{service.ErrTransferNotFound, http.StatusNotFound, "transfer_not_found"},
+	{service.ErrPurchaseNotFound, http.StatusNotFound, "purchase_not_found"},
+
+	{service.ErrUsernameTaken, http.StatusConflict, "username_taken"},
+	{service.ErrMerchNameTaken, http.StatusConflict, "merch_name_taken"},
+	{service.ErrVariantSkuTaken, http.StatusConflict, "variant_sku_taken"},
+	{service.ErrSoldOut, http.StatusConflict, "sold_out"},
+	{service.ErrCartStale, http.StatusConflict, "cart_stale"},
+	{service.ErrOrderTransitionNotAllowed, http.StatusConflict, "order_transition_not_allowed"},
+	{service.ErrOrderStatusChanged, http.StatusConflict, "order_status_changed"},
+	{service.ErrOrderNotCancellable, http.StatusConflict, "order_not_cancellable"},
+	{service.ErrAlreadyReversed, http.StatusConflict, "already_reversed"},
+	{service.ErrReversalExceedsAmount, http.StatusConflict, "reversal_exceeds_amount"},
+	{service.ErrPurchaseNotRefundable, http.StatusConflict, "purchase_not_refundable"},
+	{service.ErrIdempotencyKeyReused, http.StatusConflict, "idempotency_key_reused"},
+	{service.ErrIdempotencyKeyInProgress, http.StatusConflict, "idempotency_key_in_progress"},
+
+	{service.ErrLimitExceeded, http.StatusUnprocessableEntity, "limit_exceeded"},
+}
+
+// codeForStatus - общий код ошибки для HTTP-статуса.
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	}
+
+	if status < http.StatusInternalServerError {
+		return CodeInvalidRequest
+	}
+
+	return CodeInternalError
+}
+
+// errorResponse - HTTP-статус и тело ответа для ошибки, которую вернул обработчик.
+func errorResponse(err error) (int, api.ErrorResponse) {
+	var limitErr *service.LimitError
+	if errors.As(err, &limitErr) {
+		return http.StatusUnprocessableEntity, newErrorResponse("limit_exceeded", limitErr.Error(), limitDetails(limitErr))
+	}
+
+	for _, mapping := range domainErrors {
+		if errors.Is(err, mapping.err) {
+			return mapping.status, newErrorResponse(mapping.code, err.Error(), nil)
+		}
+	}
+
+	// Ошибки Echo: неверные параметры запроса, неизвестный маршрут и т.д.
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Code < http.StatusInternalServerError {
+		return httpErr.Code, newErrorResponse(codeForStatus(httpErr.Code), fmt.Sprint(httpErr.Message), nil)
+	}
+
+	return http.StatusInternalServerError, newErrorResponse(CodeInternalError, "Internal server error", nil)
+}
+
+// newErrorResponse - тело ответа с ошибкой.
+func newErrorResponse(code, message string, details map[string]interface{}) api.ErrorResponse {
+	response := api.ErrorResponse{Code: code, Errors: &message}
+	if details != nil {
+		response.Details = &details
+	}
+
+	return response
+}
+
+// limitDetails - какой лимит нарушен, его значение и когда он обновится.
+func limitDetails(limitErr *service.LimitError) map[string]interface{} {
+	details := map[string]interface{}{
+		"limit": limitErr.Limit,
+		"value": limitErr.Value,
+	}
+
+	if !limitErr.ResetsAt.IsZero() {
+		details["used"] = limitErr.Used
+		details["resetsAt"] = limitErr.ResetsAt
+	}
+
+	return details
+}
+
+// HTTPErrorHandler - центральный обработчик ошибок Echo: ошибки сервиса превращаются в ответ
+// с HTTP-статусом и машиночитаемым кодом, внутренние ошибки пишутся в лог и клиенту не раскрываются.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status, response := errorResponse(err)
+
+	entry := logrus.WithContext(c.Request().Context()).WithFields(logrus.Fields{
+		"method": c.Request().Method,
+		"path":   c.Path(),
+		"status": status,
+		"code":   response.Code,
+		"error":  err.Error(),
+	})
+
+	if status >= http.StatusInternalServerError {
+		entry.Error("Request failed")
+	} else {
+		entry.Info("Request rejected")
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, response)
+	}
+
+	if err != nil {
+		logrus.WithContext(c.Request().Context()).WithError(err).Error("Failed to send error response")
+	}
+}
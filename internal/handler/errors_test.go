@@ -0,0 +1,110 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"avito_coin/api"
+	"avito_coin/internal/handler"
+	"avito_coin/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// handleError - ответ центрального обработчика на ошибку.
+func handleError(t *testing.T, err error) (int, api.ErrorResponse) {
+	t.Helper()
+
+	e := echo.New()
+	recorder := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/api/sendCoin", nil), recorder)
+
+	handler.HTTPErrorHandler(err, c)
+
+	var response api.ErrorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+
+	return recorder.Code, response
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{service.ErrInsufficientBalance, http.StatusPaymentRequired, "insufficient_funds"},
+		{fmt.Errorf("%w: bob", service.ErrUserNotFound), http.StatusNotFound, "user_not_found"},
+		{service.ErrSelfTransfer, http.StatusBadRequest, "self_transfer"},
+		{service.ErrMerchNotFound, http.StatusNotFound, "merch_not_found"},
+		{service.ErrSoldOut, http.StatusConflict, "sold_out"},
+		{echo.NewHTTPError(http.StatusBadRequest, "Invalid format for parameter limit"), http.StatusBadRequest, handler.CodeInvalidRequest},
+		{echo.ErrNotFound, http.StatusNotFound, handler.CodeNotFound},
+	}
+
+	for _, tt := range tests {
+		status, response := handleError(t, tt.err)
+		assert.Equal(t, tt.status, status, tt.err.Error())
+		assert.Equal(t, tt.code, response.Code, tt.err.Error())
+		assert.Nil(t, response.Details)
+	}
+
+	// Внутренние ошибки клиенту не раскрываются
+	status, response := handleError(t, errors.New("pq: connection refused"))
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, handler.CodeInternalError, response.Code)
+	assert.NotContains(t, *response.Errors, "connection refused")
+}
+
+func TestHTTPErrorHandlerLimitDetails(t *testing.T) {
+	resetsAt := time.Date(2025, 2, 11, 0, 0, 0, 0, time.UTC)
+
+	status, response := handleError(t, &service.LimitError{
+		Limit: service.LimitDailyOutgoing, Value: 200, Used: 150, ResetsAt: resetsAt,
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "limit_exceeded", response.Code)
+	require.NotNil(t, response.Details)
+
+	details := *response.Details
+	assert.Equal(t, service.LimitDailyOutgoing, details["limit"])
+	assert.EqualValues(t, 200, details["value"])
+	assert.EqualValues(t, 150, details["used"])
+	assert.Equal(t, resetsAt.Format(time.RFC3339), details["resetsAt"])
+
+	// У лимита суммы одного перевода нет периода
+	_, response = handleError(t, &service.LimitError{Limit: service.LimitMaxAmount, Value: 100})
+	require.NotNil(t, response.Details)
+	assert.NotContains(t, *response.Details, "resetsAt")
+}
+
+// Тест: отказ в доступе без токена или с неверным токеном приходит в общем формате ошибок.
+func TestVerifyAuthErrors(t *testing.T) {
+	keys, err := handler.NewKeyRing(handler.KeyRingConfig{Secret: testSecret, SecretKeyID: "main"})
+	require.NoError(t, err)
+
+	e := echo.New()
+	handler.NewCoinHandler(e, service.NewCoinService(nil), keys)
+
+	for _, token := range []string{"", "Bearer not-a-token"} {
+		request := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+		if token != "" {
+			request.Header.Set(echo.HeaderAuthorization, token)
+		}
+
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code, token)
+
+		var response api.ErrorResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, handler.CodeUnauthorized, response.Code, token)
+		require.NotNil(t, response.Errors)
+	}
+}
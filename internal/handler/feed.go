@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"avito_coin/api"
+	"avito_coin/internal/db"
+	"avito_coin/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// GetApiFeed - обработчик для получения ленты благодарностей.
+func (h *CoinHandler) GetAPIFeed(c echo.Context, params api.GetApiFeedParams) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/feed",
+		"method":   "GET",
+	}).Info("GetApiFeed request received")
+
+	if !h.feedEnabled {
+		return echo.ErrNotFound
+	}
+
+	var tag string
+	if params.Tag != nil {
+		tag = *params.Tag
+	}
+
+	var limit int
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+
+	transfers, err := h.service.GetRecognitionFeed(c.Request().Context(), tag, limit)
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, feedResponse(transfers), logrus.Fields{
+		"tag":   tag,
+		"items": len(transfers),
+	})
+}
+
+// feedResponse - лента благодарностей в формате API.
+func feedResponse(transfers []db.ListPublicTransfersRow) []api.Recognition {
+	response := make([]api.Recognition, 0, len(transfers))
+
+	for _, transfer := range transfers {
+		entry := api.Recognition{
+			Id:        int(transfer.ID),
+			FromUser:  transfer.FromUsername,
+			ToUser:    transfer.ToUsername,
+			Amount:    int(transfer.Amount),
+			Tags:      service.SplitTags(transfer.Tags),
+			CreatedAt: transfer.TransactionTime.Time,
+		}
+
+		if transfer.Message.Valid {
+			entry.Message = &transfer.Message.String
+		}
+
+		response = append(response, entry)
+	}
+
+	return response
+}
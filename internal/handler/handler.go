@@ -1,34 +1,132 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"avito_coin/api"
+	"avito_coin/internal/health"
+	"avito_coin/internal/metrics"
 	"avito_coin/internal/service"
+	"avito_coin/internal/tracing"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
 // CoinHandler - структура для обработчиков HTTP-запросов.
 type CoinHandler struct {
 	service *service.CoinService
+	keys    *KeyRing
 	logger  *logrus.Logger
+	// accessTokenTTL - время жизни access-токена.
+	accessTokenTTL time.Duration
+	// autoRegister - регистрировать неизвестных пользователей при входе (старое поведение /api/auth).
+	autoRegister bool
+	// feedEnabled - доступна ли лента благодарностей /api/feed.
+	feedEnabled bool
+	// readiness - проверки зависимостей для /readyz.
+	readiness []health.Check
+	// metrics - метрики HTTP-запросов и обработчик /metrics; без них /metrics отвечает 404.
+	metrics        *metrics.Metrics
+	metricsHandler http.Handler
+	// tracingService - имя сервиса в спанах HTTP-запросов; пустое - запросы не трейсятся.
+	tracingService string
+}
+
+// Option - настройка обработчика.
+type Option func(*CoinHandler)
+
+// WithAccessTokenTTL - время жизни access-токенов.
+func WithAccessTokenTTL(ttl time.Duration) Option {
+	return func(h *CoinHandler) {
+		h.accessTokenTTL = ttl
+	}
+}
+
+// WithAutoRegister - регистрация неизвестных пользователей при входе через /api/auth.
+func WithAutoRegister(enabled bool) Option {
+	return func(h *CoinHandler) {
+		h.autoRegister = enabled
+	}
+}
+
+// WithFeed - включение ленты благодарностей /api/feed; выключенная лента отвечает 404.
+func WithFeed(enabled bool) Option {
+	return func(h *CoinHandler) {
+		h.feedEnabled = enabled
+	}
+}
+
+// WithReadinessChecks - проверки зависимостей, которые выполняет /readyz.
+func WithReadinessChecks(checks ...health.Check) Option {
+	return func(h *CoinHandler) {
+		h.readiness = checks
+	}
+}
+
+// WithMetrics - учет HTTP-запросов в метриках и публикация метрик на /metrics.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(h *CoinHandler) {
+		h.metrics = m
+		h.metricsHandler = m.Handler()
+	}
+}
+
+// WithTracing - спаны HTTP-запросов с продолжением трейса из заголовка traceparent входящего запроса.
+func WithTracing(serviceName string) Option {
+	return func(h *CoinHandler) {
+		h.tracingService = serviceName
+	}
+}
+
+// WithLogLevel - уровень логирования запросов.
+func WithLogLevel(level logrus.Level) Option {
+	return func(h *CoinHandler) {
+		h.logger.SetLevel(level)
+	}
 }
 
 // NewCoinHandler - функция для создания нового обработчика.
-func NewCoinHandler(e *echo.Echo, service *service.CoinService) {
+func NewCoinHandler(e *echo.Echo, service *service.CoinService, keys *KeyRing, opts ...Option) {
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{}) // Используем JSON-формат для логов
+	logger.AddHook(tracing.LogHook{})            // В записи с контекстом запроса попадают trace_id и span_id
 
 	handler := &CoinHandler{
-		service: service,
-		logger:  logger,
+		service:        service,
+		keys:           keys,
+		logger:         logger,
+		accessTokenTTL: DefaultAccessTokenTTL,
+		feedEnabled:    true,
+	}
+
+	for _, opt := range opts {
+		opt(handler)
+	}
+
+	// Ошибки обработчиков превращаются в ответы с кодами в одном месте
+	e.HTTPErrorHandler = HTTPErrorHandler
+
+	// Спан запроса охватывает все остальные middleware, включая метрики и задержку
+	if handler.tracingService != "" {
+		e.Use(otelecho.Middleware(handler.tracingService, otelecho.WithSkipper(func(c echo.Context) bool {
+			return probePaths[c.Request().URL.Path]
+		})))
+	}
+
+	// Метрики учитывают полное время ответа, включая задержку
+	if handler.metrics != nil {
+		e.Use(handler.metrics.Middleware())
 	}
 
 	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			time.Sleep(50 * time.Millisecond) // Задержка в 50 мс
+			if !probePaths[c.Request().URL.Path] {
+				time.Sleep(50 * time.Millisecond) // Задержка в 50 мс
+			}
+
 			return next(c)
 		}
 	})
@@ -38,15 +136,18 @@ func NewCoinHandler(e *echo.Echo, service *service.CoinService) {
 	public := e.Group("")
 
 	protected := public.Group("") // Группируем защищенные маршруты
-	protected.Use(verifyAuth)
+	protected.Use(handler.verifyAuth)
+	protected.Use(handler.authorize)   // Права проверяются по ролям из токена
+	protected.Use(handler.idempotency) // Повторы с тем же Idempotency-Key не выполняют операцию дважды
 
 	api.RegisterHandlers(public, protected, handler)
 	public.GET("/api/merch/:merch_id", handler.GetMerchPrice) // своя ручка (посчитал нужным)
+	public.GET("/api/merch/:merch_id/variants", handler.GetMerchVariants)
 }
 
-// PostApiAuth - обработчик для авторизации/регистрации пользователя.
+// PostApiAuth - обработчик для авторизации пользователя.
 func (h *CoinHandler) PostAPIAuth(c echo.Context) error {
-	h.logger.WithFields(logrus.Fields{
+	h.log(c).WithFields(logrus.Fields{
 		"endpoint": "/auth",
 		"method":   "POST",
 	}).Info("PostApiAuth request received")
@@ -57,37 +158,153 @@ func (h *CoinHandler) PostAPIAuth(c echo.Context) error {
 		return respondWithError(c, http.StatusBadRequest, "Failed to parse request body", err)
 	}
 
-	// Проверяем, существует ли пользователь
-	user, err := h.service.UserExists(c.Request().Context(), request.Username)
-	if err == nil {
-		// Проверяем пароль
-		if !validatePassword(request.Password, user.Password) {
-			return respondWithError(c, http.StatusUnauthorized, "Invalid password", nil)
-		}
+	// Проверяем пароль существующего пользователя
+	userID, err := h.service.Authenticate(c.Request().Context(), request.Username, request.Password)
 
+	switch {
+	case err == nil:
 		// Генерируем JWT и отправляем ответ
-		return respondWithToken(c, user.ID, "User authenticated successfully")
+		return h.newSession(c, http.StatusOK, userID, "User authenticated successfully")
+	case errors.Is(err, service.ErrUserNotFound) && h.autoRegister:
+		// Старое поведение: неизвестный пользователь регистрируется при первом входе
+		return h.autoRegisterUser(c, request)
+	case errors.Is(err, service.ErrUserNotFound):
+		// Не раскрываем, существует ли пользователь
+		return service.ErrInvalidCredentials
+	default:
+		return err
+	}
+}
+
+// autoRegisterUser - регистрация при первом входе без проверки политики регистрации (AUTH_AUTO_REGISTER).
+func (h *CoinHandler) autoRegisterUser(c echo.Context, request *api.AuthRequest) error {
+	userID, err := h.service.CreateUser(c.Request().Context(), request.Username, request.Password)
+	if errors.Is(err, service.ErrUsernameTaken) {
+		// Пользователя с тем же именем успел зарегистрировать параллельный запрос
+		return err
 	}
 
-	// Регистрируем нового пользователя
-	newUserID, err := h.service.CreateUser(c.Request().Context(), request.Username, request.Password)
 	if err != nil {
 		return respondWithError(c, http.StatusInternalServerError, "Failed to create user", err)
 	}
 
 	// Генерируем JWT и отправляем ответ
-	return respondWithToken(c, newUserID, "User created and authenticated successfully")
+	return h.newSession(c, http.StatusOK, userID, "User created and authenticated successfully")
+}
+
+// PostApiRegister - обработчик для регистрации нового пользователя.
+func (h *CoinHandler) PostAPIRegister(c echo.Context) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/register",
+		"method":   "POST",
+	}).Info("PostApiRegister request received")
+
+	// Парсим запрос
+	request, err := parseRegisterRequest(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Failed to parse request body", err)
+	}
+
+	var inviteCode string
+	if request.InviteCode != nil {
+		inviteCode = *request.InviteCode
+	}
+
+	// Регистрируем пользователя по правилам политики регистрации
+	userID, err := h.service.Register(c.Request().Context(), request.Username, request.Password, inviteCode)
+
+	if err != nil {
+		return err
+	}
+
+	// Генерируем JWT и отправляем ответ
+	return h.newSession(c, http.StatusCreated, userID, "User registered successfully")
+}
+
+// GetWellKnownJWKSJSON - обработчик для получения открытых ключей подписи JWT.
+func (h *CoinHandler) GetWellKnownJWKSJSON(c echo.Context) error {
+	// Ключи меняются при ротации, поэтому кэшируем ненадолго
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+
+	return c.JSON(http.StatusOK, h.keys.JWKS())
+}
+
+// PostApiAuthRefresh - обработчик для обмена refresh-токена на новую пару токенов.
+func (h *CoinHandler) PostAPIAuthRefresh(c echo.Context) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/auth/refresh",
+		"method":   "POST",
+	}).Info("PostApiAuthRefresh request received")
+
+	// Парсим запрос
+	request, err := parseRefreshRequest(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	// Обмениваем refresh-токен; использованный токен больше не действует
+	userID, refreshToken, err := h.service.RefreshSession(c.Request().Context(), request.RefreshToken)
+
+	if err != nil {
+		return err
+	}
+
+	// Генерируем JWT и отправляем ответ
+	return h.respondWithToken(c, http.StatusOK, userID, refreshToken, "Session refreshed successfully")
+}
+
+// PostApiLogout - обработчик для выхода пользователя.
+func (h *CoinHandler) PostAPILogout(c echo.Context) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/logout",
+		"method":   "POST",
+	}).Info("PostApiLogout request received")
+
+	// Парсим запрос
+	request, err := parseLogoutRequest(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	// Получаем данные токена из JWT
+	claims, err := extractClaims(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid token", err)
+	}
+
+	ctx := c.Request().Context()
+
+	// Отзываем текущий access-токен
+	if err := h.service.RevokeAccessToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		return respondWithError(c, http.StatusInternalServerError, "Failed to revoke token", err)
+	}
+
+	switch {
+	case request.AllSessions != nil && *request.AllSessions:
+		err = h.service.RevokeAllSessions(ctx, claims.UserID)
+	case request.RefreshToken != nil:
+		err = h.service.RevokeSession(ctx, claims.UserID, *request.RefreshToken)
+	}
+
+	if err != nil {
+		return respondWithError(c, http.StatusInternalServerError, "Failed to revoke session", err)
+	}
+
+	return respondWithSuccess(c, "Logged out successfully", logrus.Fields{
+		"user_id":      claims.UserID,
+		"all_sessions": request.AllSessions != nil && *request.AllSessions,
+	})
 }
 
 // GetApiBuyItem - обработчик для покупки мерча.
-func (h *CoinHandler) GetAPIBuyItem(c echo.Context, item string) error {
-	h.logger.WithFields(logrus.Fields{
+func (h *CoinHandler) GetAPIBuyItem(c echo.Context, item string, params api.GetApiBuyItemParams) error {
+	h.log(c).WithFields(logrus.Fields{
 		"endpoint": "/buy/:item",
 		"method":   "GET",
 	}).Info("GetApiBuyItem request received")
 
 	// Получаем merchID
-	merchID, err := parseMerchID(item)
+	merchID, err := parseMerchID(c, item)
 	if err != nil {
 		return respondWithError(c, http.StatusBadRequest, "Invalid merch ID", err)
 	}
@@ -98,13 +315,24 @@ func (h *CoinHandler) GetAPIBuyItem(c echo.Context, item string) error {
 		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
 	}
 
-	// Вызываем сервисный слой
-	if err := h.service.BuyMerch(c.Request().Context(), userID, merchID); err != nil {
-		return respondWithError(c, http.StatusInternalServerError, "Failed to buy merch", err)
+	// Вызываем сервисный слой: по резерву или со списанием остатка
+	if params.Reservation != nil {
+		reservationID, err := validateReservationID(*params.Reservation)
+		if err != nil {
+			return respondWithError(c, http.StatusNotFound, "Reservation not found", err)
+		}
+
+		err = h.service.BuyReservedMerch(c.Request().Context(), userID, merchID, reservationID)
+	} else {
+		err = h.service.BuyMerch(c.Request().Context(), userID, merchID, variantSku(params.Variant))
+	}
+
+	if err != nil {
+		return err
 	}
 
 	// Логируем и возвращаем успешный ответ
-	h.logger.WithFields(logrus.Fields{
+	h.log(c).WithFields(logrus.Fields{
 		"user_id":  userID,
 		"merch_id": merchID,
 	}).Info("Merch purchased successfully")
@@ -115,9 +343,82 @@ func (h *CoinHandler) GetAPIBuyItem(c echo.Context, item string) error {
 	})
 }
 
+// PostApiReservations - обработчик для резервирования товара.
+func (h *CoinHandler) PostAPIReservations(c echo.Context) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/reservations",
+		"method":   "POST",
+	}).Info("PostApiReservations request received")
+
+	var request api.ReservationRequest
+	if err := c.Bind(&request); err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	merchID, err := validateMerchID(request.MerchId)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Merch not found", err)
+	}
+
+	userID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	reservationID, expiresAt, err := h.service.ReserveMerch(c.Request().Context(), userID, merchID, variantSku(request.Variant))
+	if err != nil {
+		return err
+	}
+
+	h.log(c).WithFields(logrus.Fields{
+		"user_id":        userID,
+		"merch_id":       merchID,
+		"reservation_id": reservationID,
+	}).Info("Merch reserved")
+
+	reservation := api.Reservation{
+		Id:        int(reservationID),
+		MerchId:   int(merchID),
+		ExpiresAt: expiresAt,
+	}
+
+	if sku := variantSku(request.Variant); sku != "" {
+		reservation.Variant = &sku
+	}
+
+	return c.JSON(http.StatusCreated, reservation)
+}
+
+// DeleteApiReservationsId - обработчик для отмены резерва.
+func (h *CoinHandler) DeleteAPIReservationsID(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/reservations/:id",
+		"method":   "DELETE",
+	}).Info("DeleteApiReservationsId request received")
+
+	reservationID, err := validateReservationID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Reservation not found", err)
+	}
+
+	userID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	if err := h.service.CancelReservation(c.Request().Context(), userID, reservationID); err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, "Reservation cancelled", logrus.Fields{
+		"user_id":        userID,
+		"reservation_id": reservationID,
+	})
+}
+
 // PostApiSendCoin - обработчик для перевода монет.
-func (h *CoinHandler) PostAPISendCoin(c echo.Context) error {
-	h.logger.WithFields(logrus.Fields{
+func (h *CoinHandler) PostAPISendCoin(c echo.Context, _ api.PostApiSendCoinParams) error {
+	h.log(c).WithFields(logrus.Fields{
 		"endpoint": "/sendCoin",
 		"method":   "POST",
 	}).Info("PostApiSendCoin request received")
@@ -131,7 +432,7 @@ func (h *CoinHandler) PostAPISendCoin(c echo.Context) error {
 	// Проверяем диапазон значения amount
 	amount, err := validateAmount(request.Amount)
 	if err != nil {
-		return respondWithError(c, http.StatusInternalServerError, err.Error(), nil)
+		return respondWithError(c, http.StatusBadRequest, err.Error(), nil)
 	}
 
 	// Извлекаем user_id из JWT
@@ -140,9 +441,10 @@ func (h *CoinHandler) PostAPISendCoin(c echo.Context) error {
 		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
 	}
 
-	// Вызываем сервисный слой
-	if err := h.service.TransferCoins(c.Request().Context(), userID, request.ToUser, amount); err != nil {
-		return respondWithTransferError(c, err, userID, request.ToUser, amount)
+	// Вызываем сервисный слой; ошибки превращает в ответ HTTPErrorHandler
+	err = h.service.TransferCoins(c.Request().Context(), userID, request.ToUser, amount, transferNote(request))
+	if err != nil {
+		return err
 	}
 
 	// Логируем и отправляем успешный ответ
@@ -155,7 +457,7 @@ func (h *CoinHandler) PostAPISendCoin(c echo.Context) error {
 
 // GetMerchPrice - обработчик для получения цены товара.
 func (h *CoinHandler) GetMerchPrice(c echo.Context) error {
-	h.logger.WithFields(logrus.Fields{
+	h.log(c).WithFields(logrus.Fields{
 		"endpoint": "/merch/:merch_id/price",
 		"method":   "GET",
 	}).Info("GetMerchPrice request received")
@@ -163,13 +465,13 @@ func (h *CoinHandler) GetMerchPrice(c echo.Context) error {
 	// Извлекаем merch_id из параметров
 	merchID, err := extractMerchID(c)
 	if err != nil {
-		return respondWithError(c, http.StatusInternalServerError, "Invalid merch ID", err)
+		return respondWithError(c, http.StatusBadRequest, "Invalid merch ID", err)
 	}
 
 	// Получаем цену товара через сервисный слой
 	price, err := h.service.GetMerchPrice(c.Request().Context(), merchID)
 	if err != nil {
-		return respondWithError(c, http.StatusInternalServerError, "Failed to get merch price", err)
+		return err
 	}
 
 	// Логируем успешный ответ и возвращаем результат
@@ -179,9 +481,37 @@ func (h *CoinHandler) GetMerchPrice(c echo.Context) error {
 	})
 }
 
+// GetMerchVariants - обработчик для получения вариантов товара в продаже.
+func (h *CoinHandler) GetMerchVariants(c echo.Context) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/merch/:merch_id/variants",
+		"method":   "GET",
+	}).Info("GetMerchVariants request received")
+
+	merchID, err := extractMerchID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid merch ID", err)
+	}
+
+	variants, err := h.service.ListActiveMerchVariants(c.Request().Context(), merchID)
+	if err != nil {
+		return respondWithError(c, http.StatusInternalServerError, "Failed to list merch variants", err)
+	}
+
+	items := make([]api.MerchVariant, 0, len(variants))
+	for _, v := range variants {
+		items = append(items, merchVariant(v))
+	}
+
+	return respondWithSuccess(c, items, logrus.Fields{
+		"merch_id": merchID,
+		"variants": len(items),
+	})
+}
+
 // GetApiInfo - обработчик для получения баланса, покупок и транзакций пользователя.
 func (h *CoinHandler) GetAPIInfo(c echo.Context) error {
-	h.logger.WithFields(logrus.Fields{
+	h.log(c).WithFields(logrus.Fields{
 		"endpoint": "/info",
 		"method":   "GET",
 	}).Info("GetApiInfo request received")
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"avito_coin/api"
+	"avito_coin/internal/health"
+	"github.com/labstack/echo/v4"
+)
+
+// probePaths - пути проверок оркестратора и сбора метрик: они не требуют авторизации, не получают искусственную задержку
+// и не записываются в трейсы.
+var probePaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// GetHealthz - обработчик проверки, что процесс жив.
+func (h *CoinHandler) GetHealthz(c echo.Context) error {
+	return c.JSON(http.StatusOK, api.HealthResponse{Status: health.StatusOK})
+}
+
+// GetReadyz - обработчик проверки готовности: запускает проверки зависимостей и отдает результат каждой.
+func (h *CoinHandler) GetReadyz(c echo.Context) error {
+	results, ok := health.Run(c.Request().Context(), h.readiness)
+
+	response := api.ReadinessResponse{Status: health.StatusOK, Checks: make([]api.HealthCheck, 0, len(results))}
+
+	for _, result := range results {
+		check := api.HealthCheck{
+			Name:       result.Name,
+			Status:     result.Status,
+			DurationMs: float64(result.Duration.Microseconds()) / 1000,
+		}
+
+		if result.Error != "" {
+			check.Error = &result.Error
+		}
+
+		if result.Details != nil {
+			check.Details = &result.Details
+		}
+
+		response.Checks = append(response.Checks, check)
+	}
+
+	if !ok {
+		response.Status = health.StatusFail
+
+		h.log(c).WithField("checks", response.Checks).Warn("Readiness check failed")
+
+		return c.JSON(http.StatusServiceUnavailable, response)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetMetrics - обработчик для получения метрик Prometheus.
+func (h *CoinHandler) GetMetrics(c echo.Context) error {
+	if h.metricsHandler == nil {
+		return echo.ErrNotFound
+	}
+
+	h.metricsHandler.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
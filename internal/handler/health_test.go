@@ -0,0 +1,73 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"avito_coin/api"
+	"avito_coin/internal/handler"
+	"avito_coin/internal/health"
+	"avito_coin/internal/lifecycle"
+	"avito_coin/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: /healthz и /readyz доступны без токена, /readyz отдает результат каждой проверки
+// и отвечает 503, пока хотя бы одна проверка не пройдена.
+func TestReadiness(t *testing.T) {
+	keys, err := handler.NewKeyRing(handler.KeyRingConfig{Secret: testSecret, SecretKeyID: "main"})
+	require.NoError(t, err)
+
+	lc := lifecycle.New()
+	dbErr := errors.New("connection refused")
+
+	e := echo.New()
+	handler.NewCoinHandler(e, service.NewCoinService(nil), keys, handler.WithReadinessChecks(
+		health.Lifecycle(lc),
+		health.Check{Name: "database", Run: func(context.Context) (map[string]interface{}, error) {
+			return map[string]interface{}{"host": "db"}, dbErr
+		}},
+	))
+
+	get := func(path string) (int, []byte) {
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return recorder.Code, recorder.Body.Bytes()
+	}
+
+	code, body := get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"status":"ok"}`, string(body))
+
+	code, body = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	var response api.ReadinessResponse
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, health.StatusFail, response.Status)
+	require.Len(t, response.Checks, 2)
+	assert.Equal(t, "lifecycle", response.Checks[0].Name)
+	assert.Equal(t, health.StatusFail, response.Checks[0].Status)
+	assert.Equal(t, "database", response.Checks[1].Name)
+	assert.Equal(t, "connection refused", *response.Checks[1].Error)
+	assert.Equal(t, map[string]interface{}{"host": "db"}, *response.Checks[1].Details)
+
+	// Сервис запущен и БД доступна
+	lc.SetReady(true)
+	dbErr = nil
+
+	code, body = get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	var ready api.ReadinessResponse
+	require.NoError(t, json.Unmarshal(body, &ready))
+	assert.Equal(t, health.StatusOK, ready.Status)
+	assert.Nil(t, ready.Checks[1].Error)
+}
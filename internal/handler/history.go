@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"net/http"
+
+	"avito_coin/api"
+	"avito_coin/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// GetApiHistory - обработчик для получения страницы истории переводов и покупок.
+func (h *CoinHandler) GetAPIHistory(c echo.Context, params api.GetApiHistoryParams) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/history",
+		"method":   "GET",
+	}).Info("GetApiHistory request received")
+
+	userID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	filter, err := historyFilter(params)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, err.Error(), nil)
+	}
+
+	page, err := h.service.GetHistory(c.Request().Context(), userID, filter)
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, historyResponse(page), logrus.Fields{
+		"user_id": userID,
+		"items":   len(page.Items),
+	})
+}
+
+// historyFilter - фильтр истории из параметров запроса.
+func historyFilter(params api.GetApiHistoryParams) (service.HistoryFilter, error) {
+	filter := service.HistoryFilter{
+		From: params.From,
+		To:   params.To,
+	}
+
+	if params.Type != nil {
+		filter.Type = *params.Type
+	}
+
+	if params.Direction != nil {
+		filter.Direction = *params.Direction
+	}
+
+	if params.Counterparty != nil {
+		filter.Counterparty = *params.Counterparty
+	}
+
+	if params.Cursor != nil {
+		filter.Cursor = *params.Cursor
+	}
+
+	if params.Limit != nil {
+		filter.Limit = *params.Limit
+	}
+
+	if params.MinAmount != nil {
+		minAmount, err := validateAmount(*params.MinAmount)
+		if err != nil {
+			return filter, err
+		}
+
+		filter.MinAmount = &minAmount
+	}
+
+	if params.MaxAmount != nil {
+		maxAmount, err := validateAmount(*params.MaxAmount)
+		if err != nil {
+			return filter, err
+		}
+
+		filter.MaxAmount = &maxAmount
+	}
+
+	return filter, nil
+}
+
+// historyResponse - страница истории в формате API.
+func historyResponse(page *service.HistoryPage) api.HistoryPage {
+	response := api.HistoryPage{Items: make([]api.HistoryItem, 0, len(page.Items))}
+
+	for _, item := range page.Items {
+		entry := api.HistoryItem{
+			Id:        int(item.ID),
+			Type:      item.Type,
+			Direction: item.Direction,
+			Amount:    int(item.Amount),
+			CreatedAt: item.CreatedAt.Time,
+		}
+
+		if item.Counterparty.Valid {
+			entry.Counterparty = &item.Counterparty.String
+		}
+
+		if item.Item.Valid {
+			entry.Item = &item.Item.String
+		}
+
+		if item.Variant.Valid {
+			entry.Variant = &item.Variant.String
+		}
+
+		if item.Message.Valid {
+			entry.Message = &item.Message.String
+		}
+
+		if item.Type == service.HistoryTypeTransfer {
+			tags := service.SplitTags(item.Tags)
+			entry.Tags = &tags
+		}
+
+		response.Items = append(response.Items, entry)
+	}
+
+	if page.NextCursor != "" {
+		response.NextCursor = &page.NextCursor
+	}
+
+	return response
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"avito_coin/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// idempotencyKeyHeader - заголовок с ключом идемпотентности.
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayHeader - признак того, что ответ взят из сохраненных.
+	idempotentReplayHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength - максимальная длина ключа идемпотентности.
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize - максимальный размер тела запроса с ключом идемпотентности: тело читается в память целиком.
+	maxIdempotentBodySize = 64 << 10
+	// idempotencyWriteTimeout - время на сохранение ответа или освобождение ключа после выполнения запроса.
+	idempotencyWriteTimeout = 5 * time.Second
+)
+
+// idempotentRoutes - маршруты, которые поддерживают заголовок Idempotency-Key.
+var idempotentRoutes = map[string]bool{
+	"/api/sendCoin":      true,
+	"/api/buy/:item":     true,
+	"/api/cart/checkout": true,
+}
+
+// bodyRecorder - копирует тело ответа, чтобы сохранить его для повторов.
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// idempotency - middleware, которое не дает повторному запросу с тем же Idempotency-Key выполнить операцию дважды.
+func (h *CoinHandler) idempotency(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := c.Request().Header.Get(idempotencyKeyHeader)
+		if key == "" || !idempotentRoutes[c.Path()] {
+			return next(c)
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			return respondWithError(c, http.StatusBadRequest, "Idempotency key is too long", nil)
+		}
+
+		userID, err := extractUserID(c)
+		if err != nil {
+			return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+		}
+
+		fingerprint, err := requestFingerprint(c)
+
+		var tooLarge *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &tooLarge):
+			return respondWithError(c, http.StatusRequestEntityTooLarge, "Request body is too large", err)
+		case err != nil:
+			return respondWithError(c, http.StatusBadRequest, "Failed to read request body", err)
+		}
+
+		ctx := c.Request().Context()
+
+		// Резервируем ключ или получаем сохраненный ответ
+		stored, err := h.service.BeginIdempotentRequest(ctx, userID, key, fingerprint)
+
+		switch {
+		case err != nil:
+			return err
+		case stored != nil:
+			c.Response().Header().Set(idempotentReplayHeader, "true")
+			return c.JSONBlob(stored.StatusCode, stored.Body)
+		}
+
+		// Выполняем запрос, запоминая ответ
+		recorder := &bodyRecorder{ResponseWriter: c.Response().Writer}
+		c.Response().Writer = recorder
+
+		// Ошибку обработчика превращаем в ответ здесь, чтобы он попал в recorder
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+
+		// Операция уже выполнена: результат записывается, даже если клиент отключился и контекст запроса отменен
+		writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyWriteTimeout)
+		defer cancel()
+
+		status := c.Response().Status
+		if status >= http.StatusInternalServerError {
+			// Операция не выполнена, освобождаем ключ, чтобы клиент мог повторить запрос
+			if abortErr := h.service.AbortIdempotentRequest(writeCtx, userID, key); abortErr != nil {
+				h.log(c).WithError(abortErr).Error("Failed to release idempotency key")
+			}
+
+			return nil
+		}
+
+		response := service.IdempotentResponse{StatusCode: status, Body: recorder.body.Bytes()}
+		if err := h.service.CompleteIdempotentRequest(writeCtx, userID, key, response); err != nil {
+			h.log(c).WithFields(logrus.Fields{
+				"user_id": userID,
+				"error":   err.Error(),
+			}).Error("Failed to store idempotent response")
+		}
+
+		return nil
+	}
+}
+
+// requestFingerprint - SHA-256 от метода, пути с параметрами запроса и тела запроса. Тело после чтения восстанавливается.
+// Тело больше maxIdempotentBodySize не читается, возвращается *http.MaxBytesError.
+func requestFingerprint(c echo.Context) (string, error) {
+	req := c.Request()
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Response(), req.Body, maxIdempotentBodySize))
+	if err != nil {
+		return "", err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.RequestURI() + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -1,55 +1,57 @@
 package handler
 
 import (
+	"context"
 	"crypto/rand"
-	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"time"
 
+	"avito_coin/internal/service"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo/v4"
-	"github.com/sirupsen/logrus"
 )
 
+// DefaultAccessTokenTTL - время жизни access-токена по умолчанию; сеанс продлевается refresh-токеном.
+const DefaultAccessTokenTTL = 15 * time.Minute
+
 type Claims struct {
-	UserID int32 `json:"jwt_user_id"`
+	UserID int32    `json:"jwt_user_id"`
+	Roles  []string `json:"roles"`
 	jwt.StandardClaims
 }
 
-func generateSecretKey() string {
-	// Генерация случайных 32 байтов
-	secret := make([]byte, 32)
-
-	_, err := rand.Read(secret)
+func (h *CoinHandler) generateJWT(ctx context.Context, userID int32) (string, error) {
+	// Роли читаются при каждом выпуске токена, поэтому изменения ролей попадают в токен при следующем обновлении
+	roles, err := h.service.GetUserRoles(ctx, userID)
 	if err != nil {
-		logrus.Fatal(err)
+		return "", err
 	}
 
-	logrus.Info("secret key generated")
-	// Преобразуем секретный ключ в base64 для удобства хранения
-	return base64.StdEncoding.EncodeToString(secret)
-}
-
-var jwtSecret = []byte(generateSecretKey())
+	// jti позволяет отозвать конкретный токен
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
 
-func generateJWT(userID int32) (string, error) {
+	now := time.Now()
 	claims := &Claims{
 		UserID: userID,
+		Roles:  roles,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(), // Время жизни токена 24 часа
+			Id:        hex.EncodeToString(jti),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(h.accessTokenTTL).Unix(),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	return token.SignedString(jwtSecret)
+	return h.keys.Sign(claims)
 }
 
 // Функция для проверки токена.
-func verifyJWT(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(_ *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+func (h *CoinHandler) verifyJWT(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, h.keys.Keyfunc)
 	if err != nil || !token.Valid {
 		return nil, err
 	}
@@ -62,11 +64,11 @@ func verifyJWT(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
-func verifyAuth(next echo.HandlerFunc) echo.HandlerFunc {
+func (h *CoinHandler) verifyAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		token := c.Request().Header.Get("Authorization")
 		if token == "" {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Missing token"})
+			return respondWithError(c, http.StatusUnauthorized, "Missing token", nil)
 		}
 
 		// Удаляем префикс "Bearer " если он есть
@@ -75,14 +77,102 @@ func verifyAuth(next echo.HandlerFunc) echo.HandlerFunc {
 		}
 
 		// Проверяем токен
-		claims, err := verifyJWT(token)
+		claims, err := h.verifyJWT(token)
+		if err != nil {
+			return respondWithError(c, http.StatusUnauthorized, "Invalid token", err)
+		}
+
+		// Проверяем, не отозван ли токен
+		revoked, err := h.service.IsTokenRevoked(c.Request().Context(), claims.UserID, claims.Id, time.Unix(claims.IssuedAt, 0))
 		if err != nil {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid token"})
+			return respondWithError(c, http.StatusInternalServerError, "Failed to check token", err)
+		}
+
+		if revoked {
+			return respondWithError(c, http.StatusUnauthorized, "Token revoked", nil)
 		}
 
 		// Сохраняем данные о пользователе в контексте
 		c.Set("jwt_user_id", claims.UserID)
+		c.Set("jwt_claims", claims)
+		c.Set("jwt_roles", claims.Roles)
 
 		return next(c)
 	}
 }
+
+// newSession - выдача пары токенов при входе пользователя.
+func (h *CoinHandler) newSession(c echo.Context, statusCode int, userID int32, logMessage string) error {
+	refreshToken, err := h.service.IssueRefreshToken(c.Request().Context(), userID)
+	if err != nil {
+		return respondWithError(c, http.StatusInternalServerError, "Failed to issue refresh token", err)
+	}
+
+	return h.respondWithToken(c, statusCode, userID, refreshToken, logMessage)
+}
+
+// routePermissions - права, необходимые для защищенных маршрутов (ключ - метод и путь маршрута).
+// Маршруты без записи доступны любому пользователю с действующим токеном.
+var routePermissions = map[string]service.Permission{
+	"POST /api/sendCoin":                              service.PermTransferCoins,
+	"GET /api/buy/:item":                              service.PermBuyMerch,
+	"GET /api/info":                                   service.PermViewInfo,
+	"GET /api/history":                                service.PermViewInfo,
+	"GET /api/feed":                                   service.PermViewInfo,
+	"POST /api/reservations":                          service.PermBuyMerch,
+	"DELETE /api/reservations/:id":                    service.PermBuyMerch,
+	"GET /api/cart":                                   service.PermBuyMerch,
+	"POST /api/cart":                                  service.PermBuyMerch,
+	"POST /api/cart/checkout":                         service.PermBuyMerch,
+	"DELETE /api/cart/:id":                            service.PermBuyMerch,
+	"GET /api/orders":                                 service.PermBuyMerch,
+	"GET /api/orders/:id":                             service.PermBuyMerch,
+	"POST /api/orders/:id/cancel":                     service.PermBuyMerch,
+	"GET /api/admin/ledger/audit":                     service.PermAuditLedger,
+	"GET /api/admin/merch":                            service.PermManageMerch,
+	"POST /api/admin/merch":                           service.PermManageMerch,
+	"PATCH /api/admin/merch/:id":                      service.PermManageMerch,
+	"DELETE /api/admin/merch/:id":                     service.PermManageMerch,
+	"GET /api/admin/merch/:id/audit":                  service.PermManageMerch,
+	"GET /api/admin/merch/low-stock":                  service.PermManageMerch,
+	"POST /api/admin/merch/:id/restock":               service.PermManageMerch,
+	"GET /api/admin/merch/:id/variants":               service.PermManageMerch,
+	"POST /api/admin/merch/:id/variants":              service.PermManageMerch,
+	"PATCH /api/admin/merch/:id/variants/:sku":        service.PermManageMerch,
+	"POST /api/admin/merch/:id/variants/:sku/restock": service.PermManageMerch,
+	"GET /api/admin/orders":                           service.PermFulfillOrders,
+	"GET /api/admin/orders/:id":                       service.PermFulfillOrders,
+	"PATCH /api/admin/orders/:id":                     service.PermFulfillOrders,
+	"POST /api/admin/transactions/:id/reverse":        service.PermIssueRefunds,
+	"POST /api/admin/purchases/:id/refund":            service.PermIssueRefunds,
+	"PUT /api/admin/users/:username/balance":          service.PermAdjustBalance,
+	"GET /api/admin/users/:username/roles":            service.PermViewUsers,
+	"PUT /api/admin/users/:username/roles/:role":      service.PermManageRoles,
+	"DELETE /api/admin/users/:username/roles/:role":   service.PermManageRoles,
+}
+
+// requirePermission - middleware, которое пропускает только пользователей, чьи роли дают право permission.
+func requirePermission(permission service.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			roles, _ := c.Get("jwt_roles").([]string)
+			if !service.HasPermission(roles, permission) {
+				return respondWithError(c, http.StatusForbidden, "Insufficient permissions", nil)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// authorize - проверка прав для маршрутов из routePermissions.
+func (h *CoinHandler) authorize(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		permission, ok := routePermissions[c.Request().Method+" "+c.Path()]
+		if !ok {
+			return next(c)
+		}
+
+		return requirePermission(permission)(next)(c)
+	}
+}
@@ -0,0 +1,391 @@
+package handler
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"avito_coin/api"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// activeKeyFile - файл в каталоге ключей с ID ключа, которым подписываются новые токены.
+	activeKeyFile = "active"
+	// secretKeyExt - расширение файла с секретом HS256.
+	secretKeyExt = ".secret"
+	// pemKeyExt - расширение файла с закрытым ключом RSA или Ed25519 в формате PEM.
+	pemKeyExt = ".pem"
+	// minSecretLength - минимальная длина секрета HS256 в байтах.
+	minSecretLength = 32
+)
+
+var (
+	// errUnknownKeyID - токен подписан неизвестным ключом.
+	errUnknownKeyID = errors.New("unknown signing key")
+	// errUnexpectedSigningMethod - алгоритм токена не совпадает с алгоритмом ключа.
+	errUnexpectedSigningMethod = errors.New("unexpected signing method")
+	// errNoSigningKeys - не задан ни один ключ подписи.
+	errNoSigningKeys = errors.New("no JWT signing keys configured: set JWT_SECRET or JWT_KEYS_DIR")
+)
+
+// KeyRingConfig - источники ключей подписи.
+type KeyRingConfig struct {
+	// Dir - каталог с ключами: <kid>.secret (HS256) и <kid>.pem (RS256 или EdDSA).
+	// Необязательный файл active содержит ID ключа для подписи новых токенов.
+	Dir string
+	// Secret - секрет HS256, который добавляется в связку с ID SecretKeyID.
+	Secret string
+	// SecretKeyID - ID ключа для Secret.
+	SecretKeyID string
+	// ActiveKeyID - ID ключа для подписи, если в каталоге нет файла active.
+	// По умолчанию используется ключ с наибольшим ID (удобно именовать ключи датой выпуска).
+	ActiveKeyID string
+	// AllowEphemeral - если ключи не заданы, подписывать токены случайным ключом, который живет до перезапуска.
+	// Только для разработки: после перезапуска токены недействительны, а реплики не принимают токены друг друга.
+	AllowEphemeral bool
+}
+
+// signingKey - ключ подписи токенов.
+type signingKey struct {
+	id     string
+	method jwt.SigningMethod
+	// private - ключ для подписи: []byte, *rsa.PrivateKey или ed25519.PrivateKey.
+	private interface{}
+	// public - ключ для проверки: []byte, *rsa.PublicKey или ed25519.PublicKey.
+	public interface{}
+}
+
+// KeyRing - связка ключей подписи JWT. Новые токены подписываются активным ключом,
+// проверяются токены, подписанные любым ключом из связки, поэтому ключи можно менять без простоя.
+type KeyRing struct {
+	cfg KeyRingConfig
+
+	mu     sync.RWMutex
+	keys   map[string]*signingKey
+	active *signingKey
+}
+
+// NewKeyRing - загрузка связки ключей. Если ни один источник не задан, возвращается ошибка;
+// с AllowEphemeral создается случайный ключ HS256, и токены перестают действовать после перезапуска.
+func NewKeyRing(cfg KeyRingConfig) (*KeyRing, error) {
+	keys, err := loadKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		if !cfg.AllowEphemeral {
+			return nil, errNoSigningKeys
+		}
+
+		logrus.Warn("JWT signing keys are not configured, using an ephemeral key: tokens will not survive a restart")
+
+		key, err := ephemeralKey()
+		if err != nil {
+			return nil, err
+		}
+
+		keys[key.id] = key
+	}
+
+	ring := &KeyRing{cfg: cfg}
+
+	if err := ring.setKeys(keys); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// Reload - повторная загрузка ключей, например после добавления нового ключа или смены активного.
+// При ошибке, в том числе если ключей не осталось, продолжают действовать текущие ключи.
+func (k *KeyRing) Reload() error {
+	keys, err := loadKeys(k.cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return errNoSigningKeys
+	}
+
+	return k.setKeys(keys)
+}
+
+// setKeys - замена ключей связки и выбор активного ключа.
+func (k *KeyRing) setKeys(keys map[string]*signingKey) error {
+	active, err := activeKey(k.cfg, keys)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.active = active
+	k.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"keys":   len(keys),
+		"active": active.id,
+	}).Info("JWT signing keys loaded")
+
+	return nil
+}
+
+// Sign - подпись токена активным ключом, ID ключа записывается в заголовок kid.
+func (k *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	k.mu.RLock()
+	key := k.active
+	k.mu.RUnlock()
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+
+	return token.SignedString(key.private)
+}
+
+// Keyfunc - выбор ключа для проверки токена по заголовку kid.
+func (k *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	k.mu.RLock()
+	key, ok := k.keys[kid]
+	k.mu.RUnlock()
+
+	if !ok {
+		return nil, errUnknownKeyID
+	}
+
+	// Алгоритм задается ключом, а не токеном: иначе открытый ключ RSA можно выдать за секрет HS256
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errUnexpectedSigningMethod
+	}
+
+	return key.public, nil
+}
+
+// JWKS - открытые ключи связки в формате JWK Set. Секреты HS256 не публикуются.
+func (k *KeyRing) JWKS() api.JWKSResponse {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	response := api.JWKSResponse{Keys: []api.JWK{}}
+
+	for _, key := range k.keys {
+		jwk := api.JWK{Kid: key.id, Alg: key.method.Alg(), Use: "sig"}
+
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			n := base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+			jwk.Kty, jwk.N, jwk.E = "RSA", &n, &e
+		case ed25519.PublicKey:
+			crv := "Ed25519"
+			x := base64.RawURLEncoding.EncodeToString(public)
+			jwk.Kty, jwk.Crv, jwk.X = "OKP", &crv, &x
+		default:
+			continue
+		}
+
+		response.Keys = append(response.Keys, jwk)
+	}
+
+	slices.SortFunc(response.Keys, func(a, b api.JWK) int { return strings.Compare(a.Kid, b.Kid) })
+
+	return response
+}
+
+// loadKeys - загрузка ключей из секрета и каталога.
+func loadKeys(cfg KeyRingConfig) (map[string]*signingKey, error) {
+	keys := make(map[string]*signingKey)
+
+	if cfg.Secret != "" {
+		if len(cfg.Secret) < minSecretLength {
+			return nil, fmt.Errorf("JWT secret must be at least %d bytes long", minSecretLength)
+		}
+
+		kid := cfg.SecretKeyID
+		if kid == "" {
+			kid = "default"
+		}
+
+		keys[kid] = hmacKey(kid, []byte(cfg.Secret))
+	}
+
+	if cfg.Dir == "" {
+		return keys, nil
+	}
+
+	entries, err := os.ReadDir(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT keys directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != secretKeyExt && ext != pemKeyExt) {
+			continue
+		}
+
+		kid := strings.TrimSuffix(entry.Name(), ext)
+
+		data, err := os.ReadFile(filepath.Join(cfg.Dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT key %q: %w", kid, err)
+		}
+
+		key, err := parseKey(kid, ext, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JWT key %q: %w", kid, err)
+		}
+
+		keys[kid] = key
+	}
+
+	return keys, nil
+}
+
+// parseKey - разбор файла ключа.
+func parseKey(kid, ext string, data []byte) (*signingKey, error) {
+	if ext == secretKeyExt {
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) < minSecretLength {
+			return nil, fmt.Errorf("HS256 secret must be at least %d bytes long", minSecretLength)
+		}
+
+		return hmacKey(kid, secret), nil
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var (
+		private interface{}
+		err     error
+	)
+
+	if block.Type == "RSA PRIVATE KEY" {
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		return &signingKey{id: kid, method: jwt.SigningMethodRS256, private: private, public: &private.PublicKey}, nil
+	case ed25519.PrivateKey:
+		public, _ := private.Public().(ed25519.PublicKey)
+		return &signingKey{id: kid, method: signingMethodEdDSA, private: private, public: public}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", private)
+	}
+}
+
+// hmacKey - ключ HS256.
+func hmacKey(kid string, secret []byte) *signingKey {
+	return &signingKey{id: kid, method: jwt.SigningMethodHS256, private: secret, public: secret}
+}
+
+// ephemeralKey - случайный ключ HS256, живущий до перезапуска.
+func ephemeralKey() (*signingKey, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate JWT secret: %w", err)
+	}
+
+	return hmacKey("ephemeral", secret), nil
+}
+
+// activeKey - выбор ключа для подписи новых токенов.
+func activeKey(cfg KeyRingConfig, keys map[string]*signingKey) (*signingKey, error) {
+	kid := cfg.ActiveKeyID
+
+	if cfg.Dir != "" {
+		data, err := os.ReadFile(filepath.Join(cfg.Dir, activeKeyFile))
+		if err == nil {
+			kid = strings.TrimSpace(string(data))
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read active JWT key ID: %w", err)
+		}
+	}
+
+	if kid == "" {
+		for id := range keys {
+			kid = max(kid, id)
+		}
+	}
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("active JWT key %q is not loaded", kid)
+	}
+
+	return key, nil
+}
+
+// signingMethodEdDSA - подпись Ed25519 (RFC 8037), которой нет в jwt-go v3.
+var signingMethodEdDSA = &eddsaSigningMethod{}
+
+func init() {
+	jwt.RegisterSigningMethod(signingMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return signingMethodEdDSA
+	})
+}
+
+type eddsaSigningMethod struct{}
+
+func (m *eddsaSigningMethod) Alg() string {
+	return "EdDSA"
+}
+
+func (m *eddsaSigningMethod) Sign(signingString string, key interface{}) (string, error) {
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	signature, err := private.Sign(rand.Reader, []byte(signingString), crypto.Hash(0))
+	if err != nil {
+		return "", err
+	}
+
+	return jwt.EncodeSegment(signature), nil
+}
+
+func (m *eddsaSigningMethod) Verify(signingString, signature string, key interface{}) error {
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	decoded, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(public, []byte(signingString), decoded) {
+		return jwt.ErrSignatureInvalid
+	}
+
+	return nil
+}
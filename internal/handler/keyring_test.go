@@ -0,0 +1,170 @@
+package handler_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"avito_coin/internal/handler"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSecret - секрет HS256 допустимой длины.
+var testSecret = strings.Repeat("s", 32)
+
+// writeFile - записывает файл в каталог ключей.
+func writeFile(t *testing.T, dir, name, data string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600))
+}
+
+// writeEd25519Key - создает закрытый ключ Ed25519 в формате PEM.
+func writeEd25519Key(t *testing.T, dir, kid string) {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	writeFile(t, dir, kid+".pem", string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+}
+
+func parse(ring *handler.KeyRing, token string) error {
+	_, err := jwt.ParseWithClaims(token, &handler.Claims{}, ring.Keyfunc)
+	return err
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "2024-01.secret", strings.Repeat("a", 32))
+
+	ring, err := handler.NewKeyRing(handler.KeyRingConfig{Dir: dir})
+	require.NoError(t, err)
+
+	oldToken, err := ring.Sign(&handler.Claims{UserID: 1})
+	require.NoError(t, err)
+
+	// Добавляем новый ключ: он становится активным, старые токены продолжают проверяться
+	writeEd25519Key(t, dir, "2024-02")
+	require.NoError(t, ring.Reload())
+
+	newToken, err := ring.Sign(&handler.Claims{UserID: 1})
+	require.NoError(t, err)
+
+	token, _, err := new(jwt.Parser).ParseUnverified(newToken, &handler.Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "2024-02", token.Header["kid"])
+	assert.Equal(t, "EdDSA", token.Method.Alg())
+
+	assert.NoError(t, parse(ring, oldToken))
+	assert.NoError(t, parse(ring, newToken))
+
+	// Удаляем старый ключ: подписанные им токены больше не принимаются
+	require.NoError(t, os.Remove(filepath.Join(dir, "2024-01.secret")))
+	require.NoError(t, ring.Reload())
+
+	assert.Error(t, parse(ring, oldToken))
+	assert.NoError(t, parse(ring, newToken))
+}
+
+func TestKeyRingActiveKey(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.secret", strings.Repeat("a", 32))
+	writeFile(t, dir, "b.secret", strings.Repeat("b", 32))
+	writeFile(t, dir, "active", "a\n")
+
+	ring, err := handler.NewKeyRing(handler.KeyRingConfig{Dir: dir})
+	require.NoError(t, err)
+
+	signed, err := ring.Sign(&handler.Claims{UserID: 1})
+	require.NoError(t, err)
+
+	token, _, err := new(jwt.Parser).ParseUnverified(signed, &handler.Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "a", token.Header["kid"])
+
+	// Активный ключ должен быть загружен
+	writeFile(t, dir, "active", "missing")
+	assert.Error(t, ring.Reload())
+}
+
+func TestKeyRingWithoutKeys(t *testing.T) {
+	// Без ключей связка не создается, случайный ключ - только по явному разрешению
+	_, err := handler.NewKeyRing(handler.KeyRingConfig{Dir: t.TempDir()})
+	assert.Error(t, err)
+
+	ring, err := handler.NewKeyRing(handler.KeyRingConfig{AllowEphemeral: true})
+	require.NoError(t, err)
+
+	_, err = ring.Sign(&handler.Claims{UserID: 1})
+	assert.NoError(t, err)
+
+	// Если при перечитывании ключей каталог пуст, выданные токены продолжают действовать
+	dir := t.TempDir()
+	writeFile(t, dir, "main.secret", testSecret)
+
+	ring, err = handler.NewKeyRing(handler.KeyRingConfig{Dir: dir})
+	require.NoError(t, err)
+
+	signed, err := ring.Sign(&handler.Claims{UserID: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "main.secret")))
+	assert.Error(t, ring.Reload())
+	assert.NoError(t, parse(ring, signed))
+}
+
+func TestKeyRingShortSecret(t *testing.T) {
+	// Короткий секрет не принимается ни из конфигурации, ни из каталога ключей
+	_, err := handler.NewKeyRing(handler.KeyRingConfig{Secret: "secret"})
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	writeFile(t, dir, "short.secret", "secret")
+
+	_, err = handler.NewKeyRing(handler.KeyRingConfig{Dir: dir})
+	assert.Error(t, err)
+}
+
+func TestKeyRingRejectsForeignTokens(t *testing.T) {
+	ring, err := handler.NewKeyRing(handler.KeyRingConfig{Secret: testSecret, SecretKeyID: "main"})
+	require.NoError(t, err)
+
+	// Токен без kid
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &handler.Claims{UserID: 1})
+	signed, err := token.SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	assert.Error(t, parse(ring, signed))
+
+	// Токен с известным kid, но другим алгоритмом
+	token = jwt.NewWithClaims(jwt.SigningMethodHS512, &handler.Claims{UserID: 1})
+	token.Header["kid"] = "main"
+	signed, err = token.SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	assert.Error(t, parse(ring, signed))
+}
+
+func TestKeyRingJWKS(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "ed")
+
+	ring, err := handler.NewKeyRing(handler.KeyRingConfig{Dir: dir, Secret: testSecret})
+	require.NoError(t, err)
+
+	// Секрет HS256 не публикуется
+	jwks := ring.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "ed", jwks.Keys[0].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+	require.NotNil(t, jwks.Keys[0].X)
+}
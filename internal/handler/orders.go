@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+
+	"avito_coin/api"
+	"avito_coin/internal/db"
+	"avito_coin/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// GetApiOrders - обработчик для получения заказов пользователя.
+func (h *CoinHandler) GetAPIOrders(c echo.Context) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/orders",
+		"method":   "GET",
+	}).Info("GetApiOrders request received")
+
+	userID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	orders, err := h.service.ListUserOrders(c.Request().Context(), userID)
+	if err != nil {
+		return respondWithError(c, http.StatusInternalServerError, "Failed to list orders", err)
+	}
+
+	return respondWithSuccess(c, ordersResponse(orders), logrus.Fields{
+		"user_id": userID,
+		"orders":  len(orders),
+	})
+}
+
+// GetApiOrdersId - обработчик для получения заказа пользователя.
+func (h *CoinHandler) GetAPIOrdersID(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/orders/:id",
+		"method":   "GET",
+	}).Info("GetApiOrdersId request received")
+
+	orderID, err := validateOrderID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Order not found", err)
+	}
+
+	userID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	details, err := h.service.GetUserOrder(c.Request().Context(), userID, orderID)
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, orderDetailsResponse(details), logrus.Fields{
+		"user_id":  userID,
+		"order_id": orderID,
+	})
+}
+
+// PostApiOrdersIdCancel - обработчик для отмены заказа пользователем.
+func (h *CoinHandler) PostAPIOrdersIDCancel(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/orders/:id/cancel",
+		"method":   "POST",
+	}).Info("PostApiOrdersIdCancel request received")
+
+	orderID, err := validateOrderID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Order not found", err)
+	}
+
+	userID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	details, err := h.service.CancelOrder(c.Request().Context(), userID, orderID)
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, orderDetailsResponse(details), logrus.Fields{
+		"user_id":  userID,
+		"order_id": orderID,
+	})
+}
+
+// GetApiAdminOrders - обработчик для получения заказов для выдачи.
+func (h *CoinHandler) GetAPIAdminOrders(c echo.Context, params api.GetApiAdminOrdersParams) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/orders",
+		"method":   "GET",
+	}).Info("GetApiAdminOrders request received")
+
+	status := ""
+	if params.Status != nil {
+		status = *params.Status
+	}
+
+	orders, err := h.service.ListOrders(c.Request().Context(), status)
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, ordersResponse(orders), logrus.Fields{
+		"status": status,
+		"orders": len(orders),
+	})
+}
+
+// GetApiAdminOrdersId - обработчик для получения заказа администратором магазина.
+func (h *CoinHandler) GetAPIAdminOrdersID(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/orders/:id",
+		"method":   "GET",
+	}).Info("GetApiAdminOrdersId request received")
+
+	orderID, err := validateOrderID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Order not found", err)
+	}
+
+	details, err := h.service.GetOrder(c.Request().Context(), orderID)
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, orderDetailsResponse(details), logrus.Fields{
+		"order_id": orderID,
+	})
+}
+
+// PatchApiAdminOrdersId - обработчик для смены статуса заказа.
+func (h *CoinHandler) PatchAPIAdminOrdersID(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/orders/:id",
+		"method":   "PATCH",
+	}).Info("PatchApiAdminOrdersId request received")
+
+	orderID, err := validateOrderID(id)
+	if err != nil {
+		return respondWithError(c, http.StatusNotFound, "Order not found", err)
+	}
+
+	var request api.OrderStatusRequest
+	if err := c.Bind(&request); err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	details, err := h.service.UpdateOrderStatus(c.Request().Context(), actorID, orderID, service.OrderStatusUpdate{
+		Status:         request.Status,
+		PickupLocation: request.PickupLocation,
+		Note:           request.Note,
+	})
+	if err != nil {
+		return err
+	}
+
+	return respondWithSuccess(c, orderDetailsResponse(details), logrus.Fields{
+		"order_id": orderID,
+		"actor_id": actorID,
+		"status":   details.Order.Status,
+	})
+}
+
+// validateOrderID - проверка ID заказа.
+func validateOrderID(id int) (int32, error) {
+	if id <= 0 || id > math.MaxInt32 {
+		return 0, errors.New("order ID is out of range")
+	}
+
+	return int32(id), nil
+}
+
+// orderResponse - заказ в формате API.
+func orderResponse(order db.Order) api.Order {
+	response := api.Order{
+		Id:        int(order.ID),
+		Total:     int(order.Total),
+		Status:    order.Status,
+		CreatedAt: order.CreatedAt,
+		UpdatedAt: order.UpdatedAt,
+	}
+
+	if order.PickupLocation.Valid {
+		response.PickupLocation = &order.PickupLocation.String
+	}
+
+	if order.Notes.Valid {
+		response.Notes = &order.Notes.String
+	}
+
+	return response
+}
+
+// ordersResponse - список заказов в формате API.
+func ordersResponse(orders []db.Order) []api.Order {
+	response := make([]api.Order, 0, len(orders))
+	for _, order := range orders {
+		response = append(response, orderResponse(order))
+	}
+
+	return response
+}
+
+// orderDetailsResponse - заказ с составом и историей в формате API.
+func orderDetailsResponse(details service.OrderDetails) api.Order {
+	response := orderResponse(details.Order)
+
+	items := make([]api.OrderItem, 0, len(details.Items))
+	for _, item := range details.Items {
+		orderItem := api.OrderItem{
+			MerchId:  int(item.MerchID.Int32),
+			Name:     item.Name,
+			Quantity: int(item.Quantity),
+			Price:    int(item.Price.Int32),
+		}
+
+		if item.Variant.Valid {
+			orderItem.Variant = &item.Variant.String
+		}
+
+		items = append(items, orderItem)
+	}
+
+	history := make([]api.OrderEvent, 0, len(details.History))
+	for _, event := range details.History {
+		orderEvent := api.OrderEvent{
+			Status:    event.Status,
+			CreatedAt: event.CreatedAt,
+		}
+
+		if event.Actor.Valid {
+			orderEvent.Actor = &event.Actor.String
+		}
+
+		if event.Note.Valid {
+			orderEvent.Note = &event.Note.String
+		}
+
+		history = append(history, orderEvent)
+	}
+
+	response.Items = &items
+	response.History = &history
+
+	return response
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+
+	"avito_coin/api"
+	"avito_coin/internal/db"
+	"avito_coin/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// PostApiAdminTransactionsIdReverse - обработчик для сторно перевода.
+func (h *CoinHandler) PostAPIAdminTransactionsIDReverse(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/transactions/:id/reverse",
+		"method":   "POST",
+	}).Info("PostApiAdminTransactionsIdReverse request received")
+
+	if id <= 0 || id > math.MaxInt32 {
+		return respondWithError(c, http.StatusNotFound, "Transfer not found", nil)
+	}
+
+	input, err := bindReversalRequest(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	reversal, err := h.service.ReverseTransfer(c.Request().Context(), actorID, int32(id), input)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, reversalResponse(reversal))
+}
+
+// PostApiAdminPurchasesIdRefund - обработчик для возврата покупки.
+func (h *CoinHandler) PostAPIAdminPurchasesIDRefund(c echo.Context, id int) error {
+	h.log(c).WithFields(logrus.Fields{
+		"endpoint": "/admin/purchases/:id/refund",
+		"method":   "POST",
+	}).Info("PostApiAdminPurchasesIdRefund request received")
+
+	if id <= 0 || id > math.MaxInt32 {
+		return respondWithError(c, http.StatusNotFound, "Purchase not found", nil)
+	}
+
+	input, err := bindReversalRequest(c)
+	if err != nil {
+		return respondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+
+	actorID, err := extractUserID(c)
+	if err != nil {
+		return respondWithError(c, http.StatusUnauthorized, "Invalid user ID", err)
+	}
+
+	reversal, err := h.service.RefundPurchase(c.Request().Context(), actorID, int32(id), input)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, reversalResponse(reversal))
+}
+
+// bindReversalRequest - разбор суммы и причины сторно.
+func bindReversalRequest(c echo.Context) (service.ReversalInput, error) {
+	var request api.ReversalRequest
+	if err := c.Bind(&request); err != nil {
+		return service.ReversalInput{}, err
+	}
+
+	input := service.ReversalInput{Reason: request.Reason}
+
+	if request.Amount != nil {
+		amount, err := validateAmount(*request.Amount)
+		if err != nil {
+			return service.ReversalInput{}, err
+		}
+
+		input.Amount = &amount
+	}
+
+	return input, nil
+}
+
+// reversalResponse - сторно в формате API.
+func reversalResponse(reversal db.Reversal) api.Reversal {
+	response := api.Reversal{
+		Id:        int(reversal.ID),
+		Type:      "transfer",
+		Amount:    int(reversal.Amount),
+		Reason:    reversal.Reason,
+		CreatedAt: reversal.CreatedAt,
+	}
+
+	if reversal.TransactionID.Valid {
+		response.OriginalId = int(reversal.TransactionID.Int32)
+	} else {
+		response.Type = "purchase"
+		response.OriginalId = int(reversal.PurchaseID.Int32)
+	}
+
+	return response
+}
@@ -0,0 +1,75 @@
+package handler_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"avito_coin/internal/handler"
+	"avito_coin/internal/service"
+	"avito_coin/internal/tracing"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Тест: запрос продолжает трейс из заголовка traceparent, записи лога получают его trace_id,
+// а проверки состояния в трейсы не попадают.
+func TestTracing(t *testing.T) {
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+
+	spans := tracetest.NewSpanRecorder()
+
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var logs bytes.Buffer
+
+	hooks := logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+	logrus.AddHook(tracing.LogHook{})
+	logrus.SetOutput(&logs)
+
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+		logrus.StandardLogger().ReplaceHooks(hooks)
+		logrus.SetOutput(os.Stderr)
+	})
+
+	keys, err := handler.NewKeyRing(handler.KeyRingConfig{Secret: testSecret, SecretKeyID: "main"})
+	require.NoError(t, err)
+
+	e := echo.New()
+	handler.NewCoinHandler(e, service.NewCoinService(nil), keys, handler.WithTracing("coin_service"))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/auth", strings.NewReader("{"))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	assert.Equal(t, "/api/auth", ended[0].Name())
+	assert.Equal(t, traceID, ended[0].SpanContext().TraceID().String())
+	assert.Equal(t, parentSpanID, ended[0].Parent().SpanID().String())
+
+	assert.Contains(t, logs.String(), "trace_id="+traceID)
+	assert.Contains(t, logs.String(), "span_id="+ended[0].SpanContext().SpanID().String())
+}
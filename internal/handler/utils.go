@@ -6,8 +6,10 @@ import (
 	"math"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"avito_coin/api"
+	"avito_coin/internal/service"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 )
@@ -21,20 +23,51 @@ func parseAuthRequest(c echo.Context) (*api.AuthRequest, error) {
 	return &request, nil
 }
 
+func parseRegisterRequest(c echo.Context) (*api.RegisterRequest, error) {
+	var request api.RegisterRequest
+	if err := c.Bind(&request); err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+func parseRefreshRequest(c echo.Context) (*api.RefreshRequest, error) {
+	var request api.RefreshRequest
+	if err := c.Bind(&request); err != nil {
+		return nil, err
+	}
+
+	if request.RefreshToken == "" {
+		return nil, fmt.Errorf("refresh token is required")
+	}
+
+	return &request, nil
+}
+
+func parseLogoutRequest(c echo.Context) (*api.LogoutRequest, error) {
+	var request api.LogoutRequest
+	if err := c.Bind(&request); err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
 func parseSendCoinRequest(c echo.Context) (*api.SendCoinRequest, error) {
 	var request api.SendCoinRequest
 	if err := c.Bind(&request); err != nil {
-		logrus.WithFields(logrus.Fields{"error": err.Error()}).Error("Failed to parse request body")
+		logrus.WithContext(c.Request().Context()).WithFields(logrus.Fields{"error": err.Error()}).Error("Failed to parse request body")
 		return nil, err
 	}
 
 	return &request, nil
 }
 
-func parseMerchID(item string) (int32, error) {
+func parseMerchID(c echo.Context, item string) (int32, error) {
 	merchID, err := strconv.ParseInt(item, 10, 32)
 	if err != nil {
-		logrus.WithFields(logrus.Fields{
+		logrus.WithContext(c.Request().Context()).WithFields(logrus.Fields{
 			"error":    err.Error(),
 			"merch_id": item,
 		}).Error("Invalid merch ID")
@@ -45,71 +78,115 @@ func parseMerchID(item string) (int32, error) {
 	return int32(merchID), nil
 }
 
+// log - запись в лог обработчика с контекстом запроса c, чтобы в нее попали идентификаторы трейса.
+func (h *CoinHandler) log(c echo.Context) *logrus.Entry {
+	return h.logger.WithContext(c.Request().Context())
+}
+
 func respondWithError(c echo.Context, statusCode int, message string, err error) error {
-	logrus.WithFields(logrus.Fields{
+	logrus.WithContext(c.Request().Context()).WithFields(logrus.Fields{
 		"error": err,
 	}).Error(message)
 
-	errorMessage := message
-
-	return c.JSON(statusCode, api.ErrorResponse{
-		Errors: &errorMessage,
-	})
+	return c.JSON(statusCode, newErrorResponse(codeForStatus(statusCode), message, nil))
 }
 
-func respondWithToken(c echo.Context, userID int32, logMessage string) error {
-	token, err := generateJWT(userID)
+func (h *CoinHandler) respondWithToken(c echo.Context, statusCode int, userID int32, refreshToken, logMessage string) error {
+	token, err := h.generateJWT(c.Request().Context(), userID)
 	if err != nil {
 		return respondWithError(c, http.StatusInternalServerError, "Failed to generate JWT", err)
 	}
 
-	logrus.WithFields(logrus.Fields{
+	h.log(c).WithFields(logrus.Fields{
 		"user_id": userID,
 	}).Info(logMessage)
 
-	return c.JSON(http.StatusOK, api.AuthResponse{
-		Token: &token,
+	expiresIn := int(h.accessTokenTTL.Seconds())
+
+	return c.JSON(statusCode, api.AuthResponse{
+		Token:        &token,
+		RefreshToken: &refreshToken,
+		ExpiresIn:    &expiresIn,
 	})
 }
 
 func respondWithSuccess(c echo.Context, message interface{}, fields logrus.Fields) error {
-	logrus.WithFields(fields).Info(message)
+	logrus.WithContext(c.Request().Context()).WithFields(fields).Info(message)
 	return c.JSON(http.StatusOK, message)
 }
 
-func respondWithTransferError(c echo.Context, err error, fromUser int32, toUser string, amount int32) error {
-	logrus.WithFields(logrus.Fields{
-		"error":     err.Error(),
-		"from_user": fromUser,
-		"to_user":   toUser,
-		"amount":    amount,
-	}).Error("Failed to transfer coins")
+func validateAmount(value int) (int32, error) {
+	if value > math.MaxInt32 || value < math.MinInt32 {
+		return 0, fmt.Errorf("amount value %d is out of range for int32", value)
+	}
 
-	return respondWithError(c, http.StatusInternalServerError, "Failed to transfer coins", err)
+	return int32(value), nil
 }
 
-func validatePassword(inputPassword, storedPassword string) bool {
-	return inputPassword == storedPassword
+func validateMerchID(value int) (int32, error) {
+	if value <= 0 || value > math.MaxInt32 {
+		return 0, fmt.Errorf("merch ID %d is out of range", value)
+	}
+
+	return int32(value), nil
 }
 
-func validateAmount(value int) (int32, error) {
-	if value > math.MaxInt32 || value < math.MinInt32 {
-		return 0, fmt.Errorf("amount value %d is out of range for int32", value)
+func validateReservationID(value int) (int32, error) {
+	if value <= 0 || value > math.MaxInt32 {
+		return 0, fmt.Errorf("reservation ID %d is out of range", value)
 	}
 
 	return int32(value), nil
 }
 
+// variantSku - артикул варианта из необязательного параметра запроса.
+func variantSku(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(*value)
+}
+
+// transferNote - сообщение, теги и видимость перевода из запроса.
+func transferNote(request *api.SendCoinRequest) service.TransferNote {
+	var note service.TransferNote
+
+	if request.Message != nil {
+		note.Message = *request.Message
+	}
+
+	if request.Tags != nil {
+		note.Tags = *request.Tags
+	}
+
+	if request.Public != nil {
+		note.Public = *request.Public
+	}
+
+	return note
+}
+
 func extractUserID(c echo.Context) (int32, error) {
 	userID, ok := c.Get("jwt_user_id").(int32)
 	if !ok {
-		logrus.Error("Failed to extract user ID from JWT")
+		logrus.WithContext(c.Request().Context()).Error("Failed to extract user ID from JWT")
 		return 0, fmt.Errorf("invalid user ID")
 	}
 
 	return userID, nil
 }
 
+func extractClaims(c echo.Context) (*Claims, error) {
+	claims, ok := c.Get("jwt_claims").(*Claims)
+	if !ok {
+		logrus.WithContext(c.Request().Context()).Error("Failed to extract claims from JWT")
+		return nil, fmt.Errorf("invalid token claims")
+	}
+
+	return claims, nil
+}
+
 func extractMerchID(c echo.Context) (int32, error) {
 	merchID, err := strconv.ParseInt(c.Param("merch_id"), 10, 32)
 	if err != nil {
@@ -138,9 +215,27 @@ func (h *CoinHandler) getUserInfo(ctx context.Context, userID int32) (*api.InfoR
 		return nil, fmt.Errorf("failed to get user transactions: %w", err)
 	}
 
+	reversals, err := h.service.GetUserReversals(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user reversals: %w", err)
+	}
+
+	history := make([]api.Reversal, 0, len(reversals))
+	for _, reversal := range reversals {
+		history = append(history, api.Reversal{
+			Id:         int(reversal.ID),
+			Type:       reversal.Type,
+			OriginalId: int(reversal.OriginalID),
+			Amount:     int(reversal.Amount),
+			Reason:     reversal.Reason,
+			CreatedAt:  reversal.CreatedAt,
+		})
+	}
+
 	info.Coins = balance.Coins
 	info.Inventory = purchases.Inventory
 	info.CoinHistory = transactions.CoinHistory
+	info.CoinHistory.Reversals = &history
 
 	return &info, nil
 }
@@ -0,0 +1,148 @@
+// Package health - проверки зависимостей сервиса для /readyz: подключение к БД, версия миграций,
+// свободные подключения пула и состояние жизненного цикла.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"avito_coin/internal/db"
+	"avito_coin/internal/lifecycle"
+)
+
+// CheckTimeout - сколько ждать одну проверку.
+const CheckTimeout = 2 * time.Second
+
+// Статусы проверки.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check - проверка зависимости. Run возвращает подробности для ответа и ошибку, если проверка не пройдена.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (map[string]interface{}, error)
+}
+
+// Result - результат одной проверки.
+type Result struct {
+	Name     string
+	Status   string
+	Duration time.Duration
+	Error    string
+	Details  map[string]interface{}
+}
+
+// Run - одновременный запуск проверок, каждая ограничена CheckTimeout. Результаты идут в порядке checks,
+// ok - пройдены ли все проверки.
+func Run(ctx context.Context, checks []Check) ([]Result, bool) {
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+
+	for i, check := range checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, CheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			details, err := check.Run(checkCtx)
+
+			results[i] = Result{Name: check.Name, Status: StatusOK, Duration: time.Since(start), Details: details}
+			if err != nil {
+				results[i].Status = StatusFail
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	ok := true
+	for _, result := range results {
+		ok = ok && result.Status == StatusOK
+	}
+
+	return results, ok
+}
+
+// Lifecycle - сервис запущен и не останавливается.
+func Lifecycle(lc *lifecycle.Lifecycle) Check {
+	return Check{
+		Name: "lifecycle",
+		Run: func(context.Context) (map[string]interface{}, error) {
+			if !lc.Ready() {
+				return nil, errors.New("service is starting or shutting down")
+			}
+
+			return nil, nil
+		},
+	}
+}
+
+// Database - БД отвечает на ping.
+func Database(database *sql.DB) Check {
+	return Check{
+		Name: "database",
+		Run: func(ctx context.Context) (map[string]interface{}, error) {
+			return nil, database.PingContext(ctx)
+		},
+	}
+}
+
+// Migrations - в БД применены все миграции, встроенные в сервис.
+func Migrations(database *sql.DB) Check {
+	return Check{
+		Name: "migrations",
+		Run: func(ctx context.Context) (map[string]interface{}, error) {
+			expected, err := db.LatestMigrationVersion()
+			if err != nil {
+				return nil, err
+			}
+
+			current, err := db.MigrationVersion(ctx, database)
+			if err != nil {
+				return nil, err
+			}
+
+			details := map[string]interface{}{"current": current, "expected": expected}
+			if current != expected {
+				return details, fmt.Errorf("database is at migration %d, expected %d", current, expected)
+			}
+
+			return details, nil
+		},
+	}
+}
+
+// Pool - в пуле есть свободные подключения.
+func Pool(database *sql.DB) Check {
+	return Check{
+		Name: "pool",
+		Run: func(context.Context) (map[string]interface{}, error) {
+			stats := database.Stats()
+			details := map[string]interface{}{
+				"open":      stats.OpenConnections,
+				"inUse":     stats.InUse,
+				"idle":      stats.Idle,
+				"maxOpen":   stats.MaxOpenConnections,
+				"waitCount": stats.WaitCount,
+			}
+
+			if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+				return details, fmt.Errorf("all %d connections are in use", stats.MaxOpenConnections)
+			}
+
+			return details, nil
+		},
+	}
+}
@@ -0,0 +1,69 @@
+// Package lifecycle - готовность сервиса к приему запросов и фоновые задачи, которые
+// останавливаются при завершении. Порядок остановки задает main: сначала сервис перестает
+// быть готовым, затем дорабатывают запросы, затем останавливаются фоновые задачи и закрывается БД.
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Lifecycle - состояние готовности и фоновые задачи сервиса.
+type Lifecycle struct {
+	ready atomic.Bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	tasks  sync.WaitGroup
+}
+
+// New - жизненный цикл сервиса, который еще не готов принимать запросы.
+func New() *Lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Lifecycle{ctx: ctx, cancel: cancel}
+}
+
+// SetReady - готов ли сервис принимать запросы.
+func (l *Lifecycle) SetReady(ready bool) {
+	l.ready.Store(ready)
+}
+
+// Ready - готов ли сервис принимать запросы. Перестает быть готовым в начале остановки.
+func (l *Lifecycle) Ready() bool {
+	return l.ready.Load()
+}
+
+// Go - запуск фоновой задачи. Контекст задачи отменяется в StopTasks, задача должна после этого завершиться.
+func (l *Lifecycle) Go(name string, task func(ctx context.Context)) {
+	l.tasks.Add(1)
+
+	go func() {
+		defer l.tasks.Done()
+		defer logrus.WithField("task", name).Info("Background task stopped")
+
+		task(l.ctx)
+	}()
+}
+
+// StopTasks - отмена фоновых задач и ожидание их завершения, но не дольше дедлайна ctx.
+func (l *Lifecycle) StopTasks(ctx context.Context) error {
+	l.cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		l.tasks.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
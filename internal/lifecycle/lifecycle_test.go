@@ -0,0 +1,50 @@
+package lifecycle_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"avito_coin/internal/lifecycle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: StopTasks отменяет контекст фоновых задач и дожидается их завершения.
+func TestStopTasks(t *testing.T) {
+	lc := lifecycle.New()
+	assert.False(t, lc.Ready())
+
+	lc.SetReady(true)
+	assert.True(t, lc.Ready())
+
+	var stopped atomic.Int32
+
+	for range 3 {
+		lc.Go("worker", func(ctx context.Context) {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			stopped.Add(1)
+		})
+	}
+
+	require.NoError(t, lc.StopTasks(context.Background()))
+	assert.Equal(t, int32(3), stopped.Load())
+}
+
+// Тест: задача, которая не завершается, не задерживает остановку дольше дедлайна.
+func TestStopTasksDeadline(t *testing.T) {
+	lc := lifecycle.New()
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	lc.Go("stuck", func(context.Context) {
+		<-release
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, lc.StopTasks(ctx), context.DeadlineExceeded)
+}
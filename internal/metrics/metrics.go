@@ -0,0 +1,188 @@
+// Package metrics - метрики Prometheus: HTTP-запросы, переводы и покупки, пул подключений к БД
+// и количество монет в обращении.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+// namespace - префикс метрик сервиса.
+const namespace = "coin"
+
+// ScrapeTimeout - сколько ждать запроса к БД при сборе метрик.
+const ScrapeTimeout = 2 * time.Second
+
+// unmatchedRoute - маршрут запросов, для которых не нашлось обработчика.
+const unmatchedRoute = "unmatched"
+
+// Metrics - реестр метрик сервиса. Реализует service.Metrics.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+
+	transfers        prometheus.Counter
+	transferredCoins prometheus.Counter
+	purchases        *prometheus.CounterVec
+	purchaseFailures *prometheus.CounterVec
+}
+
+// New - реестр с метриками HTTP, переводов, покупок, а также процесса и рантайма Go.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+
+		transfers: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transfers_total",
+			Help:      "Completed coin transfers.",
+		}),
+		transferredCoins: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transferred_coins_total",
+			Help:      "Coins moved by completed transfers.",
+		}),
+		purchases: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "purchases_total",
+			Help:      "Purchased merch units by merch item.",
+		}, []string{"merch_id"}),
+		purchaseFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "purchase_failures_total",
+			Help:      "Failed purchases by reason.",
+		}, []string{"reason"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.transfers,
+		m.transferredCoins,
+		m.purchases,
+		m.purchaseFailures,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// RegisterDB - метрики пула подключений sql.DBStats: открытые, занятые и простаивающие подключения,
+// количество и общее время ожидания свободного подключения.
+func (m *Metrics) RegisterDB(database *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(database, namespace))
+}
+
+// RegisterCirculation - метрика количества монет в обращении; circulation вызывается при каждом сборе метрик.
+func (m *Metrics) RegisterCirculation(circulation func(ctx context.Context) (int64, error)) {
+	m.registry.MustRegister(&circulationCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "coins_in_circulation"),
+			"Coins on user accounts.",
+			nil, nil,
+		),
+		circulation: circulation,
+	})
+}
+
+// Handler - HTTP-обработчик, отдающий метрики. Ошибка одного сборщика не мешает остальным метрикам.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// Middleware - учет количества и длительности HTTP-запросов по шаблону маршрута и статусу ответа.
+func (m *Metrics) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			// Ошибка обрабатывается здесь, чтобы статус ответа был известен, и возвращается
+			// внешним middleware: повторно ответ не пишется, так как он уже отправлен
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" || route == "/*" {
+				route = unmatchedRoute
+			}
+
+			labels := prometheus.Labels{
+				"method": c.Request().Method,
+				"route":  route,
+				"status": strconv.Itoa(c.Response().Status),
+			}
+
+			m.requests.With(labels).Inc()
+			m.requestDuration.With(labels).Observe(time.Since(start).Seconds())
+
+			return err
+		}
+	}
+}
+
+// TransferCompleted - проведен перевод amount монет.
+func (m *Metrics) TransferCompleted(amount int32) {
+	m.transfers.Inc()
+	m.transferredCoins.Add(float64(amount))
+}
+
+// PurchaseCompleted - куплено quantity единиц товара merchID.
+func (m *Metrics) PurchaseCompleted(merchID, quantity int32) {
+	m.purchases.WithLabelValues(strconv.Itoa(int(merchID))).Add(float64(quantity))
+}
+
+// PurchaseFailed - покупка не прошла по причине reason.
+func (m *Metrics) PurchaseFailed(reason string) {
+	m.purchaseFailures.WithLabelValues(reason).Inc()
+}
+
+// circulationCollector - количество монет в обращении, которое запрашивается при сборе метрик.
+type circulationCollector struct {
+	desc        *prometheus.Desc
+	circulation func(ctx context.Context) (int64, error)
+}
+
+func (c *circulationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *circulationCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), ScrapeTimeout)
+	defer cancel()
+
+	coins, err := c.circulation(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to collect coins in circulation")
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(coins))
+}
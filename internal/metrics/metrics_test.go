@@ -0,0 +1,109 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"avito_coin/internal/metrics"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scrape - метрики в текстовом формате Prometheus.
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+// Тест: запросы учитываются по шаблону маршрута и статусу ответа, включая ответы с ошибкой.
+func TestMiddleware(t *testing.T) {
+	m := metrics.New()
+
+	// Внешние middleware (например, трейсинг) по-прежнему видят ошибку обработчика
+	var seen []error
+
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			if err != nil {
+				seen = append(seen, err)
+			}
+
+			return err
+		}
+	})
+	e.Use(m.Middleware())
+	e.GET("/api/merch/:merch_id", func(c echo.Context) error {
+		if c.Param("merch_id") == "0" {
+			return echo.ErrNotFound
+		}
+
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/api/merch/1", "/api/merch/2", "/api/merch/0", "/unknown"} {
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		// Ответ с ошибкой пишется один раз
+		if recorder.Code == http.StatusNotFound {
+			assert.JSONEq(t, `{"message":"Not Found"}`, recorder.Body.String())
+		}
+	}
+
+	assert.Len(t, seen, 2)
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `coin_http_requests_total{method="GET",route="/api/merch/:merch_id",status="200"} 2`)
+	assert.Contains(t, body, `coin_http_requests_total{method="GET",route="/api/merch/:merch_id",status="404"} 1`)
+	assert.Contains(t, body, `coin_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `coin_http_request_duration_seconds_count{method="GET",route="/api/merch/:merch_id",status="200"} 2`)
+}
+
+// Тест: переводы, покупки и монеты в обращении попадают в метрики; ошибка подсчета монет не ломает остальные метрики.
+func TestBusinessMetrics(t *testing.T) {
+	m := metrics.New()
+
+	m.TransferCompleted(100)
+	m.TransferCompleted(50)
+	m.PurchaseCompleted(3, 2)
+	m.PurchaseCompleted(3, 1)
+	m.PurchaseFailed("sold_out")
+
+	circulationErr := error(nil)
+	m.RegisterCirculation(func(context.Context) (int64, error) {
+		return 4200, circulationErr
+	})
+
+	body := scrape(t, m)
+	for _, line := range []string{
+		"coin_transfers_total 2",
+		"coin_transferred_coins_total 150",
+		`coin_purchases_total{merch_id="3"} 3`,
+		`coin_purchase_failures_total{reason="sold_out"} 1`,
+		"coin_coins_in_circulation 4200",
+	} {
+		assert.Contains(t, body, line)
+	}
+
+	circulationErr = errors.New("database is down")
+
+	body = scrape(t, m)
+	assert.False(t, strings.Contains(body, "coin_coins_in_circulation "))
+	assert.Contains(t, body, "coin_transfers_total 2")
+}
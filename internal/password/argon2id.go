@@ -0,0 +1,117 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// AlgorithmArgon2id - имя алгоритма argon2id.
+const AlgorithmArgon2id = "argon2id"
+
+// errInvalidArgon2idHash - строка не является хешем argon2id.
+var errInvalidArgon2idHash = errors.New("invalid argon2id hash")
+
+// Argon2id - параметры argon2id. Хеш хранится в формате PHC:
+// $argon2id$v=19$m=<память в КиБ>,t=<итерации>,p=<потоки>$<соль>$<хеш>.
+type Argon2id struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2id - параметры argon2id по умолчанию (рекомендации OWASP).
+func DefaultArgon2id() Argon2id {
+	return Argon2id{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Hash - хеширование пароля argon2id со случайной солью.
+func (a Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify - проверка пароля с параметрами, сохраненными в хеше.
+func (a Argon2id) Verify(password, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(actual, key) == 1, nil
+}
+
+// Recognizes - является ли строка хешем argon2id.
+func (a Argon2id) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+// NeedsRehash - отличаются ли параметры хеша от текущих.
+func (a Argon2id) NeedsRehash(encoded string) bool {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != a.Memory ||
+		params.Iterations != a.Iterations ||
+		params.Parallelism != a.Parallelism ||
+		uint32(len(salt)) != a.SaltLength ||
+		uint32(len(key)) != a.KeyLength
+}
+
+// decodeArgon2id - разбор строки хеша argon2id.
+func decodeArgon2id(encoded string) (params Argon2id, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, errInvalidArgon2idHash
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errInvalidArgon2idHash
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return params, nil, nil, errInvalidArgon2idHash
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, errInvalidArgon2idHash
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return params, nil, nil, errInvalidArgon2idHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
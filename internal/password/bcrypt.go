@@ -0,0 +1,54 @@
+package password
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AlgorithmBcrypt - имя алгоритма bcrypt.
+const AlgorithmBcrypt = "bcrypt"
+
+// Bcrypt - параметры bcrypt. Стоимость хранится в самом хеше ($2a$<стоимость>$...).
+type Bcrypt struct {
+	Cost int
+}
+
+// DefaultBcrypt - параметры bcrypt по умолчанию.
+func DefaultBcrypt() Bcrypt {
+	return Bcrypt{Cost: 12}
+}
+
+// Hash - хеширование пароля bcrypt.
+func (b Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Verify - проверка пароля.
+func (b Bcrypt) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Recognizes - является ли строка хешем bcrypt.
+func (b Bcrypt) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+// NeedsRehash - отличается ли стоимость хеша от текущей.
+func (b Bcrypt) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != b.Cost
+}
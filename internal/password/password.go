@@ -0,0 +1,95 @@
+// Package password - хеширование паролей с настраиваемым алгоритмом.
+// Параметры алгоритма хранятся в самой строке хеша, поэтому пароли, захешированные
+// со старыми параметрами или другим алгоритмом, продолжают проверяться и перехешируются при входе.
+package password
+
+import (
+	"crypto/subtle"
+	"errors"
+	"sync"
+)
+
+// ErrUnknownAlgorithm - алгоритм хеширования не поддерживается.
+var ErrUnknownAlgorithm = errors.New("unknown password hashing algorithm")
+
+// Algorithm - алгоритм хеширования паролей.
+type Algorithm interface {
+	// Hash - хеширует пароль, соль и параметры сохраняются в результате.
+	Hash(password string) (string, error)
+	// Verify - совпадает ли пароль с хешем.
+	Verify(password, encoded string) (bool, error)
+	// Recognizes - создан ли хеш этим алгоритмом.
+	Recognizes(encoded string) bool
+	// NeedsRehash - хеш создан этим алгоритмом, но с другими параметрами.
+	NeedsRehash(encoded string) bool
+}
+
+// dummyPassword - пароль хеша-заглушки для проверки входа неизвестного пользователя.
+const dummyPassword = "dummy password"
+
+// Hasher - хеширует пароли предпочтительным алгоритмом и проверяет хеши всех известных алгоритмов.
+type Hasher struct {
+	preferred Algorithm
+	known     []Algorithm
+
+	// dummy - хеш-заглушка с параметрами предпочтительного алгоритма, создается при первом использовании.
+	dummy     string
+	dummyOnce sync.Once
+}
+
+// NewHasher - создание хешера; кроме предпочтительного распознаются хеши argon2id и bcrypt с любыми параметрами.
+func NewHasher(preferred Algorithm) *Hasher {
+	return &Hasher{
+		preferred: preferred,
+		known:     []Algorithm{preferred, DefaultArgon2id(), DefaultBcrypt()},
+	}
+}
+
+// New - создание хешера по имени алгоритма ("argon2id" или "bcrypt").
+func New(algorithm string, argon2id Argon2id, bcrypt Bcrypt) (*Hasher, error) {
+	switch algorithm {
+	case "", AlgorithmArgon2id:
+		return NewHasher(argon2id), nil
+	case AlgorithmBcrypt:
+		return NewHasher(bcrypt), nil
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+}
+
+// Hash - хеширование пароля предпочтительным алгоритмом.
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+// Verify - проверка пароля. rehash сообщает, что хеш нужно пересчитать предпочтительным алгоритмом:
+// он создан другим алгоритмом, с устаревшими параметрами или это пароль в открытом виде из старых записей.
+func (h *Hasher) Verify(password, encoded string) (ok, rehash bool, err error) {
+	for _, algorithm := range h.known {
+		if !algorithm.Recognizes(encoded) {
+			continue
+		}
+
+		ok, err = algorithm.Verify(password, encoded)
+		if err != nil || !ok {
+			return false, false, err
+		}
+
+		return true, algorithm != h.preferred || algorithm.NeedsRehash(encoded), nil
+	}
+
+	// Пароль в открытом виде, сохраненный до появления хеширования
+	ok = subtle.ConstantTimeCompare([]byte(password), []byte(encoded)) == 1
+
+	return ok, ok, nil
+}
+
+// VerifyDummy - проверка пароля по хешу-заглушке, когда пользователя нет. Занимает столько же времени,
+// сколько проверка настоящего хеша, поэтому по времени ответа нельзя узнать, существует ли пользователь.
+func (h *Hasher) VerifyDummy(password string) {
+	h.dummyOnce.Do(func() {
+		h.dummy, _ = h.preferred.Hash(dummyPassword)
+	})
+
+	_, _ = h.preferred.Verify(password, h.dummy)
+}
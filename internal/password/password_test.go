@@ -0,0 +1,159 @@
+package password_test
+
+import (
+	"fmt"
+	"testing"
+
+	"avito_coin/internal/password"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastArgon2id - облегченные параметры, чтобы тесты выполнялись быстро.
+func fastArgon2id() password.Argon2id {
+	return password.Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func TestHashAndVerify(t *testing.T) {
+	algorithms := map[string]password.Algorithm{
+		"argon2id": fastArgon2id(),
+		"bcrypt":   password.Bcrypt{Cost: 4},
+	}
+
+	for name, algorithm := range algorithms {
+		t.Run(name, func(t *testing.T) {
+			hasher := password.NewHasher(algorithm)
+
+			hash, err := hasher.Hash("secret")
+			require.NoError(t, err)
+			assert.NotEqual(t, "secret", hash)
+
+			// Правильный пароль подходит и не требует перехеширования
+			ok, rehash, err := hasher.Verify("secret", hash)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.False(t, rehash)
+
+			// Неправильный пароль не подходит
+			ok, _, err = hasher.Verify("wrong", hash)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestVerifyLegacyPlaintext(t *testing.T) {
+	hasher := password.NewHasher(fastArgon2id())
+
+	// Пароль в открытом виде из старых записей подходит и должен быть перехеширован
+	ok, rehash, err := hasher.Verify("test", "test")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, rehash)
+
+	ok, rehash, err = hasher.Verify("wrong", "test")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, rehash)
+}
+
+func TestVerifyNeedsRehash(t *testing.T) {
+	// Хеш bcrypt проверяется хешером argon2id и требует перехеширования
+	bcryptHash, err := password.NewHasher(password.Bcrypt{Cost: 4}).Hash("secret")
+	require.NoError(t, err)
+
+	ok, rehash, err := password.NewHasher(fastArgon2id()).Verify("secret", bcryptHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, rehash)
+
+	// Хеш argon2id с устаревшими параметрами требует перехеширования
+	oldHash, err := password.NewHasher(fastArgon2id()).Hash("secret")
+	require.NoError(t, err)
+
+	stronger := fastArgon2id()
+	stronger.Iterations = 2
+
+	ok, rehash, err = password.NewHasher(stronger).Verify("secret", oldHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, rehash)
+}
+
+func TestNewUnknownAlgorithm(t *testing.T) {
+	_, err := password.New("md5", password.DefaultArgon2id(), password.DefaultBcrypt())
+	assert.ErrorIs(t, err, password.ErrUnknownAlgorithm)
+}
+
+// BenchmarkArgon2id - стоимость хеширования argon2id для подбора параметров под бюджет задержки входа.
+// Запуск: go test -bench Argon2id -benchmem ./internal/password/.
+func BenchmarkArgon2id(b *testing.B) {
+	for _, memory := range []uint32{19 * 1024, 46 * 1024, 64 * 1024} {
+		for _, iterations := range []uint32{1, 2, 3} {
+			params := password.DefaultArgon2id()
+			params.Memory = memory
+			params.Iterations = iterations
+
+			b.Run(fmt.Sprintf("m=%dKiB/t=%d/p=%d", memory, iterations, params.Parallelism), func(b *testing.B) {
+				benchmarkVerify(b, params)
+			})
+		}
+	}
+}
+
+// BenchmarkBcrypt - стоимость хеширования bcrypt для подбора параметра cost.
+func BenchmarkBcrypt(b *testing.B) {
+	for _, cost := range []int{10, 11, 12, 13} {
+		b.Run(fmt.Sprintf("cost=%d", cost), func(b *testing.B) {
+			benchmarkVerify(b, password.Bcrypt{Cost: cost})
+		})
+	}
+}
+
+// benchmarkVerify измеряет проверку пароля - именно она выполняется при каждом входе.
+func benchmarkVerify(b *testing.B, algorithm password.Algorithm) {
+	b.Helper()
+
+	hasher := password.NewHasher(algorithm)
+
+	hash, err := hasher.Hash("benchmark-password")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if ok, _, _ := hasher.Verify("benchmark-password", hash); !ok {
+			b.Fatal("password does not match")
+		}
+	}
+}
+
+// countingAlgorithm - bcrypt, который считает вызовы хеширования и проверки.
+type countingAlgorithm struct {
+	password.Bcrypt
+	hashes, verifies *int
+}
+
+func (a countingAlgorithm) Hash(pwd string) (string, error) {
+	*a.hashes++
+	return a.Bcrypt.Hash(pwd)
+}
+
+func (a countingAlgorithm) Verify(pwd, encoded string) (bool, error) {
+	*a.verifies++
+	return a.Bcrypt.Verify(pwd, encoded)
+}
+
+func TestVerifyDummy(t *testing.T) {
+	var hashes, verifies int
+
+	hasher := password.NewHasher(countingAlgorithm{Bcrypt: password.Bcrypt{Cost: 4}, hashes: &hashes, verifies: &verifies})
+
+	// Каждая проверка неизвестного пользователя проходит через алгоритм, а заглушка хешируется один раз
+	hasher.VerifyDummy("secret")
+	hasher.VerifyDummy("other")
+	assert.Equal(t, 1, hashes)
+	assert.Equal(t, 2, verifies)
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"avito_coin/internal/db"
+)
+
+// AddCartItem - добавление quantity единиц товара (или его варианта sku) в корзину по текущей цене.
+// Если товар снят с продажи или его нет, возвращается sql.ErrNoRows.
+func (r *coinRepository) AddCartItem(ctx context.Context, userID, merchID int32, sku string, quantity int32) error {
+	return r.inTx(ctx, func(qtx *db.Queries) error {
+		variantID, price, err := currentPrice(ctx, qtx, merchID, sku)
+		if err != nil {
+			return err
+		}
+
+		return qtx.AddCartItem(ctx, db.AddCartItemParams{
+			UserID:    userID,
+			MerchID:   merchID,
+			VariantID: variantID,
+			Quantity:  quantity,
+			Price:     price,
+		})
+	})
+}
+
+// ListCartItems - позиции корзины пользователя с ценами на момент добавления.
+func (r *coinRepository) ListCartItems(ctx context.Context, userID int32) ([]db.ListCartItemsRow, error) {
+	return r.queries.ListCartItems(ctx, userID)
+}
+
+// RemoveCartItem - удаление позиции из корзины пользователя.
+func (r *coinRepository) RemoveCartItem(ctx context.Context, userID, itemID int32) error {
+	rows, err := r.queries.RemoveCartItem(ctx, db.RemoveCartItemParams{ID: itemID, UserID: userID})
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrCartItemNotFound
+	}
+
+	return nil
+}
+
+// Checkout - оформление заказа с местом получения и комментарием: списание остатков,
+// оплата всей корзины одной проводкой и запись покупок в одной транзакции. Если цены изменились, корзина получает новые цены,
+// а заказ не создается (ErrCartStale).
+func (r *coinRepository) Checkout(ctx context.Context, userID int32, pickupLocation, notes sql.NullString) (db.Order, error) {
+	repriced, err := r.queries.RepriceCart(ctx, userID)
+	if err != nil {
+		return db.Order{}, fmt.Errorf("error repricing cart: %w", err)
+	}
+
+	if repriced > 0 {
+		return db.Order{}, ErrCartStale
+	}
+
+	var order db.Order
+
+	err = r.inTx(ctx, func(qtx *db.Queries) error {
+		items, err := qtx.GetCartForCheckout(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("error retrieving cart: %w", err)
+		}
+
+		if len(items) == 0 {
+			return ErrCartEmpty
+		}
+
+		var (
+			total     int64
+			purchases []db.AddOrderPurchaseParams
+		)
+
+		for _, item := range items {
+			for range item.Quantity {
+				variantID, price, err := takeMerchFromStock(ctx, qtx, item.MerchID, item.Variant.String)
+				if err != nil {
+					return err
+				}
+
+				// Цену изменили уже после сверки корзины
+				if price != item.Price {
+					return ErrCartStale
+				}
+
+				total += int64(price)
+
+				purchases = append(purchases, db.AddOrderPurchaseParams{
+					UserID:    sql.NullInt32{Int32: userID, Valid: true},
+					MerchID:   sql.NullInt32{Int32: item.MerchID, Valid: true},
+					VariantID: variantID,
+					Price:     sql.NullInt32{Int32: price, Valid: true},
+				})
+			}
+		}
+
+		// Баланс хранится в int32, такую сумму оплатить невозможно
+		if total > math.MaxInt32 {
+			return ErrInsufficientBalance
+		}
+
+		order, err = placeOrder(ctx, qtx, userID, int32(total), pickupLocation, notes)
+		if err != nil {
+			return err
+		}
+
+		for _, purchase := range purchases {
+			purchase.EntryID = sql.NullInt32{Int32: order.EntryID, Valid: true}
+			purchase.OrderID = sql.NullInt32{Int32: order.ID, Valid: true}
+
+			if err := qtx.AddOrderPurchase(ctx, purchase); err != nil {
+				return fmt.Errorf("error recording order purchase: %w", err)
+			}
+		}
+
+		return qtx.ClearCart(ctx, userID)
+	})
+
+	return order, err
+}
+
+// currentPrice - текущая цена товара или его варианта sku в продаже и ID варианта.
+func currentPrice(ctx context.Context, qtx *db.Queries, merchID int32, sku string) (sql.NullInt32, int32, error) {
+	if sku != "" {
+		variant, err := qtx.GetMerchVariantForPurchase(ctx, db.GetMerchVariantForPurchaseParams{
+			MerchID: merchID,
+			Sku:     sku,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.NullInt32{}, 0, ErrVariantNotFound
+		}
+
+		if err != nil {
+			return sql.NullInt32{}, 0, fmt.Errorf("error retrieving merch variant: %w", err)
+		}
+
+		return sql.NullInt32{Int32: variant.ID, Valid: true}, variant.Price, nil
+	}
+
+	price, err := qtx.GetMerchPrice(ctx, merchID)
+	if err != nil {
+		return sql.NullInt32{}, 0, fmt.Errorf("error retrieving merch price: %w", err)
+	}
+
+	variants, err := qtx.CountActiveMerchVariants(ctx, merchID)
+	if err != nil {
+		return sql.NullInt32{}, 0, fmt.Errorf("error counting merch variants: %w", err)
+	}
+
+	if variants > 0 {
+		return sql.NullInt32{}, 0, ErrVariantRequired
+	}
+
+	return sql.NullInt32{}, price, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"avito_coin/internal/db"
+)
+
+// CreateIdempotencyKey - резервирование ключа идемпотентности, false - ключ уже занят.
+// Резерв того же запроса без ответа, который старше lease, считается брошенным и занимается заново.
+func (r *coinRepository) CreateIdempotencyKey(
+	ctx context.Context,
+	userID int32,
+	key, fingerprint string,
+	ttl, lease time.Duration,
+) (bool, error) {
+	rows, err := r.queries.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+		UserID:       userID,
+		Key:          key,
+		Fingerprint:  fingerprint,
+		TtlSeconds:   int32(ttl.Seconds()),
+		LeaseSeconds: int32(lease.Seconds()),
+	})
+
+	return rows == 1, err
+}
+
+// GetIdempotencyKey - получение ключа идемпотентности и сохраненного ответа.
+func (r *coinRepository) GetIdempotencyKey(ctx context.Context, userID int32, key string) (db.GetIdempotencyKeyRow, error) {
+	return r.queries.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		UserID: userID,
+		Key:    key,
+	})
+}
+
+// CompleteIdempotencyKey - сохранение ответа на выполненный запрос.
+func (r *coinRepository) CompleteIdempotencyKey(
+	ctx context.Context,
+	userID int32,
+	key string,
+	statusCode int32,
+	body []byte,
+) error {
+	return r.queries.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		UserID:       userID,
+		Key:          key,
+		StatusCode:   sql.NullInt32{Int32: statusCode, Valid: true},
+		ResponseBody: body,
+	})
+}
+
+// DeleteIdempotencyKey - освобождение ключа идемпотентности.
+func (r *coinRepository) DeleteIdempotencyKey(ctx context.Context, userID int32, key string) error {
+	return r.queries.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{
+		UserID: userID,
+		Key:    key,
+	})
+}
+
+// DeleteExpiredIdempotencyKeys - удаление просроченных ключей идемпотентности.
+func (r *coinRepository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	return r.queries.DeleteExpiredIdempotencyKeys(ctx)
+}
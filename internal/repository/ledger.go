@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"avito_coin/internal/db"
+)
+
+// Типы счетов.
+const (
+	AccountUser     = "user"
+	AccountShop     = "shop"
+	AccountIssuance = "issuance"
+)
+
+// Типы журнальных записей.
+const (
+	EntryOpening    = "opening"
+	EntryIssuance   = "issuance"
+	EntryTransfer   = "transfer"
+	EntryPurchase   = "purchase"
+	EntryAdjustment = "adjustment"
+	EntryRefund     = "refund"
+	EntryReversal   = "reversal"
+)
+
+// InitialGrant - сколько монет начисляется сотруднику при регистрации по умолчанию.
+const InitialGrant int32 = 1000
+
+// posting - одна проводка журнальной записи.
+type posting struct {
+	accountID int32
+	amount    int32
+	// cached - ведется ли для счета кэш баланса (только для пользовательских счетов).
+	cached bool
+}
+
+// userPosting - проводка по счету пользователя.
+func userPosting(accountID, amount int32) posting {
+	return posting{accountID: accountID, amount: amount, cached: true}
+}
+
+// systemPosting - проводка по системному счету, баланс которого считается по проводкам.
+func systemPosting(accountID, amount int32) posting {
+	return posting{accountID: accountID, amount: amount}
+}
+
+// lockAccounts - блокировка счетов пользователей до конца транзакции в порядке возрастания ID,
+// чтобы избежать дедлоков.
+func lockAccounts(ctx context.Context, qtx *db.Queries, accountIDs ...int32) error {
+	slices.Sort(accountIDs)
+
+	for _, id := range slices.Compact(accountIDs) {
+		if _, err := qtx.LockAccount(ctx, id); err != nil {
+			return fmt.Errorf("error locking account %d: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// postEntry - создает журнальную запись с проводками и обновляет кэш балансов пользователей.
+// Сумма проводок должна быть равна нулю, списания не могут увести баланс пользователя в минус.
+func postEntry(ctx context.Context, qtx *db.Queries, kind string, postings ...posting) (int32, error) {
+	var (
+		sum    int64
+		locked []int32
+	)
+
+	for _, p := range postings {
+		sum += int64(p.amount)
+
+		if p.cached {
+			locked = append(locked, p.accountID)
+		}
+	}
+
+	if sum != 0 {
+		return 0, fmt.Errorf("journal entry %q is not balanced: sum of postings is %d", kind, sum)
+	}
+
+	if err := lockAccounts(ctx, qtx, locked...); err != nil {
+		return 0, err
+	}
+
+	entryID, err := qtx.CreateJournalEntry(ctx, kind)
+	if err != nil {
+		return 0, fmt.Errorf("error creating journal entry: %w", err)
+	}
+
+	for _, p := range postings {
+		err = qtx.CreatePosting(ctx, db.CreatePostingParams{
+			EntryID:   entryID,
+			AccountID: p.accountID,
+			Amount:    p.amount,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("error creating posting: %w", err)
+		}
+
+		if !p.cached {
+			continue
+		}
+
+		if err := applyPosting(ctx, qtx, p); err != nil {
+			return 0, err
+		}
+	}
+
+	return entryID, nil
+}
+
+// applyPosting - обновляет кэш баланса счета пользователя.
+func applyPosting(ctx context.Context, qtx *db.Queries, p posting) error {
+	var (
+		rows int64
+		err  error
+	)
+
+	if p.amount < 0 {
+		rows, err = qtx.DebitAccount(ctx, db.DebitAccountParams{Amount: -p.amount, ID: p.accountID})
+		if err == nil && rows == 0 {
+			return ErrInsufficientBalance
+		}
+	} else {
+		rows, err = qtx.CreditAccount(ctx, db.CreditAccountParams{Amount: p.amount, ID: p.accountID})
+		if err == nil && rows == 0 {
+			return fmt.Errorf("account %d: %w", p.accountID, sql.ErrNoRows)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("error updating account %d balance: %w", p.accountID, err)
+	}
+
+	return nil
+}
+
+// userAccountID - счет пользователя.
+func userAccountID(ctx context.Context, qtx *db.Queries, userID int32) (int32, error) {
+	id, err := qtx.GetUserAccountID(ctx, sql.NullInt32{Int32: userID, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving account of user %d: %w", userID, err)
+	}
+
+	return id, nil
+}
+
+// systemAccountID - системный счет (магазин или эмиссия).
+func systemAccountID(ctx context.Context, qtx *db.Queries, kind string) (int32, error) {
+	id, err := qtx.GetSystemAccountID(ctx, kind)
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving %s account: %w", kind, err)
+	}
+
+	return id, nil
+}
+
+// GetAccountPostings - выписка по счету пользователя.
+func (r *coinRepository) GetAccountPostings(ctx context.Context, userID int32) ([]db.GetAccountPostingsRow, error) {
+	return r.queries.GetAccountPostings(ctx, sql.NullInt32{Int32: userID, Valid: true})
+}
+
+// GetLedgerTotals - сумма проводок по каждому типу счетов.
+func (r *coinRepository) GetLedgerTotals(ctx context.Context) ([]db.GetLedgerTotalsRow, error) {
+	return r.queries.GetLedgerTotals(ctx)
+}
+
+// GetUserBalancesTotal - сумма кэшированных балансов пользователей.
+func (r *coinRepository) GetUserBalancesTotal(ctx context.Context) (int64, error) {
+	return r.queries.GetUserBalancesTotal(ctx)
+}
+
+// GetUnbalancedEntries - журнальные записи, проводки которых не сходятся в ноль.
+func (r *coinRepository) GetUnbalancedEntries(ctx context.Context) ([]db.GetUnbalancedEntriesRow, error) {
+	return r.queries.GetUnbalancedEntries(ctx)
+}
+
+// GetAccountDiscrepancies - счета, кэш баланса которых расходится с проводками.
+func (r *coinRepository) GetAccountDiscrepancies(ctx context.Context) ([]db.GetAccountDiscrepanciesRow, error) {
+	return r.queries.GetAccountDiscrepancies(ctx)
+}
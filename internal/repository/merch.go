@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"avito_coin/internal/db"
+)
+
+// Действия в журнале изменений каталога.
+const (
+	MerchActionCreate     = "create"
+	MerchActionUpdate     = "update"
+	MerchActionDeactivate = "deactivate"
+	MerchActionActivate   = "activate"
+	MerchActionRestock    = "restock"
+)
+
+// AddMerch - добавление товара в каталог администратором actorID с записью в журнал изменений.
+func (r *coinRepository) AddMerch(ctx context.Context, actorID int32, name string, price int32) (db.Merch, error) {
+	var merch db.Merch
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		var err error
+
+		merch, err = qtx.AddMerch(ctx, db.AddMerchParams{Name: name, Price: price})
+		if isUniqueViolation(err) {
+			return ErrMerchExists
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return addMerchAudit(ctx, qtx, actorID, MerchActionCreate, merch)
+	})
+
+	return merch, err
+}
+
+// UpdateMerch - изменение переданных полей товара администратором actorID с записью в журнал изменений.
+// Если товара нет, возвращается sql.ErrNoRows.
+func (r *coinRepository) UpdateMerch(ctx context.Context, actorID int32, arg db.UpdateMerchParams) (db.Merch, error) {
+	var merch db.Merch
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		// Блокируем товар, чтобы действие в журнале соответствовало предыдущему состоянию
+		before, err := qtx.GetMerchForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		merch, err = qtx.UpdateMerch(ctx, arg)
+		if isUniqueViolation(err) {
+			return ErrMerchExists
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if merch == before {
+			return nil
+		}
+
+		action := MerchActionUpdate
+
+		switch {
+		case before.Active && !merch.Active:
+			action = MerchActionDeactivate
+		case !before.Active && merch.Active:
+			action = MerchActionActivate
+		}
+
+		return addMerchAudit(ctx, qtx, actorID, action, merch)
+	})
+
+	return merch, err
+}
+
+// ListMerch - весь каталог, включая снятые с продажи товары.
+func (r *coinRepository) ListMerch(ctx context.Context) ([]db.Merch, error) {
+	return r.queries.ListMerch(ctx)
+}
+
+// GetMerchAudit - журнал изменений товара.
+func (r *coinRepository) GetMerchAudit(ctx context.Context, merchID int32) ([]db.GetMerchAuditRow, error) {
+	return r.queries.GetMerchAudit(ctx, merchID)
+}
+
+// GetMerch - товар по ID, в том числе снятый с продажи.
+func (r *coinRepository) GetMerch(ctx context.Context, merchID int32) (db.Merch, error) {
+	return r.queries.GetMerch(ctx, merchID)
+}
+
+// RestockMerch - пополнение склада администратором actorID с записью в журнал изменений.
+// Если товара нет, возвращается sql.ErrNoRows.
+func (r *coinRepository) RestockMerch(ctx context.Context, actorID, merchID, quantity int32) (db.Merch, error) {
+	var merch db.Merch
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		var err error
+
+		merch, err = qtx.RestockMerch(ctx, db.RestockMerchParams{Quantity: quantity, ID: merchID})
+		if err != nil {
+			return err
+		}
+
+		return addMerchAudit(ctx, qtx, actorID, MerchActionRestock, merch)
+	})
+
+	return merch, err
+}
+
+// ListLowStockMerch - товары и варианты в продаже с остатком не больше threshold.
+func (r *coinRepository) ListLowStockMerch(ctx context.Context, threshold int32) ([]db.ListLowStockMerchRow, error) {
+	return r.queries.ListLowStockMerch(ctx, threshold)
+}
+
+// takeMerchFromStock - блокировка товара в продаже и списание единицы остатка товара или его варианта sku,
+// возвращает ID варианта (если он указан) и цену. Если товар снят с продажи или его нет, возвращается sql.ErrNoRows.
+func takeMerchFromStock(ctx context.Context, qtx *db.Queries, merchID int32, sku string) (sql.NullInt32, int32, error) {
+	if sku != "" {
+		variantID, price, err := takeVariantFromStock(ctx, qtx, merchID, sku)
+		return sql.NullInt32{Int32: variantID, Valid: err == nil}, price, err
+	}
+
+	merch, err := qtx.GetMerchForPurchase(ctx, merchID)
+	if err != nil {
+		return sql.NullInt32{}, 0, fmt.Errorf("error retrieving merch: %w", err)
+	}
+
+	// У товара с вариантами остаток и цена ведутся по вариантам
+	variants, err := qtx.CountActiveMerchVariants(ctx, merchID)
+	if err != nil {
+		return sql.NullInt32{}, 0, fmt.Errorf("error counting merch variants: %w", err)
+	}
+
+	if variants > 0 {
+		return sql.NullInt32{}, 0, ErrVariantRequired
+	}
+
+	if !merch.Stock.Valid {
+		return sql.NullInt32{}, merch.Price, nil
+	}
+
+	// Истекшие резервы возвращаются на склад сразу, не дожидаясь фоновой очистки
+	released, err := releaseExpiredReservations(ctx, qtx, merchID, sql.NullInt32{})
+	if err != nil {
+		return sql.NullInt32{}, 0, err
+	}
+
+	if merch.Stock.Int32+released <= 0 {
+		return sql.NullInt32{}, 0, ErrSoldOut
+	}
+
+	if err := qtx.DecrementMerchStock(ctx, merchID); err != nil {
+		return sql.NullInt32{}, 0, fmt.Errorf("error decrementing merch stock: %w", err)
+	}
+
+	return sql.NullInt32{}, merch.Price, nil
+}
+
+// addMerchAudit - запись состояния товара после изменения в журнал.
+func addMerchAudit(ctx context.Context, qtx *db.Queries, actorID int32, action string, merch db.Merch) error {
+	return qtx.AddMerchAudit(ctx, db.AddMerchAuditParams{
+		MerchID: merch.ID,
+		Action:  action,
+		ActorID: sql.NullInt32{Int32: actorID, Valid: actorID != 0},
+		Name:    merch.Name,
+		Price:   merch.Price,
+		Active:  merch.Active,
+		Stock:   merch.Stock,
+	})
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"avito_coin/internal/db"
+)
+
+// Статусы заказа.
+const (
+	OrderStatusPlaced         = "placed"
+	OrderStatusApproved       = "approved"
+	OrderStatusReadyForPickup = "ready_for_pickup"
+	OrderStatusDelivered      = "delivered"
+	OrderStatusCancelled      = "cancelled"
+)
+
+// placeOrder - оплата заказа на сумму total одной проводкой со счета пользователя на счет магазина
+// и создание заказа в статусе placed.
+func placeOrder(ctx context.Context, qtx *db.Queries, userID, total int32, pickupLocation, notes sql.NullString) (db.Order, error) {
+	accountID, err := userAccountID(ctx, qtx, userID)
+	if err != nil {
+		return db.Order{}, err
+	}
+
+	shopID, err := systemAccountID(ctx, qtx, AccountShop)
+	if err != nil {
+		return db.Order{}, err
+	}
+
+	// Списываем монеты, только если их достаточно
+	entryID, err := postEntry(ctx, qtx, EntryPurchase,
+		userPosting(accountID, -total),
+		systemPosting(shopID, total),
+	)
+	if err != nil {
+		return db.Order{}, err
+	}
+
+	order, err := qtx.CreateOrder(ctx, db.CreateOrderParams{
+		UserID:         userID,
+		EntryID:        entryID,
+		Total:          total,
+		PickupLocation: pickupLocation,
+		Notes:          notes,
+	})
+	if err != nil {
+		return db.Order{}, fmt.Errorf("error creating order: %w", err)
+	}
+
+	err = qtx.AddOrderEvent(ctx, db.AddOrderEventParams{
+		OrderID: order.ID,
+		Status:  OrderStatusPlaced,
+		ActorID: sql.NullInt32{Int32: userID, Valid: true},
+	})
+	if err != nil {
+		return db.Order{}, fmt.Errorf("error recording order event: %w", err)
+	}
+
+	return order, nil
+}
+
+// UpdateOrderStatus - перевод заказа из статуса arg.FromStatus в arg.Status с записью в историю.
+// Если статус заказа уже изменился, возвращается ErrOrderStatusChanged. При отмене монеты
+// возвращаются пользователю проводкой со счета магазина, а товары - на склад.
+func (r *coinRepository) UpdateOrderStatus(ctx context.Context, actorID int32, arg db.UpdateOrderStatusParams, note sql.NullString) (db.Order, error) {
+	var order db.Order
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		var err error
+
+		order, err = qtx.UpdateOrderStatus(ctx, arg)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOrderStatusChanged
+		}
+
+		if err != nil {
+			return fmt.Errorf("error updating order status: %w", err)
+		}
+
+		err = qtx.AddOrderEvent(ctx, db.AddOrderEventParams{
+			OrderID: order.ID,
+			Status:  order.Status,
+			ActorID: sql.NullInt32{Int32: actorID, Valid: actorID != 0},
+			Note:    note,
+		})
+		if err != nil {
+			return fmt.Errorf("error recording order event: %w", err)
+		}
+
+		if order.Status != OrderStatusCancelled {
+			return nil
+		}
+
+		order.RefundEntryID, err = refundOrder(ctx, qtx, order)
+
+		return err
+	})
+
+	return order, err
+}
+
+// refundOrder - возврат монет за заказ и товаров заказа на склад.
+func refundOrder(ctx context.Context, qtx *db.Queries, order db.Order) (sql.NullInt32, error) {
+	accountID, err := userAccountID(ctx, qtx, order.UserID)
+	if err != nil {
+		return sql.NullInt32{}, err
+	}
+
+	shopID, err := systemAccountID(ctx, qtx, AccountShop)
+	if err != nil {
+		return sql.NullInt32{}, err
+	}
+
+	entryID, err := postEntry(ctx, qtx, EntryRefund,
+		systemPosting(shopID, -order.Total),
+		userPosting(accountID, order.Total),
+	)
+	if err != nil {
+		return sql.NullInt32{}, err
+	}
+
+	refundEntryID := sql.NullInt32{Int32: entryID, Valid: true}
+
+	err = qtx.SetOrderRefund(ctx, db.SetOrderRefundParams{ID: order.ID, RefundEntryID: refundEntryID})
+	if err != nil {
+		return sql.NullInt32{}, fmt.Errorf("error recording order refund: %w", err)
+	}
+
+	items, err := qtx.GetOrderItems(ctx, sql.NullInt32{Int32: order.ID, Valid: true})
+	if err != nil {
+		return sql.NullInt32{}, fmt.Errorf("error retrieving order items: %w", err)
+	}
+
+	// Остаток без учета (NULL) не меняется
+	for _, item := range items {
+		for range item.Quantity {
+			if item.VariantID.Valid {
+				err = qtx.IncrementVariantStock(ctx, item.VariantID.Int32)
+			} else {
+				err = qtx.IncrementMerchStock(ctx, item.MerchID.Int32)
+			}
+
+			if err != nil {
+				return sql.NullInt32{}, fmt.Errorf("error returning merch to stock: %w", err)
+			}
+		}
+	}
+
+	return refundEntryID, nil
+}
+
+// GetOrder - заказ по ID.
+func (r *coinRepository) GetOrder(ctx context.Context, orderID int32) (db.Order, error) {
+	return r.queries.GetOrder(ctx, orderID)
+}
+
+// ListUserOrders - заказы пользователя, новые первыми.
+func (r *coinRepository) ListUserOrders(ctx context.Context, userID int32) ([]db.Order, error) {
+	return r.queries.ListUserOrders(ctx, userID)
+}
+
+// ListOrders - все заказы, если status не задан, иначе заказы в этом статусе.
+func (r *coinRepository) ListOrders(ctx context.Context, status sql.NullString) ([]db.Order, error) {
+	return r.queries.ListOrders(ctx, status)
+}
+
+// GetOrderItems - состав заказа.
+func (r *coinRepository) GetOrderItems(ctx context.Context, orderID int32) ([]db.GetOrderItemsRow, error) {
+	return r.queries.GetOrderItems(ctx, sql.NullInt32{Int32: orderID, Valid: true})
+}
+
+// GetOrderEvents - история статусов заказа.
+func (r *coinRepository) GetOrderEvents(ctx context.Context, orderID int32) ([]db.GetOrderEventsRow, error) {
+	return r.queries.GetOrderEvents(ctx, orderID)
+}
@@ -3,45 +3,191 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"avito_coin/internal/db"
 )
 
+var (
+	// ErrInsufficientBalance - на балансе недостаточно монет для списания.
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	// ErrUserExists - пользователь с таким именем уже существует.
+	ErrUserExists = errors.New("user already exists")
+	// ErrMerchExists - товар с таким названием уже есть в каталоге.
+	ErrMerchExists = errors.New("merch already exists")
+	// ErrSoldOut - товар закончился на складе.
+	ErrSoldOut = errors.New("merch is sold out")
+	// ErrReservationNotFound - резерва нет, он истек или относится к другому товару.
+	ErrReservationNotFound = errors.New("reservation not found")
+	// ErrVariantRequired - у товара есть варианты, и покупка без варианта невозможна.
+	ErrVariantRequired = errors.New("merch variant is required")
+	// ErrVariantNotFound - варианта нет или он снят с продажи.
+	ErrVariantNotFound = errors.New("merch variant not found")
+	// ErrVariantExists - вариант с таким артикулом уже есть.
+	ErrVariantExists = errors.New("merch variant already exists")
+	// ErrCartEmpty - в корзине нет товаров.
+	ErrCartEmpty = errors.New("cart is empty")
+	// ErrCartStale - цены в корзине изменились с момента добавления товаров.
+	ErrCartStale = errors.New("cart prices have changed")
+	// ErrCartItemNotFound - позиции нет в корзине пользователя.
+	ErrCartItemNotFound = errors.New("cart item not found")
+	// ErrOrderStatusChanged - статус заказа изменился, пока запрос обрабатывался.
+	ErrOrderStatusChanged = errors.New("order status has changed")
+	// ErrAlreadyReversed - операция уже сторнирована полностью.
+	ErrAlreadyReversed = errors.New("operation is already fully reversed")
+	// ErrReversalExceedsAmount - сумма сторно больше несторнированного остатка операции.
+	ErrReversalExceedsAmount = errors.New("reversal exceeds the remaining amount")
+	// ErrPurchaseNotRefundable - покупка входит в невыданный заказ или ее цена неизвестна.
+	ErrPurchaseNotRefundable = errors.New("purchase cannot be refunded")
+)
+
 // Repository - интерфейс репозитория для операций с монетками и мерчем.
 type Repository interface {
 	CreateUser(ctx context.Context, username, password string) (int32, error)
 	CreateMerch(ctx context.Context, name string, price int32) error
-	BuyMerch(ctx context.Context, userID, merchID int32) error
+	BuyMerch(ctx context.Context, userID, merchID int32, sku string) error
 	GetMerchPrice(ctx context.Context, merchID int32) (int32, error)
-	TransferCoins(ctx context.Context, fromUser, toUser, amount int32) error
+	TransferCoins(ctx context.Context, fromUser, toUser, amount int32, note TransferNote, check *TransferCheck) error
 	GetUserBalance(ctx context.Context, userID int32) (int32, error)
 	GetUserPurchases(ctx context.Context, userID int32) ([]db.GetUserPurchasesRow, error)
-	GetTransactions(ctx context.Context, userID int32) ([]db.GetTransactionsRow, error)
+	GetUserInventory(ctx context.Context, userID int32) ([]db.GetUserInventoryRow, error)
+	GetTransactions(ctx context.Context, userID, limit int32) ([]db.GetTransactionsRow, error)
+	GetUserHistory(ctx context.Context, arg db.GetUserHistoryParams) ([]db.GetUserHistoryRow, error)
 	UpdateUserBalance(ctx context.Context, userID int32, balance int32) error
 	UserExists(ctx context.Context, username string) (db.UserExistsRow, error)
+	UpdateUserPassword(ctx context.Context, userID int32, passwordHash string) error
+	GetAccountPostings(ctx context.Context, userID int32) ([]db.GetAccountPostingsRow, error)
+	GetLedgerTotals(ctx context.Context) ([]db.GetLedgerTotalsRow, error)
+	GetUserBalancesTotal(ctx context.Context) (int64, error)
+	GetUnbalancedEntries(ctx context.Context) ([]db.GetUnbalancedEntriesRow, error)
+	GetAccountDiscrepancies(ctx context.Context) ([]db.GetAccountDiscrepanciesRow, error)
+	CreateIdempotencyKey(ctx context.Context, userID int32, key, fingerprint string, ttl, lease time.Duration) (bool, error)
+	GetIdempotencyKey(ctx context.Context, userID int32, key string) (db.GetIdempotencyKeyRow, error)
+	CompleteIdempotencyKey(ctx context.Context, userID int32, key string, statusCode int32, body []byte) error
+	DeleteIdempotencyKey(ctx context.Context, userID int32, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+	CreateRefreshToken(ctx context.Context, userID int32, familyID, tokenHash string, ttl time.Duration) error
+	RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, ttl time.Duration) (int32, error)
+	RevokeRefreshTokenFamily(ctx context.Context, userID int32, tokenHash string) (int64, error)
+	RevokeUserTokens(ctx context.Context, userID int32) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, userID int32, jti string, issuedAt time.Time) (bool, error)
+	DeleteExpiredRefreshTokens(ctx context.Context) (int64, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+	GetUserRoles(ctx context.Context, userID int32) ([]string, error)
+	GrantRole(ctx context.Context, userID int32, role string, grantedBy int32) (bool, error)
+	RevokeRole(ctx context.Context, userID int32, role string) (bool, error)
+	AddMerch(ctx context.Context, actorID int32, name string, price int32) (db.Merch, error)
+	UpdateMerch(ctx context.Context, actorID int32, arg db.UpdateMerchParams) (db.Merch, error)
+	ListMerch(ctx context.Context) ([]db.Merch, error)
+	GetMerchAudit(ctx context.Context, merchID int32) ([]db.GetMerchAuditRow, error)
+	GetMerch(ctx context.Context, merchID int32) (db.Merch, error)
+	RestockMerch(ctx context.Context, actorID, merchID, quantity int32) (db.Merch, error)
+	ListLowStockMerch(ctx context.Context, threshold int32) ([]db.ListLowStockMerchRow, error)
+	ReserveMerch(ctx context.Context, userID, merchID int32, sku string, expiresAt time.Time) (int32, error)
+	BuyReservedMerch(ctx context.Context, userID, merchID, reservationID int32) error
+	CancelReservation(ctx context.Context, userID, reservationID int32) error
+	ReleaseExpiredReservations(ctx context.Context) (int64, error)
+	AddMerchVariant(ctx context.Context, actorID int32, arg db.AddMerchVariantParams) (db.MerchVariant, error)
+	UpdateMerchVariant(ctx context.Context, actorID int32, arg db.UpdateMerchVariantParams) (db.MerchVariant, error)
+	RestockMerchVariant(ctx context.Context, actorID, merchID int32, sku string, quantity int32) (db.MerchVariant, error)
+	ListMerchVariants(ctx context.Context, merchID int32) ([]db.MerchVariant, error)
+	GetMerchVariant(ctx context.Context, merchID int32, sku string) (db.MerchVariant, error)
+	GetMerchVariantPrice(ctx context.Context, merchID int32, sku string) (int32, error)
+	AddCartItem(ctx context.Context, userID, merchID int32, sku string, quantity int32) error
+	ListCartItems(ctx context.Context, userID int32) ([]db.ListCartItemsRow, error)
+	RemoveCartItem(ctx context.Context, userID, itemID int32) error
+	Checkout(ctx context.Context, userID int32, pickupLocation, notes sql.NullString) (db.Order, error)
+	GetOrder(ctx context.Context, orderID int32) (db.Order, error)
+	ListUserOrders(ctx context.Context, userID int32) ([]db.Order, error)
+	ListOrders(ctx context.Context, status sql.NullString) ([]db.Order, error)
+	GetOrderItems(ctx context.Context, orderID int32) ([]db.GetOrderItemsRow, error)
+	GetOrderEvents(ctx context.Context, orderID int32) ([]db.GetOrderEventsRow, error)
+	UpdateOrderStatus(ctx context.Context, actorID int32, arg db.UpdateOrderStatusParams, note sql.NullString) (db.Order, error)
+	ReverseTransfer(ctx context.Context, actorID, transactionID int32, amount sql.NullInt32, reason string) (db.Reversal, error)
+	RefundPurchase(ctx context.Context, actorID, purchaseID int32, amount sql.NullInt32, reason string) (db.Reversal, error)
+	GetUserReversals(ctx context.Context, userID, limit int32) ([]db.GetUserReversalsRow, error)
+	ListPublicTransfers(ctx context.Context, tag sql.NullString, limit int32) ([]db.ListPublicTransfersRow, error)
 }
 
 // coinRepository - структура, которая реализует интерфейс Repository.
 type coinRepository struct {
 	queries *db.Queries
 	db      *sql.DB
+	// initialGrant - сколько монет начисляется сотруднику при регистрации.
+	initialGrant int32
+}
+
+// Option - настройка репозитория.
+type Option func(*coinRepository)
+
+// WithInitialGrant - сколько монет начисляется сотруднику при регистрации.
+func WithInitialGrant(amount int32) Option {
+	return func(r *coinRepository) {
+		r.initialGrant = amount
+	}
 }
 
 // NewRepository - функция для создания нового репозитория.
-func NewRepository(database *sql.DB) Repository {
-	return &coinRepository{
-		queries: db.New(database),
-		db:      database,
+func NewRepository(database *sql.DB, opts ...Option) Repository {
+	repo := &coinRepository{
+		queries:      db.New(database),
+		db:           database,
+		initialGrant: InitialGrant,
 	}
+
+	for _, opt := range opts {
+		opt(repo)
+	}
+
+	return repo
 }
 
-// CreateUser - создание пользователя с именем и паролем.
+// CreateUser - создание пользователя с ролью сотрудника и начисление стартовых монет со счета эмиссии.
 func (r *coinRepository) CreateUser(ctx context.Context, username, password string) (int32, error) {
-	return r.queries.CreateUser(ctx, db.CreateUserParams{
-		Username: username,
-		Password: password,
+	var userID int32
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		var err error
+
+		userID, err = qtx.CreateUser(ctx, db.CreateUserParams{
+			Username: username,
+			Password: password,
+		})
+		if isUniqueViolation(err) {
+			return ErrUserExists
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = qtx.AddUserRole(ctx, db.AddUserRoleParams{UserID: userID, Role: RoleEmployee})
+		if err != nil {
+			return fmt.Errorf("error granting default role: %w", err)
+		}
+
+		accountID, err := qtx.CreateUserAccount(ctx, sql.NullInt32{Int32: userID, Valid: true})
+		if err != nil {
+			return fmt.Errorf("error creating user account: %w", err)
+		}
+
+		issuanceID, err := systemAccountID(ctx, qtx, AccountIssuance)
+		if err != nil {
+			return err
+		}
+
+		_, err = postEntry(ctx, qtx, EntryIssuance,
+			systemPosting(issuanceID, -r.initialGrant),
+			userPosting(accountID, r.initialGrant),
+		)
+
+		return err
 	})
+
+	return userID, err
 }
 
 // CreateMerch - создание мерча с именем и ценой.
@@ -52,133 +198,93 @@ func (r *coinRepository) CreateMerch(ctx context.Context, name string, price int
 	})
 }
 
-// BuyMerch - покупка мерча пользователем.
-func (r *coinRepository) BuyMerch(ctx context.Context, userID, merchID int32) error {
-	// Начинаем транзакцию
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("error starting transaction: %w", err)
-	}
-
-	// Откат транзакции в случае ошибки
-	defer func() {
+// BuyMerch - покупка мерча (или его варианта sku, если он не пустой) пользователем:
+// проводка со счета пользователя на счет магазина.
+func (r *coinRepository) BuyMerch(ctx context.Context, userID, merchID int32, sku string) error {
+	return r.inTx(ctx, func(qtx *db.Queries) error {
+		// Получение цены мерча и списание остатка
+		variantID, price, err := takeMerchFromStock(ctx, qtx, merchID, sku)
 		if err != nil {
-			tx.Rollback()
+			return err
 		}
-	}()
 
-	// Создаём новый экземпляр queries для работы в транзакции
-	qtx := r.queries.WithTx(tx)
+		return payForMerch(ctx, qtx, userID, merchID, variantID, price)
+	})
+}
 
-	// Проверяем, достаточно ли монеток на балансе для покупки
-	balance, err := qtx.GetUserBalance(ctx, userID)
+// payForMerch - оплата единицы товара заказом из одной позиции и запись покупки.
+func payForMerch(ctx context.Context, qtx *db.Queries, userID, merchID int32, variantID sql.NullInt32, price int32) error {
+	order, err := placeOrder(ctx, qtx, userID, price, sql.NullString{}, sql.NullString{})
 	if err != nil {
-		return fmt.Errorf("error retrieving user balance: %w", err)
-	}
-
-	// Получение цены мерча
-	price, err := qtx.GetMerchPrice(ctx, merchID)
-
-	// Есть ли достаточное количество монет для покупки
-	if balance < price {
-		return fmt.Errorf("insufficient balance for purchase")
+		return err
 	}
 
 	// Выполняем покупку
-	err = qtx.BuyMerch(ctx, db.BuyMerchParams{
-		UserID:  sql.NullInt32{Int32: userID, Valid: true},
-		MerchID: sql.NullInt32{Int32: merchID, Valid: true},
+	err = qtx.AddOrderPurchase(ctx, db.AddOrderPurchaseParams{
+		UserID:    sql.NullInt32{Int32: userID, Valid: true},
+		MerchID:   sql.NullInt32{Int32: merchID, Valid: true},
+		VariantID: variantID,
+		EntryID:   sql.NullInt32{Int32: order.EntryID, Valid: true},
+		OrderID:   sql.NullInt32{Int32: order.ID, Valid: true},
+		Price:     sql.NullInt32{Int32: price, Valid: true},
 	})
 	if err != nil {
 		return fmt.Errorf("error buying merch: %w", err)
 	}
 
-	// Обновляем баланс пользователя
-	newBalance := balance - price
-
-	err = qtx.UpdateUserBalance(ctx, db.UpdateUserBalanceParams{
-		Balance: newBalance,
-		ID:      userID,
-	})
-	if err != nil {
-		return fmt.Errorf("error updating user balance after merch purchase: %w", err)
-	}
-
-	// Фиксируем транзакцию
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("error committing transaction: %w", err)
-	}
-
 	return nil
 }
 
-// TransferCoins - перевод монет от одного пользователя к другому.
-func (r *coinRepository) TransferCoins(ctx context.Context, fromUser, toUser, amount int32) error {
-	// Начинаем транзакцию
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("error starting transaction: %w", err)
-	}
-
-	// Откат транзакции в случае ошибки
-	defer func() {
+// TransferCoins - перевод монет от одного пользователя к другому: пара проводок между их счетами.
+// Сообщение и теги сохраняются вместе с переводом. Если задан check, лимиты проверяются в той же транзакции.
+func (r *coinRepository) TransferCoins(
+	ctx context.Context,
+	fromUser, toUser, amount int32,
+	note TransferNote,
+	check *TransferCheck,
+) error {
+	return r.inTx(ctx, func(qtx *db.Queries) error {
+		senderAccountID, err := userAccountID(ctx, qtx, fromUser)
 		if err != nil {
-			tx.Rollback()
+			return err
 		}
-	}()
-
-	// Создаём новый экземпляр queries для работы в транзакции
-	qtx := r.queries.WithTx(tx)
 
-	// Проверяем, достаточно ли монет у отправителя
-	balance, err := qtx.GetUserBalance(ctx, fromUser)
-	if err != nil {
-		return fmt.Errorf("error retrieving user balance: %w", err)
-	}
-
-	if balance < amount {
-		return fmt.Errorf("insufficient balance to transfer")
-	}
+		receiverAccountID, err := userAccountID(ctx, qtx, toUser)
+		if err != nil {
+			return err
+		}
 
-	// Выполняем перевод монет
-	err = qtx.TransferCoins(ctx, db.TransferCoinsParams{
-		FromUser: sql.NullInt32{Int32: fromUser, Valid: true},
-		ToUser:   sql.NullInt32{Int32: toUser, Valid: true},
-		Amount:   amount,
-	})
-	if err != nil {
-		return fmt.Errorf("error transferring coins: %w", err)
-	}
+		if check != nil {
+			err := checkTransfer(ctx, qtx, fromUser, toUser, senderAccountID, receiverAccountID, check)
+			if err != nil {
+				return err
+			}
+		}
 
-	// Обновляем балансы обоих пользователей
-	err = qtx.UpdateUserBalance(ctx, db.UpdateUserBalanceParams{
-		Balance: balance - amount,
-		ID:      fromUser,
-	})
-	if err != nil {
-		return fmt.Errorf("error updating sender balance: %w", err)
-	}
+		// Списываем монеты у отправителя, только если их достаточно
+		entryID, err := postEntry(ctx, qtx, EntryTransfer,
+			userPosting(senderAccountID, -amount),
+			userPosting(receiverAccountID, amount),
+		)
+		if err != nil {
+			return err
+		}
 
-	// Получаем баланс получателя и обновляем его
-	receiverBalance, err := qtx.GetUserBalance(ctx, toUser)
-	if err != nil {
-		return fmt.Errorf("error retrieving receiver balance: %w", err)
-	}
+		// Записываем перевод в историю
+		transactionID, err := qtx.TransferCoins(ctx, db.TransferCoinsParams{
+			FromUser: sql.NullInt32{Int32: fromUser, Valid: true},
+			ToUser:   sql.NullInt32{Int32: toUser, Valid: true},
+			Amount:   amount,
+			EntryID:  sql.NullInt32{Int32: entryID, Valid: true},
+			Message:  note.Message,
+			Public:   note.Public,
+		})
+		if err != nil {
+			return fmt.Errorf("error transferring coins: %w", err)
+		}
 
-	err = qtx.UpdateUserBalance(ctx, db.UpdateUserBalanceParams{
-		Balance: receiverBalance + amount,
-		ID:      toUser,
+		return addTransferTags(ctx, qtx, transactionID, note.Tags)
 	})
-	if err != nil {
-		return fmt.Errorf("error updating receiver balance: %w", err)
-	}
-
-	// Фиксируем транзакцию
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("error committing transaction: %w", err)
-	}
-
-	return nil
 }
 
 // GetUserPurchases - получение всех покупок пользователя.
@@ -186,22 +292,60 @@ func (r *coinRepository) GetUserPurchases(ctx context.Context, userID int32) ([]
 	return r.queries.GetUserPurchases(ctx, sql.NullInt32{Int32: userID, Valid: true})
 }
 
-// GetTransactions - получение списка всех транзакций пользователя.
-func (r *coinRepository) GetTransactions(ctx context.Context, userID int32) ([]db.GetTransactionsRow, error) {
-	return r.queries.GetTransactions(ctx, sql.NullInt32{Int32: userID, Valid: true})
+// GetUserInventory - количество купленных пользователем товаров по вариантам.
+func (r *coinRepository) GetUserInventory(ctx context.Context, userID int32) ([]db.GetUserInventoryRow, error) {
+	return r.queries.GetUserInventory(ctx, sql.NullInt32{Int32: userID, Valid: true})
+}
+
+// GetTransactions - получение последних limit транзакций пользователя.
+func (r *coinRepository) GetTransactions(ctx context.Context, userID, limit int32) ([]db.GetTransactionsRow, error) {
+	return r.queries.GetTransactions(ctx, db.GetTransactionsParams{
+		FromUser: sql.NullInt32{Int32: userID, Valid: true},
+		Limit:    limit,
+	})
+}
+
+// GetUserHistory - страница истории переводов и покупок пользователя.
+func (r *coinRepository) GetUserHistory(ctx context.Context, arg db.GetUserHistoryParams) ([]db.GetUserHistoryRow, error) {
+	return r.queries.GetUserHistory(ctx, arg)
 }
 
-// UpdateUserBalance - обновление баланса пользователя.
+// UpdateUserBalance - установка баланса пользователя корректирующей проводкой со счета эмиссии.
 func (r *coinRepository) UpdateUserBalance(ctx context.Context, userID int32, balance int32) error {
-	return r.queries.UpdateUserBalance(ctx, db.UpdateUserBalanceParams{
-		Balance: balance,
-		ID:      userID,
+	return r.inTx(ctx, func(qtx *db.Queries) error {
+		accountID, err := userAccountID(ctx, qtx, userID)
+		if err != nil {
+			return err
+		}
+
+		// Блокируем счет, чтобы разница считалась от актуального баланса
+		current, err := qtx.LockAccount(ctx, accountID)
+		if err != nil {
+			return fmt.Errorf("error locking account %d: %w", accountID, err)
+		}
+
+		delta := balance - current
+		if delta == 0 {
+			return nil
+		}
+
+		issuanceID, err := systemAccountID(ctx, qtx, AccountIssuance)
+		if err != nil {
+			return err
+		}
+
+		_, err = postEntry(ctx, qtx, EntryAdjustment,
+			systemPosting(issuanceID, -delta),
+			userPosting(accountID, delta),
+		)
+
+		return err
 	})
 }
 
 // GetUserBalance - получение баланса пользователя.
 func (r *coinRepository) GetUserBalance(ctx context.Context, userID int32) (int32, error) {
-	return r.queries.GetUserBalance(ctx, userID)
+	return r.queries.GetUserBalance(ctx, sql.NullInt32{Int32: userID, Valid: true})
 }
 
 // UserExists - существует ли пользователь.
@@ -209,7 +353,15 @@ func (r *coinRepository) UserExists(ctx context.Context, username string) (db.Us
 	return r.queries.UserExists(ctx, username)
 }
 
-// GetUserBalance - получение баланса пользователя.
+// UpdateUserPassword - замена хеша пароля пользователя.
+func (r *coinRepository) UpdateUserPassword(ctx context.Context, userID int32, passwordHash string) error {
+	return r.queries.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		ID:       userID,
+		Password: passwordHash,
+	})
+}
+
+// GetMerchPrice - получение цены мерча.
 func (r *coinRepository) GetMerchPrice(ctx context.Context, merchID int32) (int32, error) {
 	return r.queries.GetMerchPrice(ctx, merchID)
 }
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"avito_coin/internal/db"
+)
+
+// ReserveMerch - резерв единицы товара (или его варианта sku) до expiresAt: остаток списывается сразу,
+// покупка по резерву его уже не уменьшает. Если товар снят с продажи или его нет, возвращается sql.ErrNoRows.
+func (r *coinRepository) ReserveMerch(ctx context.Context, userID, merchID int32, sku string, expiresAt time.Time) (int32, error) {
+	var reservationID int32
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		variantID, _, err := takeMerchFromStock(ctx, qtx, merchID, sku)
+		if err != nil {
+			return err
+		}
+
+		reservationID, err = qtx.CreateReservation(ctx, db.CreateReservationParams{
+			UserID:    userID,
+			MerchID:   merchID,
+			VariantID: variantID,
+			ExpiresAt: expiresAt.Unix(),
+		})
+
+		return err
+	})
+
+	return reservationID, err
+}
+
+// BuyReservedMerch - покупка товара по действующему резерву пользователя.
+func (r *coinRepository) BuyReservedMerch(ctx context.Context, userID, merchID, reservationID int32) error {
+	return r.inTx(ctx, func(qtx *db.Queries) error {
+		variantID, err := qtx.ConsumeReservation(ctx, db.ConsumeReservationParams{
+			ID:      reservationID,
+			UserID:  userID,
+			MerchID: merchID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReservationNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Товар уже списан со склада при резервировании, нужна только цена
+		price, err := reservedPrice(ctx, qtx, merchID, variantID)
+		if err != nil {
+			return err
+		}
+
+		return payForMerch(ctx, qtx, userID, merchID, variantID, price)
+	})
+}
+
+// CancelReservation - отмена резерва пользователя с возвратом товара на склад.
+func (r *coinRepository) CancelReservation(ctx context.Context, userID, reservationID int32) error {
+	return r.inTx(ctx, func(qtx *db.Queries) error {
+		reservation, err := qtx.DeleteReservation(ctx, db.DeleteReservationParams{
+			ID:     reservationID,
+			UserID: userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReservationNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if reservation.VariantID.Valid {
+			return qtx.IncrementVariantStock(ctx, reservation.VariantID.Int32)
+		}
+
+		return qtx.IncrementMerchStock(ctx, reservation.MerchID)
+	})
+}
+
+// ReleaseExpiredReservations - удаление истекших резервов с возвратом товара на склад. Покупка и резерв
+// возвращают истекшие резервы своего товара сами, очистка освобождает остальные.
+func (r *coinRepository) ReleaseExpiredReservations(ctx context.Context) (int64, error) {
+	items, err := r.queries.ListExpiredReservationItems(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error listing expired reservations: %w", err)
+	}
+
+	var total int64
+
+	for _, item := range items {
+		var released int32
+
+		err := r.inTx(ctx, func(qtx *db.Queries) error {
+			var err error
+
+			// Блокируем товар или вариант в том же порядке, что и покупка
+			if item.VariantID.Valid {
+				err = qtx.LockMerchVariant(ctx, item.VariantID.Int32)
+			} else {
+				_, err = qtx.GetMerchForUpdate(ctx, item.MerchID)
+			}
+
+			if err != nil {
+				return fmt.Errorf("error locking merch %d: %w", item.MerchID, err)
+			}
+
+			released, err = releaseExpiredReservations(ctx, qtx, item.MerchID, item.VariantID)
+
+			return err
+		})
+		if err != nil {
+			return total, err
+		}
+
+		total += int64(released)
+	}
+
+	return total, nil
+}
+
+// releaseExpiredReservations - удаление истекших резервов товара merchID без варианта (variantID не задан)
+// или варианта variantID с возвратом единиц на склад. Строка товара или варианта должна быть уже
+// заблокирована, чтобы один резерв не вернулся на склад дважды. Возвращает количество удаленных резервов.
+func releaseExpiredReservations(ctx context.Context, qtx *db.Queries, merchID int32, variantID sql.NullInt32) (int32, error) {
+	deleted, err := qtx.DeleteExpiredItemReservations(ctx, db.DeleteExpiredItemReservationsParams{
+		MerchID:   merchID,
+		VariantID: variantID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired reservations: %w", err)
+	}
+
+	if deleted == 0 {
+		return 0, nil
+	}
+
+	released := int32(deleted)
+
+	if variantID.Valid {
+		err = qtx.AddVariantStock(ctx, db.AddVariantStockParams{Quantity: released, ID: variantID.Int32})
+	} else {
+		err = qtx.AddMerchStock(ctx, db.AddMerchStockParams{Quantity: released, ID: merchID})
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("error returning reserved merch to stock: %w", err)
+	}
+
+	return released, nil
+}
+
+// reservedPrice - цена зарезервированного товара или варианта, если он еще в продаже.
+func reservedPrice(ctx context.Context, qtx *db.Queries, merchID int32, variantID sql.NullInt32) (int32, error) {
+	if variantID.Valid {
+		price, err := qtx.GetVariantPrice(ctx, variantID.Int32)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrVariantNotFound
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("error retrieving merch variant: %w", err)
+		}
+
+		return price, nil
+	}
+
+	merch, err := qtx.GetMerchForPurchase(ctx, merchID)
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving merch: %w", err)
+	}
+
+	return merch.Price, nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"avito_coin/internal/db"
+)
+
+// ReverseTransfer - сторно перевода администратором actorID: компенсирующая проводка со счета
+// получателя на счет отправителя. Без amount сторнируется весь остаток перевода.
+func (r *coinRepository) ReverseTransfer(ctx context.Context, actorID, transactionID int32, amount sql.NullInt32, reason string) (db.Reversal, error) {
+	var reversal db.Reversal
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		transfer, err := qtx.LockTransaction(ctx, transactionID)
+		if err != nil {
+			return err
+		}
+
+		reversed, err := qtx.GetTransactionReversedAmount(ctx, sql.NullInt32{Int32: transactionID, Valid: true})
+		if err != nil {
+			return fmt.Errorf("error retrieving reversed amount: %w", err)
+		}
+
+		value, err := reversalAmount(transfer.Amount, reversed, amount)
+		if err != nil {
+			return err
+		}
+
+		senderAccountID, err := userAccountID(ctx, qtx, transfer.FromUser.Int32)
+		if err != nil {
+			return err
+		}
+
+		receiverAccountID, err := userAccountID(ctx, qtx, transfer.ToUser.Int32)
+		if err != nil {
+			return err
+		}
+
+		// Списываем монеты у получателя, только если их достаточно
+		entryID, err := postEntry(ctx, qtx, EntryReversal,
+			userPosting(receiverAccountID, -value),
+			userPosting(senderAccountID, value),
+		)
+		if err != nil {
+			return err
+		}
+
+		reversal, err = qtx.CreateReversal(ctx, db.CreateReversalParams{
+			TransactionID: sql.NullInt32{Int32: transactionID, Valid: true},
+			EntryID:       entryID,
+			Amount:        value,
+			Reason:        reason,
+			ActorID:       sql.NullInt32{Int32: actorID, Valid: actorID != 0},
+		})
+		if err != nil {
+			return fmt.Errorf("error recording reversal: %w", err)
+		}
+
+		return nil
+	})
+
+	return reversal, err
+}
+
+// RefundPurchase - возврат покупки администратором actorID: компенсирующая проводка со счета магазина
+// на счет покупателя. Без amount возвращается весь остаток цены. Полностью возвращенная покупка
+// пропадает из инвентаря, а товар возвращается на склад.
+func (r *coinRepository) RefundPurchase(ctx context.Context, actorID, purchaseID int32, amount sql.NullInt32, reason string) (db.Reversal, error) {
+	var reversal db.Reversal
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		purchase, err := qtx.LockPurchaseForRefund(ctx, purchaseID)
+		if err != nil {
+			return err
+		}
+
+		// Невыданный заказ отменяется целиком, а у старых покупок без проводки неизвестна цена
+		if !purchase.Price.Valid || (purchase.OrderStatus.Valid && purchase.OrderStatus.String != OrderStatusDelivered) {
+			return ErrPurchaseNotRefundable
+		}
+
+		refunded, err := qtx.GetPurchaseRefundedAmount(ctx, sql.NullInt32{Int32: purchaseID, Valid: true})
+		if err != nil {
+			return fmt.Errorf("error retrieving refunded amount: %w", err)
+		}
+
+		value, err := reversalAmount(purchase.Price.Int32, refunded, amount)
+		if err != nil {
+			return err
+		}
+
+		accountID, err := userAccountID(ctx, qtx, purchase.UserID.Int32)
+		if err != nil {
+			return err
+		}
+
+		shopID, err := systemAccountID(ctx, qtx, AccountShop)
+		if err != nil {
+			return err
+		}
+
+		entryID, err := postEntry(ctx, qtx, EntryRefund,
+			systemPosting(shopID, -value),
+			userPosting(accountID, value),
+		)
+		if err != nil {
+			return err
+		}
+
+		reversal, err = qtx.CreateReversal(ctx, db.CreateReversalParams{
+			PurchaseID: sql.NullInt32{Int32: purchaseID, Valid: true},
+			EntryID:    entryID,
+			Amount:     value,
+			Reason:     reason,
+			ActorID:    sql.NullInt32{Int32: actorID, Valid: actorID != 0},
+		})
+		if err != nil {
+			return fmt.Errorf("error recording refund: %w", err)
+		}
+
+		if refunded+value < purchase.Price.Int32 {
+			return nil
+		}
+
+		return returnPurchase(ctx, qtx, purchase)
+	})
+
+	return reversal, err
+}
+
+// GetUserReversals - последние limit сторно переводов и возвратов покупок, которые затронули баланс пользователя.
+func (r *coinRepository) GetUserReversals(ctx context.Context, userID, limit int32) ([]db.GetUserReversalsRow, error) {
+	return r.queries.GetUserReversals(ctx, db.GetUserReversalsParams{
+		UserID:  sql.NullInt32{Int32: userID, Valid: true},
+		MaxRows: limit,
+	})
+}
+
+// reversalAmount - сумма сторно: запрошенная или весь остаток операции на total монет,
+// из которых reversed уже сторнированы.
+func reversalAmount(total, reversed int32, requested sql.NullInt32) (int32, error) {
+	remaining := total - reversed
+	if remaining <= 0 {
+		return 0, ErrAlreadyReversed
+	}
+
+	if !requested.Valid {
+		return remaining, nil
+	}
+
+	if requested.Int32 > remaining {
+		return 0, ErrReversalExceedsAmount
+	}
+
+	return requested.Int32, nil
+}
+
+// returnPurchase - исключение покупки из инвентаря и возврат товара на склад.
+func returnPurchase(ctx context.Context, qtx *db.Queries, purchase db.LockPurchaseForRefundRow) error {
+	if err := qtx.MarkPurchaseReturned(ctx, purchase.ID); err != nil {
+		return fmt.Errorf("error marking purchase returned: %w", err)
+	}
+
+	var err error
+
+	// Остаток без учета (NULL) не меняется
+	if purchase.VariantID.Valid {
+		err = qtx.IncrementVariantStock(ctx, purchase.VariantID.Int32)
+	} else {
+		err = qtx.IncrementMerchStock(ctx, purchase.MerchID.Int32)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error returning merch to stock: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"avito_coin/internal/db"
+)
+
+// Роли пользователей.
+const (
+	RoleEmployee     = "employee"
+	RoleManager      = "manager"
+	RoleShopAdmin    = "shop-admin"
+	RoleFinanceAdmin = "finance-admin"
+	RoleAdmin        = "admin"
+)
+
+// GetUserRoles - роли пользователя.
+func (r *coinRepository) GetUserRoles(ctx context.Context, userID int32) ([]string, error) {
+	return r.queries.GetUserRoles(ctx, userID)
+}
+
+// GrantRole - выдача роли, false - роль уже была выдана. grantedBy равен 0, если роль выдана не администратором.
+// Ранее выпущенные access-токены пользователя отзываются, чтобы новые токены содержали актуальные роли.
+func (r *coinRepository) GrantRole(ctx context.Context, userID int32, role string, grantedBy int32) (bool, error) {
+	var granted bool
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		rows, err := qtx.AddUserRole(ctx, db.AddUserRoleParams{
+			UserID:    userID,
+			Role:      role,
+			GrantedBy: sql.NullInt32{Int32: grantedBy, Valid: grantedBy != 0},
+		})
+		if err != nil {
+			return err
+		}
+
+		granted = rows > 0
+		if !granted {
+			return nil
+		}
+
+		return qtx.SetTokensValidAfter(ctx, userID)
+	})
+
+	return granted, err
+}
+
+// RevokeRole - отзыв роли, false - роли у пользователя не было.
+// Ранее выпущенные access-токены пользователя отзываются, чтобы отозванная роль перестала действовать сразу.
+func (r *coinRepository) RevokeRole(ctx context.Context, userID int32, role string) (bool, error) {
+	var revoked bool
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		rows, err := qtx.DeleteUserRole(ctx, db.DeleteUserRoleParams{
+			UserID: userID,
+			Role:   role,
+		})
+		if err != nil {
+			return err
+		}
+
+		revoked = rows > 0
+		if !revoked {
+			return nil
+		}
+
+		return qtx.SetTokensValidAfter(ctx, userID)
+	})
+
+	return revoked, err
+}
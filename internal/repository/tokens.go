@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"avito_coin/internal/db"
+)
+
+var (
+	// ErrRefreshTokenInvalid - refresh-токен не найден, истек или отозван.
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid")
+	// ErrRefreshTokenReused - refresh-токен уже обменян; вся цепочка токенов отозвана.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// CreateRefreshToken - сохранение первого refresh-токена новой цепочки.
+func (r *coinRepository) CreateRefreshToken(
+	ctx context.Context,
+	userID int32,
+	familyID, tokenHash string,
+	ttl time.Duration,
+) error {
+	return r.queries.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+		UserID:     userID,
+		FamilyID:   familyID,
+		TokenHash:  tokenHash,
+		TtlSeconds: int32(ttl.Seconds()),
+	})
+}
+
+// RotateRefreshToken - обмен refresh-токена на новый из той же цепочки, возвращает ID пользователя.
+// Повторное предъявление уже обменянного токена отзывает всю цепочку: токен мог быть украден.
+func (r *coinRepository) RotateRefreshToken(
+	ctx context.Context,
+	tokenHash, newTokenHash string,
+	ttl time.Duration,
+) (int32, error) {
+	var (
+		userID int32
+		reused bool
+	)
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		reused = false
+
+		token, err := qtx.GetRefreshTokenForUpdate(ctx, tokenHash)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRefreshTokenInvalid
+		}
+
+		if err != nil {
+			return err
+		}
+
+		userID = token.UserID
+
+		if !token.Active {
+			return ErrRefreshTokenInvalid
+		}
+
+		if token.Used {
+			// Отзыв цепочки должен сохраниться, поэтому транзакция завершается без ошибки
+			reused = true
+
+			_, err := qtx.RevokeRefreshTokenFamily(ctx, db.RevokeRefreshTokenFamilyParams{
+				TokenHash: tokenHash,
+				UserID:    token.UserID,
+			})
+
+			return err
+		}
+
+		if err := qtx.MarkRefreshTokenUsed(ctx, token.ID); err != nil {
+			return err
+		}
+
+		return qtx.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+			UserID:     token.UserID,
+			FamilyID:   token.FamilyID,
+			ParentID:   sql.NullInt32{Int32: token.ID, Valid: true},
+			TokenHash:  newTokenHash,
+			TtlSeconds: int32(ttl.Seconds()),
+		})
+	})
+
+	if err == nil && reused {
+		err = ErrRefreshTokenReused
+	}
+
+	return userID, err
+}
+
+// RevokeRefreshTokenFamily - отзыв цепочки, к которой относится refresh-токен пользователя.
+func (r *coinRepository) RevokeRefreshTokenFamily(ctx context.Context, userID int32, tokenHash string) (int64, error) {
+	return r.queries.RevokeRefreshTokenFamily(ctx, db.RevokeRefreshTokenFamilyParams{
+		TokenHash: tokenHash,
+		UserID:    userID,
+	})
+}
+
+// RevokeUserTokens - отзыв всех токенов пользователя, выпущенных до текущего момента.
+func (r *coinRepository) RevokeUserTokens(ctx context.Context, userID int32) error {
+	return r.inTx(ctx, func(qtx *db.Queries) error {
+		if err := qtx.SetTokensValidAfter(ctx, userID); err != nil {
+			return err
+		}
+
+		return qtx.RevokeUserRefreshTokens(ctx, userID)
+	})
+}
+
+// RevokeAccessToken - отзыв access-токена до истечения его срока действия.
+func (r *coinRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return r.queries.RevokeAccessToken(ctx, db.RevokeAccessTokenParams{
+		Jti:       jti,
+		ExpiresAt: expiresAt.Unix(),
+	})
+}
+
+// IsTokenRevoked - отозван ли access-токен.
+func (r *coinRepository) IsTokenRevoked(ctx context.Context, userID int32, jti string, issuedAt time.Time) (bool, error) {
+	return r.queries.IsTokenRevoked(ctx, db.IsTokenRevokedParams{
+		Jti:      jti,
+		ID:       userID,
+		IssuedAt: issuedAt.Unix(),
+	})
+}
+
+// DeleteExpiredRefreshTokens - удаление истекших refresh-токенов.
+func (r *coinRepository) DeleteExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	return r.queries.DeleteExpiredRefreshTokens(ctx)
+}
+
+// DeleteExpiredRevokedTokens - удаление записей об отозванных токенах, срок действия которых истек.
+func (r *coinRepository) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	return r.queries.DeleteExpiredRevokedTokens(ctx)
+}
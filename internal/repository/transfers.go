@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"avito_coin/internal/db"
+)
+
+// TransferNote - сообщение к переводу, теги и видимость перевода в ленте благодарностей.
+type TransferNote struct {
+	Message sql.NullString
+	Tags    []string
+	Public  bool
+}
+
+// TransferCheck - проверка перевода по суммам переводов отправителя и получателя за день (с DayStart)
+// и неделю (с WeekStart). Выполняется в транзакции перевода после блокировки счетов обоих пользователей,
+// поэтому параллельные переводы с их участием видят суммы друг друга. Ошибка Check отменяет перевод.
+type TransferCheck struct {
+	DayStart  time.Time
+	WeekStart time.Time
+	Check     func(totals db.GetTransferTotalsRow) error
+}
+
+// checkTransfer - блокировка счетов участников перевода и проверка check по их суммам переводов.
+func checkTransfer(
+	ctx context.Context,
+	qtx *db.Queries,
+	fromUser, toUser, senderAccountID, receiverAccountID int32,
+	check *TransferCheck,
+) error {
+	if err := lockAccounts(ctx, qtx, senderAccountID, receiverAccountID); err != nil {
+		return err
+	}
+
+	totals, err := qtx.GetTransferTotals(ctx, db.GetTransferTotalsParams{
+		SenderID:    fromUser,
+		DayStart:    check.DayStart,
+		RecipientID: toUser,
+		WeekStart:   check.WeekStart,
+	})
+	if err != nil {
+		return fmt.Errorf("error getting transfer totals: %w", err)
+	}
+
+	return check.Check(totals)
+}
+
+// addTransferTags - сохранение тегов перевода.
+func addTransferTags(ctx context.Context, qtx *db.Queries, transactionID int32, tags []string) error {
+	for _, tag := range tags {
+		err := qtx.AddTransactionTag(ctx, db.AddTransactionTagParams{TransactionID: transactionID, Tag: tag})
+		if err != nil {
+			return fmt.Errorf("error tagging transfer: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListPublicTransfers - последние limit открытых переводов, необязательно с тегом tag.
+func (r *coinRepository) ListPublicTransfers(ctx context.Context, tag sql.NullString, limit int32) ([]db.ListPublicTransfersRow, error) {
+	return r.queries.ListPublicTransfers(ctx, db.ListPublicTransfersParams{Tag: tag, MaxRows: limit})
+}
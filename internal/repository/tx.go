@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"avito_coin/internal/db"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// maxTxAttempts - сколько раз пытаемся выполнить транзакцию при конфликтах.
+	maxTxAttempts = 5
+	// txRetryBaseDelay - базовая задержка перед повтором, растёт линейно с номером попытки.
+	txRetryBaseDelay = 10 * time.Millisecond
+
+	// Коды ошибок PostgreSQL, после которых транзакцию безопасно повторить.
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+
+	// pgUniqueViolation - нарушение уникального ограничения.
+	pgUniqueViolation = "23505"
+)
+
+// inTx - выполняет fn в транзакции и повторяет её при ошибке сериализации или дедлоке.
+func (r *coinRepository) inTx(ctx context.Context, fn func(qtx *db.Queries) error) error {
+	var err error
+
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = r.runTx(ctx, fn)
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+
+		// Ждём немного, чтобы конкурирующая транзакция успела завершиться
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * txRetryBaseDelay):
+		}
+	}
+
+	return fmt.Errorf("transaction failed after %d attempts: %w", maxTxAttempts, err)
+}
+
+// runTx - одна попытка выполнить fn в транзакции.
+func (r *coinRepository) runTx(ctx context.Context, fn func(qtx *db.Queries) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	if err := fn(r.queries.WithTx(tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// isRetryable - можно ли повторить транзакцию после такой ошибки.
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected
+}
+
+// isUniqueViolation - нарушено ли уникальное ограничение.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
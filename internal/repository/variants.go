@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"avito_coin/internal/db"
+)
+
+// AddMerchVariant - добавление варианта товара администратором actorID с записью в журнал изменений.
+// Если товара нет, возвращается sql.ErrNoRows.
+func (r *coinRepository) AddMerchVariant(ctx context.Context, actorID int32, arg db.AddMerchVariantParams) (db.MerchVariant, error) {
+	var variant db.MerchVariant
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		merch, err := qtx.GetMerchForUpdate(ctx, arg.MerchID)
+		if err != nil {
+			return err
+		}
+
+		variant, err = qtx.AddMerchVariant(ctx, arg)
+		if isUniqueViolation(err) {
+			return ErrVariantExists
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return addVariantAudit(ctx, qtx, actorID, MerchActionCreate, merch, variant)
+	})
+
+	return variant, err
+}
+
+// UpdateMerchVariant - изменение цены или доступности варианта администратором actorID с записью в журнал изменений.
+// Если варианта нет, возвращается sql.ErrNoRows.
+func (r *coinRepository) UpdateMerchVariant(ctx context.Context, actorID int32, arg db.UpdateMerchVariantParams) (db.MerchVariant, error) {
+	var variant db.MerchVariant
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		merch, err := qtx.GetMerchForUpdate(ctx, arg.MerchID)
+		if err != nil {
+			return err
+		}
+
+		before, err := qtx.GetMerchVariantForUpdate(ctx, db.GetMerchVariantForUpdateParams{MerchID: arg.MerchID, Sku: arg.Sku})
+		if err != nil {
+			return err
+		}
+
+		variant, err = qtx.UpdateMerchVariant(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		if variant == before {
+			return nil
+		}
+
+		action := MerchActionUpdate
+
+		switch {
+		case before.Active && !variant.Active:
+			action = MerchActionDeactivate
+		case !before.Active && variant.Active:
+			action = MerchActionActivate
+		}
+
+		return addVariantAudit(ctx, qtx, actorID, action, merch, variant)
+	})
+
+	return variant, err
+}
+
+// RestockMerchVariant - пополнение склада варианта администратором actorID с записью в журнал изменений.
+// Если варианта нет, возвращается sql.ErrNoRows.
+func (r *coinRepository) RestockMerchVariant(ctx context.Context, actorID, merchID int32, sku string, quantity int32) (db.MerchVariant, error) {
+	var variant db.MerchVariant
+
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		merch, err := qtx.GetMerch(ctx, merchID)
+		if err != nil {
+			return err
+		}
+
+		variant, err = qtx.RestockMerchVariant(ctx, db.RestockMerchVariantParams{
+			Quantity: quantity,
+			MerchID:  merchID,
+			Sku:      sku,
+		})
+		if err != nil {
+			return err
+		}
+
+		return addVariantAudit(ctx, qtx, actorID, MerchActionRestock, merch, variant)
+	})
+
+	return variant, err
+}
+
+// ListMerchVariants - все варианты товара, включая снятые с продажи.
+func (r *coinRepository) ListMerchVariants(ctx context.Context, merchID int32) ([]db.MerchVariant, error) {
+	return r.queries.ListMerchVariants(ctx, merchID)
+}
+
+// GetMerchVariant - вариант товара по артикулу. Если варианта нет, возвращается sql.ErrNoRows.
+func (r *coinRepository) GetMerchVariant(ctx context.Context, merchID int32, sku string) (db.MerchVariant, error) {
+	return r.queries.GetMerchVariant(ctx, db.GetMerchVariantParams{MerchID: merchID, Sku: sku})
+}
+
+// GetMerchVariantPrice - цена варианта в продаже.
+func (r *coinRepository) GetMerchVariantPrice(ctx context.Context, merchID int32, sku string) (int32, error) {
+	return r.queries.GetMerchVariantPrice(ctx, db.GetMerchVariantPriceParams{MerchID: merchID, Sku: sku})
+}
+
+// takeVariantFromStock - блокировка варианта в продаже и списание единицы его остатка,
+// возвращает ID варианта и цену. Если варианта нет или он снят с продажи, возвращается ErrVariantNotFound.
+func takeVariantFromStock(ctx context.Context, qtx *db.Queries, merchID int32, sku string) (int32, int32, error) {
+	variant, err := qtx.GetMerchVariantForPurchase(ctx, db.GetMerchVariantForPurchaseParams{
+		MerchID: merchID,
+		Sku:     sku,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, ErrVariantNotFound
+	}
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("error retrieving merch variant: %w", err)
+	}
+
+	if !variant.Stock.Valid {
+		return variant.ID, variant.Price, nil
+	}
+
+	// Истекшие резервы возвращаются на склад сразу, не дожидаясь фоновой очистки
+	released, err := releaseExpiredReservations(ctx, qtx, merchID, sql.NullInt32{Int32: variant.ID, Valid: true})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if variant.Stock.Int32+released <= 0 {
+		return 0, 0, ErrSoldOut
+	}
+
+	if err := qtx.DecrementVariantStock(ctx, variant.ID); err != nil {
+		return 0, 0, fmt.Errorf("error decrementing variant stock: %w", err)
+	}
+
+	return variant.ID, variant.Price, nil
+}
+
+// addVariantAudit - запись состояния варианта после изменения в журнал товара.
+func addVariantAudit(ctx context.Context, qtx *db.Queries, actorID int32, action string, merch db.Merch, variant db.MerchVariant) error {
+	price := merch.Price
+	if variant.Price.Valid {
+		price = variant.Price.Int32
+	}
+
+	return qtx.AddMerchAudit(ctx, db.AddMerchAuditParams{
+		MerchID: merch.ID,
+		Action:  action,
+		ActorID: sql.NullInt32{Int32: actorID, Valid: actorID != 0},
+		Name:    merch.Name,
+		Price:   price,
+		Active:  variant.Active,
+		Stock:   variant.Stock,
+		Variant: sql.NullString{String: variant.Sku, Valid: true},
+	})
+}
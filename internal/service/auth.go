@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrInvalidCredentials - неверное имя пользователя или пароль.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrUserNotFound - пользователя с таким именем нет.
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// Authenticate - проверка пароля пользователя, возвращает ID пользователя.
+// Хеши с устаревшими параметрами и пароли в открытом виде перехешируются после успешного входа.
+func (s *CoinService) Authenticate(ctx context.Context, username, password string) (int32, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.Authenticate")
+	defer span.End()
+
+	user, err := s.repo.UserExists(ctx, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Пароль все равно проверяется, чтобы неизвестный пользователь не отличался по времени ответа
+		s.passwords.VerifyDummy(password)
+		return 0, ErrUserNotFound
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	ok, rehash, err := s.passwords.Verify(password, user.Password)
+	if err != nil {
+		return 0, fmt.Errorf("failed to verify password: %w", err)
+	}
+
+	if !ok {
+		return 0, ErrInvalidCredentials
+	}
+
+	if rehash {
+		s.rehashPassword(ctx, user.ID, password)
+	}
+
+	return user.ID, nil
+}
+
+// rehashPassword - пересчет хеша пароля текущим алгоритмом; ошибка не мешает входу, попытка повторится при следующем.
+func (s *CoinService) rehashPassword(ctx context.Context, userID int32, password string) {
+	hash, err := s.passwords.Hash(password)
+	if err == nil {
+		err = s.repo.UpdateUserPassword(ctx, userID, hash)
+	}
+
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		}).Error("Failed to rehash password")
+	}
+}
+
+// userID - ID пользователя по имени.
+func (s *CoinService) userID(ctx context.Context, username string) (int32, error) {
+	user, err := s.repo.UserExists(ctx, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrUserNotFound
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user.ID, nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"avito_coin/internal/db"
+	"avito_coin/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxCartItemQuantity - сколько единиц товара можно добавить в корзину за раз.
+const MaxCartItemQuantity = 100
+
+var (
+	// ErrCartEmpty - в корзине нет товаров.
+	ErrCartEmpty = errors.New("cart is empty")
+	// ErrCartStale - цены в корзине изменились: корзина обновлена, заказ нужно подтвердить заново.
+	ErrCartStale = errors.New("cart prices have changed, review the cart and check out again")
+	// ErrCartItemNotFound - позиции нет в корзине пользователя.
+	ErrCartItemNotFound = errors.New("cart item not found")
+	// ErrInsufficientBalance - на балансе недостаточно монет для оплаты.
+	ErrInsufficientBalance = errors.New("insufficient balance")
+)
+
+// Cart - корзина пользователя.
+type Cart struct {
+	Items []db.ListCartItemsRow
+	// Total - стоимость корзины по ценам на момент добавления товаров.
+	Total int64
+}
+
+// cartError - перевод ошибок репозитория при работе с корзиной в ошибки сервиса.
+func cartError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrCartEmpty):
+		return ErrCartEmpty
+	case errors.Is(err, repository.ErrCartStale):
+		return ErrCartStale
+	case errors.Is(err, repository.ErrCartItemNotFound):
+		return ErrCartItemNotFound
+	default:
+		return merchError(err)
+	}
+}
+
+// GetCart - корзина пользователя.
+func (s *CoinService) GetCart(ctx context.Context, userID int32) (Cart, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetCart")
+	defer span.End()
+
+	items, err := s.repo.ListCartItems(ctx, userID)
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	cart := Cart{Items: items}
+	for _, item := range items {
+		cart.Total += int64(item.Price) * int64(item.Quantity)
+	}
+
+	return cart, nil
+}
+
+// AddToCart - добавление quantity единиц товара (или его варианта sku) в корзину по текущей цене.
+func (s *CoinService) AddToCart(ctx context.Context, userID, merchID int32, sku string, quantity int32) (Cart, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.AddToCart")
+	defer span.End()
+
+	if quantity <= 0 || quantity > MaxCartItemQuantity {
+		return Cart{}, fmt.Errorf("%w: at most %d items can be added at once", ErrInvalidQuantity, MaxCartItemQuantity)
+	}
+
+	if err := s.repo.AddCartItem(ctx, userID, merchID, sku, quantity); err != nil {
+		return Cart{}, cartError(err)
+	}
+
+	return s.GetCart(ctx, userID)
+}
+
+// RemoveFromCart - удаление позиции из корзины.
+func (s *CoinService) RemoveFromCart(ctx context.Context, userID, itemID int32) (Cart, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.RemoveFromCart")
+	defer span.End()
+
+	if err := s.repo.RemoveCartItem(ctx, userID, itemID); err != nil {
+		return Cart{}, cartError(err)
+	}
+
+	return s.GetCart(ctx, userID)
+}
+
+// Checkout - оформление заказа: оплата всей корзины и запись покупок одной транзакцией.
+// Если цены изменились с момента добавления товаров, корзина получает новые цены и возвращается ErrCartStale.
+func (s *CoinService) Checkout(ctx context.Context, userID int32, input CheckoutInput) (db.Order, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.Checkout")
+	defer span.End()
+
+	if err := input.validate(); err != nil {
+		return db.Order{}, err
+	}
+
+	items, err := s.repo.ListCartItems(ctx, userID)
+	if err != nil {
+		return db.Order{}, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	order, err := s.repo.Checkout(ctx, userID, nullString(input.PickupLocation), nullString(input.Notes))
+	if err != nil {
+		err = cartError(err)
+		s.metrics.PurchaseFailed(purchaseFailureReason(err))
+
+		return db.Order{}, err
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"user_id":  userID,
+		"order_id": order.ID,
+		"total":    order.Total,
+	}).Info("Order placed")
+
+	for _, item := range items {
+		s.metrics.PurchaseCompleted(item.MerchID, item.Quantity)
+		s.checkLowStock(ctx, item.MerchID, item.Variant.String)
+	}
+
+	return order, nil
+}
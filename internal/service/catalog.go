@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"avito_coin/internal/catalog"
+	"avito_coin/internal/db"
+)
+
+// CatalogReport - результат загрузки каталога: названия товаров по виду изменения.
+type CatalogReport struct {
+	Created   []string
+	Updated   []string
+	Unchanged []string
+}
+
+// Changed - изменила ли загрузка каталог.
+func (r *CatalogReport) Changed() bool {
+	return len(r.Created) > 0 || len(r.Updated) > 0
+}
+
+// SyncCatalog - загрузка товаров в каталог от имени actorID (0 - не администратор).
+// Товары сопоставляются по названию: новые добавляются, у существующих обновляются цена
+// и, если задан, флаг active. Товары, которых нет в файле, не меняются.
+// С dryRun изменения только подсчитываются.
+func (s *CoinService) SyncCatalog(ctx context.Context, actorID int32, items []catalog.Item, dryRun bool) (*CatalogReport, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.SyncCatalog")
+	defer span.End()
+
+	existing, err := s.ListMerch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]db.Merch, len(existing))
+	for _, m := range existing {
+		byName[m.Name] = m
+	}
+
+	report := &CatalogReport{}
+
+	for _, item := range items {
+		current, ok := byName[item.Name]
+		if !ok {
+			report.Created = append(report.Created, item.Name)
+
+			if err := s.createCatalogItem(ctx, actorID, item, dryRun); err != nil {
+				return report, err
+			}
+
+			continue
+		}
+
+		update := MerchUpdate{}
+
+		if current.Price != item.Price {
+			update.Price = &item.Price
+		}
+
+		if item.Active != nil && current.Active != *item.Active {
+			update.Active = item.Active
+		}
+
+		if update.Price == nil && update.Active == nil {
+			report.Unchanged = append(report.Unchanged, item.Name)
+			continue
+		}
+
+		report.Updated = append(report.Updated, item.Name)
+
+		if dryRun {
+			continue
+		}
+
+		if _, err := s.UpdateMerch(ctx, actorID, current.ID, update); err != nil {
+			return report, fmt.Errorf("failed to update %q: %w", item.Name, err)
+		}
+	}
+
+	return report, nil
+}
+
+// createCatalogItem - добавление нового товара каталога; снятый с продажи товар добавляется и сразу снимается.
+func (s *CoinService) createCatalogItem(ctx context.Context, actorID int32, item catalog.Item, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+
+	merch, err := s.AddMerch(ctx, actorID, item.Name, item.Price)
+	if err != nil {
+		return fmt.Errorf("failed to add %q: %w", item.Name, err)
+	}
+
+	if item.Active != nil && !*item.Active {
+		if _, err := s.DeactivateMerch(ctx, actorID, merch.ID); err != nil {
+			return fmt.Errorf("failed to deactivate %q: %w", item.Name, err)
+		}
+	}
+
+	return nil
+}
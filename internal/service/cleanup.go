@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultCleanupInterval - период запуска фоновой очистки по умолчанию.
+const DefaultCleanupInterval = 10 * time.Minute
+
+// RunCleanup - фоновая очистка просроченных данных, работает до отмены контекста.
+func (s *CoinService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.cleanup(ctx)
+		}
+	}
+}
+
+// cleanup - один проход фоновой очистки.
+func (s *CoinService) cleanup(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "CoinService.cleanup")
+	defer span.End()
+
+	tasks := []struct {
+		name string
+		run  func(ctx context.Context) (int64, error)
+	}{
+		{name: "idempotency keys", run: s.repo.DeleteExpiredIdempotencyKeys},
+		{name: "refresh tokens", run: s.repo.DeleteExpiredRefreshTokens},
+		{name: "revoked tokens", run: s.repo.DeleteExpiredRevokedTokens},
+		{name: "merch reservations", run: s.repo.ReleaseExpiredReservations},
+	}
+
+	// Ошибка одной задачи не мешает остальным
+	for _, task := range tasks {
+		deleted, err := task.run(ctx)
+		if err != nil {
+			logrus.WithContext(ctx).WithError(err).Errorf("Failed to delete expired %s", task.name)
+			continue
+		}
+
+		if deleted > 0 {
+			logrus.WithContext(ctx).WithField("deleted", deleted).Infof("Expired %s deleted", task.name)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"avito_coin/internal/db"
+)
+
+const (
+	// InfoHistoryLimit - сколько последних переводов и сторно показывается в /api/info.
+	// Полная история доступна постранично через GetHistory.
+	InfoHistoryLimit = 10
+	// DefaultHistoryPageSize - размер страницы истории по умолчанию.
+	DefaultHistoryPageSize = 20
+	// MaxHistoryPageSize - наибольший размер страницы истории.
+	MaxHistoryPageSize = 100
+)
+
+const (
+	// HistoryTypeTransfer - перевод монет.
+	HistoryTypeTransfer = "transfer"
+	// HistoryTypePurchase - покупка мерча.
+	HistoryTypePurchase = "purchase"
+
+	// HistoryDirectionIncoming - монеты пришли на баланс пользователя.
+	HistoryDirectionIncoming = "incoming"
+	// HistoryDirectionOutgoing - монеты ушли с баланса пользователя.
+	HistoryDirectionOutgoing = "outgoing"
+)
+
+// ErrInvalidHistoryFilter - неверный фильтр, размер страницы или курсор истории.
+var ErrInvalidHistoryFilter = errors.New("invalid history filter")
+
+// HistoryFilter - фильтры и курсор страницы истории. Пустые поля не фильтруют.
+type HistoryFilter struct {
+	// Type - transfer или purchase.
+	Type string
+	// Direction - incoming или outgoing; покупки всегда outgoing.
+	Direction string
+	// Counterparty - имя второго участника перевода; покупки в выборку не попадают.
+	Counterparty string
+	// MinAmount и MaxAmount - границы суммы включительно.
+	MinAmount *int32
+	MaxAmount *int32
+	// From и To - окно времени [From, To).
+	From *time.Time
+	To   *time.Time
+	// Limit - размер страницы, 0 - DefaultHistoryPageSize.
+	Limit int
+	// Cursor - NextCursor предыдущей страницы.
+	Cursor string
+}
+
+// HistoryPage - страница истории и курсор следующей страницы (пустой, если это последняя страница).
+type HistoryPage struct {
+	Items      []db.GetUserHistoryRow
+	NextCursor string
+}
+
+// historyCursor - позиция последней записи страницы в порядке сортировки истории.
+type historyCursor struct {
+	createdAt time.Time
+	kind      string
+	id        int32
+}
+
+// encode - курсор в виде непрозрачной строки для клиента.
+func (c historyCursor) encode() string {
+	raw := fmt.Sprintf("%d:%s:%d", c.createdAt.UnixMicro(), c.kind, c.id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeHistoryCursor - разбор курсора, полученного от клиента.
+func decodeHistoryCursor(cursor string) (historyCursor, error) {
+	invalid := fmt.Errorf("%w: malformed cursor", ErrInvalidHistoryFilter)
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return historyCursor{}, invalid
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 || (parts[1] != HistoryTypeTransfer && parts[1] != HistoryTypePurchase) {
+		return historyCursor{}, invalid
+	}
+
+	micros, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return historyCursor{}, invalid
+	}
+
+	id, err := strconv.ParseInt(parts[2], 10, 32)
+	if err != nil {
+		return historyCursor{}, invalid
+	}
+
+	return historyCursor{createdAt: time.UnixMicro(micros).UTC(), kind: parts[1], id: int32(id)}, nil
+}
+
+// validate - проверка фильтра и размер страницы.
+func (f HistoryFilter) validate() (int, error) {
+	if f.Type != "" && f.Type != HistoryTypeTransfer && f.Type != HistoryTypePurchase {
+		return 0, fmt.Errorf("%w: type must be transfer or purchase", ErrInvalidHistoryFilter)
+	}
+
+	if f.Direction != "" && f.Direction != HistoryDirectionIncoming && f.Direction != HistoryDirectionOutgoing {
+		return 0, fmt.Errorf("%w: direction must be incoming or outgoing", ErrInvalidHistoryFilter)
+	}
+
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MinAmount > *f.MaxAmount {
+		return 0, fmt.Errorf("%w: minAmount is greater than maxAmount", ErrInvalidHistoryFilter)
+	}
+
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return 0, fmt.Errorf("%w: from must be before to", ErrInvalidHistoryFilter)
+	}
+
+	if f.Limit == 0 {
+		return DefaultHistoryPageSize, nil
+	}
+
+	if f.Limit < 0 || f.Limit > MaxHistoryPageSize {
+		return 0, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidHistoryFilter, MaxHistoryPageSize)
+	}
+
+	return f.Limit, nil
+}
+
+// GetHistory - страница истории переводов и покупок пользователя от новых к старым.
+func (s *CoinService) GetHistory(ctx context.Context, userID int32, filter HistoryFilter) (*HistoryPage, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetHistory")
+	defer span.End()
+
+	limit, err := filter.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.GetUserHistoryParams{
+		UserID:    sql.NullInt32{Int32: userID, Valid: true},
+		Type:      sql.NullString{String: filter.Type, Valid: filter.Type != ""},
+		Direction: sql.NullString{String: filter.Direction, Valid: filter.Direction != ""},
+		MinAmount: nullInt32(filter.MinAmount),
+		MaxAmount: nullInt32(filter.MaxAmount),
+		// Лишняя запись показывает, есть ли следующая страница
+		PageSize: int32(limit) + 1,
+	}
+
+	if filter.From != nil {
+		arg.Since = sql.NullTime{Time: filter.From.UTC(), Valid: true}
+	}
+
+	if filter.To != nil {
+		arg.Until = sql.NullTime{Time: filter.To.UTC(), Valid: true}
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeHistoryCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		arg.CursorTime = sql.NullTime{Time: cursor.createdAt, Valid: true}
+		arg.CursorType = sql.NullString{String: cursor.kind, Valid: true}
+		arg.CursorID = sql.NullInt32{Int32: cursor.id, Valid: true}
+	}
+
+	if filter.Counterparty != "" {
+		counterparty, err := s.repo.UserExists(ctx, filter.Counterparty)
+		if errors.Is(err, sql.ErrNoRows) {
+			// С несуществующим пользователем переводов нет
+			return &HistoryPage{Items: []db.GetUserHistoryRow{}}, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to find counterparty: %w", err)
+		}
+
+		arg.CounterpartyID = sql.NullInt32{Int32: counterparty.ID, Valid: true}
+	}
+
+	items, err := s.repo.GetUserHistory(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+
+	page := &HistoryPage{Items: items}
+
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = historyCursor{createdAt: last.CreatedAt.Time, kind: last.Type, id: last.ID}.encode()
+	}
+
+	return page, nil
+}
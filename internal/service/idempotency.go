@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultIdempotencyTTL - время хранения ключей идемпотентности по умолчанию.
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// DefaultIdempotencyLease - сколько ключ без ответа считается занятым выполняющимся запросом.
+	DefaultIdempotencyLease = 2 * time.Minute
+)
+
+var (
+	// ErrIdempotencyKeyReused - ключ уже использован для запроса с другим содержимым.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+	// ErrIdempotencyKeyInProgress - запрос с этим ключом еще выполняется.
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is still in progress")
+)
+
+// IdempotentResponse - сохраненный ответ на запрос с ключом идемпотентности.
+type IdempotentResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// BeginIdempotentRequest - резервирует ключ идемпотентности перед выполнением запроса.
+// Если запрос с этим ключом уже выполнен, возвращается сохраненный ответ, и выполнять запрос повторно не нужно.
+// Ключ без ответа дольше idempotencyLease освобождается для повтора: выполнявший запрос процесс, видимо, упал.
+func (s *CoinService) BeginIdempotentRequest(
+	ctx context.Context,
+	userID int32,
+	key, fingerprint string,
+) (*IdempotentResponse, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.BeginIdempotentRequest")
+	defer span.End()
+
+	created, err := s.repo.CreateIdempotencyKey(ctx, userID, key, fingerprint, s.idempotencyTTL, s.idempotencyLease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	if created {
+		return nil, nil
+	}
+
+	stored, err := s.repo.GetIdempotencyKey(ctx, userID, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Ключ успели освободить после неудачного запроса, клиенту стоит повторить попытку
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if stored.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	if !stored.StatusCode.Valid {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return &IdempotentResponse{
+		StatusCode: int(stored.StatusCode.Int32),
+		Body:       stored.ResponseBody,
+	}, nil
+}
+
+// CompleteIdempotentRequest - сохраняет ответ на выполненный запрос для повторов с тем же ключом.
+func (s *CoinService) CompleteIdempotentRequest(
+	ctx context.Context,
+	userID int32,
+	key string,
+	response IdempotentResponse,
+) error {
+	ctx, span := tracer.Start(ctx, "CoinService.CompleteIdempotentRequest")
+	defer span.End()
+
+	return s.repo.CompleteIdempotencyKey(ctx, userID, key, int32(response.StatusCode), response.Body)
+}
+
+// AbortIdempotentRequest - освобождает ключ, если запрос не удалось выполнить, чтобы клиент мог его повторить.
+func (s *CoinService) AbortIdempotentRequest(ctx context.Context, userID int32, key string) error {
+	ctx, span := tracer.Start(ctx, "CoinService.AbortIdempotentRequest")
+	defer span.End()
+
+	return s.repo.DeleteIdempotencyKey(ctx, userID, key)
+}
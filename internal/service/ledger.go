@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"avito_coin/internal/db"
+	"avito_coin/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// LedgerAudit - результат сверки журнала проводок.
+type LedgerAudit struct {
+	// Issued - сколько монет выпущено со счета эмиссии.
+	Issued int64
+	// UserBalances - сколько монет на счетах пользователей.
+	UserBalances int64
+	// ShopBalance - сколько монет потрачено в магазине.
+	ShopBalance int64
+	// UnbalancedEntries - записи, проводки которых не сходятся в ноль.
+	UnbalancedEntries []db.GetUnbalancedEntriesRow
+	// Discrepancies - счета, кэш баланса которых расходится с проводками.
+	Discrepancies []db.GetAccountDiscrepanciesRow
+}
+
+// Consistent - сходится ли журнал: каждая выпущенная монета находится на счете пользователя или магазина.
+func (a *LedgerAudit) Consistent() bool {
+	return len(a.UnbalancedEntries) == 0 &&
+		len(a.Discrepancies) == 0 &&
+		a.Issued == a.UserBalances+a.ShopBalance
+}
+
+// GetAccountStatement - выписка по счету пользователя: откуда пришла и куда ушла каждая монета.
+func (s *CoinService) GetAccountStatement(ctx context.Context, userID int32) ([]db.GetAccountPostingsRow, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetAccountStatement")
+	defer span.End()
+
+	postings, err := s.repo.GetAccountPostings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account postings: %w", err)
+	}
+
+	return postings, nil
+}
+
+// CoinsInCirculation - сколько монет сейчас на счетах пользователей. Считается по кэшированным балансам,
+// а не по журналу проводок: метрика запрашивается при каждом сборе, а их сверку выполняет AuditLedger.
+func (s *CoinService) CoinsInCirculation(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.CoinsInCirculation")
+	defer span.End()
+
+	total, err := s.repo.GetUserBalancesTotal(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user balances total: %w", err)
+	}
+
+	return total, nil
+}
+
+// AuditLedger - сверка журнала проводок с кэшированными балансами.
+func (s *CoinService) AuditLedger(ctx context.Context) (*LedgerAudit, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.AuditLedger")
+	defer span.End()
+
+	totals, err := s.repo.GetLedgerTotals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger totals: %w", err)
+	}
+
+	audit := &LedgerAudit{}
+
+	for _, total := range totals {
+		switch total.Kind {
+		case repository.AccountIssuance:
+			// Счет эмиссии уходит в минус на количество выпущенных монет
+			audit.Issued = -total.Total
+		case repository.AccountUser:
+			audit.UserBalances = total.Total
+		case repository.AccountShop:
+			audit.ShopBalance = total.Total
+		}
+	}
+
+	audit.UnbalancedEntries, err = s.repo.GetUnbalancedEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unbalanced entries: %w", err)
+	}
+
+	audit.Discrepancies, err = s.repo.GetAccountDiscrepancies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account discrepancies: %w", err)
+	}
+
+	return audit, nil
+}
+
+// SetUserBalance - установка баланса пользователя администратором actorID корректирующей проводкой.
+func (s *CoinService) SetUserBalance(ctx context.Context, actorID int32, username string, balance int32) error {
+	ctx, span := tracer.Start(ctx, "CoinService.SetUserBalance")
+	defer span.End()
+
+	userID, err := s.userID(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	if err := s.UpdateUserBalance(ctx, userID, balance); err != nil {
+		return err
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"user_id":  userID,
+		"balance":  balance,
+		"actor_id": actorID,
+	}).Info("User balance adjusted")
+
+	return nil
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"avito_coin/internal/db"
+	"avito_coin/internal/repository"
+)
+
+// Названия лимитов переводов. Они же - ключи в настройке лимитов ролей.
+const (
+	// LimitMinAmount - наименьшая сумма одного перевода.
+	LimitMinAmount = "min_amount"
+	// LimitMaxAmount - наибольшая сумма одного перевода.
+	LimitMaxAmount = "max_amount"
+	// LimitDailyOutgoing - сколько монет можно отправить за день.
+	LimitDailyOutgoing = "daily_outgoing"
+	// LimitWeeklyOutgoing - сколько монет можно отправить за неделю.
+	LimitWeeklyOutgoing = "weekly_outgoing"
+	// LimitDailyIncoming - сколько монет можно получить за день.
+	LimitDailyIncoming = "daily_incoming"
+	// LimitWeeklyIncoming - сколько монет можно получить за неделю.
+	LimitWeeklyIncoming = "weekly_incoming"
+	// LimitDailyRecipients - скольким разным получателям можно отправить монеты за день.
+	LimitDailyRecipients = "daily_recipients"
+)
+
+var (
+	// ErrLimitExceeded - перевод нарушает лимит; подробности - в LimitError.
+	ErrLimitExceeded = errors.New("transfer limit exceeded")
+	// ErrInvalidLimits - неверная настройка лимитов.
+	ErrInvalidLimits = errors.New("invalid transfer limits")
+)
+
+// TransferLimits - лимиты переводов. Нулевой лимит (кроме MinAmount) не ограничивает.
+// Дни и недели (с понедельника) считаются по UTC, сторнированные суммы в расход не входят.
+type TransferLimits struct {
+	// MinAmount и MaxAmount - границы суммы одного перевода; перевод меньше 1 монеты невозможен при любом MinAmount.
+	MinAmount int64
+	MaxAmount int64
+	// DailyOutgoing и WeeklyOutgoing - сколько монет пользователь может отправить за день и за неделю.
+	DailyOutgoing  int64
+	WeeklyOutgoing int64
+	// DailyIncoming и WeeklyIncoming - сколько монет пользователь может получить за день и за неделю.
+	DailyIncoming  int64
+	WeeklyIncoming int64
+	// DailyRecipients - скольким разным получателям пользователь может отправить монеты за день.
+	DailyRecipients int64
+}
+
+// DefaultTransferLimits - лимиты по умолчанию: перевод не меньше 1 монеты, остальное без ограничений.
+func DefaultTransferLimits() TransferLimits {
+	return TransferLimits{MinAmount: 1}
+}
+
+// set - установка лимита по названию.
+func (l *TransferLimits) set(name string, value int64) error {
+	if value < 0 {
+		return fmt.Errorf("%w: %s must not be negative", ErrInvalidLimits, name)
+	}
+
+	switch name {
+	case LimitMinAmount:
+		l.MinAmount = value
+	case LimitMaxAmount:
+		l.MaxAmount = value
+	case LimitDailyOutgoing:
+		l.DailyOutgoing = value
+	case LimitWeeklyOutgoing:
+		l.WeeklyOutgoing = value
+	case LimitDailyIncoming:
+		l.DailyIncoming = value
+	case LimitWeeklyIncoming:
+		l.WeeklyIncoming = value
+	case LimitDailyRecipients:
+		l.DailyRecipients = value
+	default:
+		return fmt.Errorf("%w: unknown limit %q", ErrInvalidLimits, name)
+	}
+
+	return nil
+}
+
+// loosest - самые мягкие из двух наборов лимитов.
+func (l TransferLimits) loosest(other TransferLimits) TransferLimits {
+	return TransferLimits{
+		MinAmount:       min(l.MinAmount, other.MinAmount),
+		MaxAmount:       loosestCap(l.MaxAmount, other.MaxAmount),
+		DailyOutgoing:   loosestCap(l.DailyOutgoing, other.DailyOutgoing),
+		WeeklyOutgoing:  loosestCap(l.WeeklyOutgoing, other.WeeklyOutgoing),
+		DailyIncoming:   loosestCap(l.DailyIncoming, other.DailyIncoming),
+		WeeklyIncoming:  loosestCap(l.WeeklyIncoming, other.WeeklyIncoming),
+		DailyRecipients: loosestCap(l.DailyRecipients, other.DailyRecipients),
+	}
+}
+
+// loosestCap - больший из двух лимитов, где 0 - без ограничения.
+func loosestCap(a, b int64) int64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+
+	return max(a, b)
+}
+
+// periodic - есть ли лимиты за день или неделю, для которых нужны суммы переводов.
+func (l TransferLimits) periodic() bool {
+	return l.DailyOutgoing > 0 || l.WeeklyOutgoing > 0 || l.DailyIncoming > 0 || l.WeeklyIncoming > 0 ||
+		l.DailyRecipients > 0
+}
+
+// LimitPolicy - глобальные лимиты и лимиты ролей.
+type LimitPolicy struct {
+	// Default - лимиты пользователей без ролей из Roles.
+	Default TransferLimits
+	// Roles - лимиты ролей. Пользователь с несколькими ролями из списка получает самые мягкие из их лимитов.
+	Roles map[string]TransferLimits
+}
+
+// DefaultLimitPolicy - лимиты по умолчанию для всех ролей.
+func DefaultLimitPolicy() LimitPolicy {
+	return LimitPolicy{Default: DefaultTransferLimits()}
+}
+
+// forRoles - лимиты пользователя с ролями roles.
+func (p LimitPolicy) forRoles(roles []string) TransferLimits {
+	var (
+		limits TransferLimits
+		found  bool
+	)
+
+	for _, role := range roles {
+		roleLimits, ok := p.Roles[role]
+		if !ok {
+			continue
+		}
+
+		if !found {
+			limits, found = roleLimits, true
+			continue
+		}
+
+		limits = limits.loosest(roleLimits)
+	}
+
+	if !found {
+		return p.Default
+	}
+
+	return limits
+}
+
+// ParseRoleLimits - разбор лимитов ролей вида "manager:max_amount=500,daily_outgoing=2000;admin:daily_outgoing=0".
+// Незаданные лимиты роли берутся из base.
+func ParseRoleLimits(value string, base TransferLimits) (map[string]TransferLimits, error) {
+	roles := make(map[string]TransferLimits)
+
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		role, list, ok := strings.Cut(entry, ":")
+		role = strings.TrimSpace(role)
+
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid role limits entry %q", ErrInvalidLimits, entry)
+		}
+
+		if !ValidRole(role) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownRole, role)
+		}
+
+		limits := base
+
+		for _, item := range strings.Split(list, ",") {
+			name, raw, ok := strings.Cut(item, "=")
+			if !ok {
+				return nil, fmt.Errorf("%w: invalid limit %q for role %s", ErrInvalidLimits, item, role)
+			}
+
+			limit, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid value of %s for role %s", ErrInvalidLimits, strings.TrimSpace(name), role)
+			}
+
+			if err := limits.set(strings.TrimSpace(name), limit); err != nil {
+				return nil, err
+			}
+		}
+
+		roles[role] = limits
+	}
+
+	return roles, nil
+}
+
+// LimitError - перевод нарушает лимит.
+type LimitError struct {
+	// Limit - название лимита, например LimitDailyOutgoing.
+	Limit string
+	// Value - значение лимита.
+	Value int64
+	// Used - сколько лимита уже израсходовано за период; для лимитов суммы одного перевода - 0.
+	Used int64
+	// ResetsAt - начало следующего периода лимита; нулевое для лимитов суммы одного перевода.
+	ResetsAt time.Time
+}
+
+func (e *LimitError) Error() string {
+	if e.ResetsAt.IsZero() {
+		return fmt.Sprintf("%s: %s is %d", ErrLimitExceeded, e.Limit, e.Value)
+	}
+
+	return fmt.Sprintf("%s: %s is %d, %d already used, resets at %s",
+		ErrLimitExceeded, e.Limit, e.Value, e.Used, e.ResetsAt.Format(time.RFC3339))
+}
+
+// Is - LimitError соответствует ErrLimitExceeded.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// limitsFor - лимиты пользователя с учетом его ролей.
+func (s *CoinService) limitsFor(ctx context.Context, userID int32) (TransferLimits, error) {
+	if len(s.limits.Roles) == 0 {
+		return s.limits.Default, nil
+	}
+
+	roles, err := s.repo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return TransferLimits{}, fmt.Errorf("failed to get user roles: %w", err)
+	}
+
+	return s.limits.forRoles(roles), nil
+}
+
+// transferCheck - проверка суммы перевода по лимитам отправителя и проверка сумм за день и неделю
+// по лимитам отправителя (расход, получатели) и получателя (поступления). Суммы за периоды проверяются
+// репозиторием в транзакции перевода, чтобы параллельные переводы не превысили лимит вместе;
+// nil - таких лимитов нет.
+func (s *CoinService) transferCheck(ctx context.Context, senderID, recipientID, amount int32) (*repository.TransferCheck, error) {
+	sender, err := s.limitsFor(ctx, senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	value := int64(amount)
+
+	if minAmount := max(sender.MinAmount, 1); value < minAmount {
+		return nil, &LimitError{Limit: LimitMinAmount, Value: minAmount}
+	}
+
+	if sender.MaxAmount > 0 && value > sender.MaxAmount {
+		return nil, &LimitError{Limit: LimitMaxAmount, Value: sender.MaxAmount}
+	}
+
+	recipient, err := s.limitsFor(ctx, recipientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sender.periodic() && !recipient.periodic() {
+		return nil, nil
+	}
+
+	dayStart := time.Now().UTC().Truncate(24 * time.Hour)
+	weekStart := dayStart.AddDate(0, 0, -(int(dayStart.Weekday())+6)%7)
+	dayEnd, weekEnd := dayStart.AddDate(0, 0, 1), weekStart.AddDate(0, 0, 7)
+
+	return &repository.TransferCheck{
+		DayStart:  dayStart,
+		WeekStart: weekStart,
+		Check: func(totals db.GetTransferTotalsRow) error {
+			for _, check := range []LimitError{
+				{Limit: LimitDailyOutgoing, Value: sender.DailyOutgoing, Used: totals.SentToday, ResetsAt: dayEnd},
+				{Limit: LimitWeeklyOutgoing, Value: sender.WeeklyOutgoing, Used: totals.SentThisWeek, ResetsAt: weekEnd},
+				{Limit: LimitDailyIncoming, Value: recipient.DailyIncoming, Used: totals.ReceivedToday, ResetsAt: dayEnd},
+				{Limit: LimitWeeklyIncoming, Value: recipient.WeeklyIncoming, Used: totals.ReceivedThisWeek, ResetsAt: weekEnd},
+			} {
+				if check.Value > 0 && check.Used+value > check.Value {
+					return &check
+				}
+			}
+
+			// Повторный перевод тому же получателю не расходует лимит получателей
+			if sender.DailyRecipients > 0 && totals.OtherRecipientsToday >= sender.DailyRecipients {
+				return &LimitError{
+					Limit:    LimitDailyRecipients,
+					Value:    sender.DailyRecipients,
+					Used:     totals.OtherRecipientsToday,
+					ResetsAt: dayEnd,
+				}
+			}
+
+			return nil
+		},
+	}, nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"avito_coin/internal/db"
+	"avito_coin/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrMerchNotFound - товара нет в каталоге или он снят с продажи.
+	ErrMerchNotFound = errors.New("merch not found")
+	// ErrInvalidMerch - пустое название или неположительная цена товара.
+	ErrInvalidMerch = errors.New("invalid merch")
+	// ErrMerchNameTaken - товар с таким названием уже есть в каталоге.
+	ErrMerchNameTaken = errors.New("merch name is already taken")
+)
+
+// MerchUpdate - изменяемые поля товара; nil - поле не меняется.
+type MerchUpdate struct {
+	Name   *string
+	Price  *int32
+	Active *bool
+}
+
+// validateMerch - проверка названия и цены товара.
+func validateMerch(name *string, price *int32) error {
+	if name != nil && strings.TrimSpace(*name) == "" {
+		return fmt.Errorf("%w: name must not be empty", ErrInvalidMerch)
+	}
+
+	if price != nil && *price <= 0 {
+		return fmt.Errorf("%w: price must be positive", ErrInvalidMerch)
+	}
+
+	return nil
+}
+
+// ListMerch - весь каталог, включая снятые с продажи товары.
+func (s *CoinService) ListMerch(ctx context.Context) ([]db.Merch, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.ListMerch")
+	defer span.End()
+
+	merch, err := s.repo.ListMerch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merch: %w", err)
+	}
+
+	return merch, nil
+}
+
+// AddMerch - добавление товара в каталог администратором actorID.
+func (s *CoinService) AddMerch(ctx context.Context, actorID int32, name string, price int32) (db.Merch, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.AddMerch")
+	defer span.End()
+
+	name = strings.TrimSpace(name)
+	if err := validateMerch(&name, &price); err != nil {
+		return db.Merch{}, err
+	}
+
+	merch, err := s.repo.AddMerch(ctx, actorID, name, price)
+	if errors.Is(err, repository.ErrMerchExists) {
+		return db.Merch{}, ErrMerchNameTaken
+	}
+
+	if err != nil {
+		return db.Merch{}, fmt.Errorf("failed to add merch: %w", err)
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"merch_id": merch.ID,
+		"name":     merch.Name,
+		"price":    merch.Price,
+		"actor_id": actorID,
+	}).Info("Merch added")
+
+	return merch, nil
+}
+
+// UpdateMerch - изменение товара администратором actorID. Снятый с продажи товар остается
+// в каталоге, поэтому история покупок продолжает на него ссылаться.
+func (s *CoinService) UpdateMerch(ctx context.Context, actorID, merchID int32, update MerchUpdate) (db.Merch, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.UpdateMerch")
+	defer span.End()
+
+	arg := db.UpdateMerchParams{ID: merchID}
+
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		update.Name = &name
+		arg.Name = sql.NullString{String: name, Valid: true}
+	}
+
+	if update.Price != nil {
+		arg.Price = sql.NullInt32{Int32: *update.Price, Valid: true}
+	}
+
+	if update.Active != nil {
+		arg.Active = sql.NullBool{Bool: *update.Active, Valid: true}
+	}
+
+	if err := validateMerch(update.Name, update.Price); err != nil {
+		return db.Merch{}, err
+	}
+
+	merch, err := s.repo.UpdateMerch(ctx, actorID, arg)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return db.Merch{}, ErrMerchNotFound
+	case errors.Is(err, repository.ErrMerchExists):
+		return db.Merch{}, ErrMerchNameTaken
+	case err != nil:
+		return db.Merch{}, fmt.Errorf("failed to update merch: %w", err)
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"merch_id": merch.ID,
+		"name":     merch.Name,
+		"price":    merch.Price,
+		"active":   merch.Active,
+		"actor_id": actorID,
+	}).Info("Merch updated")
+
+	return merch, nil
+}
+
+// DeactivateMerch - снятие товара с продажи администратором actorID.
+func (s *CoinService) DeactivateMerch(ctx context.Context, actorID, merchID int32) (db.Merch, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.DeactivateMerch")
+	defer span.End()
+
+	active := false
+	return s.UpdateMerch(ctx, actorID, merchID, MerchUpdate{Active: &active})
+}
+
+// GetMerchAudit - журнал изменений товара.
+func (s *CoinService) GetMerchAudit(ctx context.Context, merchID int32) ([]db.GetMerchAuditRow, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetMerchAudit")
+	defer span.End()
+
+	audit, err := s.repo.GetMerchAudit(ctx, merchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merch audit: %w", err)
+	}
+
+	return audit, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import "errors"
+
+// Причины неудачных покупок в метриках.
+const (
+	PurchaseFailureInsufficientFunds = "insufficient_funds"
+	PurchaseFailureSoldOut           = "sold_out"
+	PurchaseFailureNotFound          = "not_found"
+	PurchaseFailureVariantRequired   = "variant_required"
+	PurchaseFailureCartEmpty         = "cart_empty"
+	PurchaseFailureCartStale         = "cart_stale"
+	PurchaseFailureOther             = "other"
+)
+
+// Metrics - учет бизнес-операций для метрик.
+type Metrics interface {
+	// TransferCompleted - проведен перевод amount монет.
+	TransferCompleted(amount int32)
+	// PurchaseCompleted - куплено quantity единиц товара merchID.
+	PurchaseCompleted(merchID, quantity int32)
+	// PurchaseFailed - покупка не прошла по причине reason, одной из PurchaseFailure*.
+	PurchaseFailed(reason string)
+}
+
+// noopMetrics - метрики по умолчанию: ничего не учитывают.
+type noopMetrics struct{}
+
+func (noopMetrics) TransferCompleted(int32)        {}
+func (noopMetrics) PurchaseCompleted(int32, int32) {}
+func (noopMetrics) PurchaseFailed(string)          {}
+
+// purchaseFailureReason - причина неудачной покупки по ошибке сервиса.
+func purchaseFailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrInsufficientBalance):
+		return PurchaseFailureInsufficientFunds
+	case errors.Is(err, ErrSoldOut):
+		return PurchaseFailureSoldOut
+	case errors.Is(err, ErrMerchNotFound), errors.Is(err, ErrVariantNotFound), errors.Is(err, ErrReservationNotFound):
+		return PurchaseFailureNotFound
+	case errors.Is(err, ErrVariantRequired):
+		return PurchaseFailureVariantRequired
+	case errors.Is(err, ErrCartEmpty):
+		return PurchaseFailureCartEmpty
+	case errors.Is(err, ErrCartStale):
+		return PurchaseFailureCartStale
+	default:
+		return PurchaseFailureOther
+	}
+}
+
+// recordPurchase - учет покупки quantity единиц товара merchID в метриках.
+func (s *CoinService) recordPurchase(merchID, quantity int32, err error) {
+	if err != nil {
+		s.metrics.PurchaseFailed(purchaseFailureReason(err))
+		return
+	}
+
+	s.metrics.PurchaseCompleted(merchID, quantity)
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"unicode/utf8"
+
+	"avito_coin/internal/db"
+	"avito_coin/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrOrderNotFound - заказа нет или он принадлежит другому пользователю.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrInvalidOrderStatus - такого статуса заказа нет.
+	ErrInvalidOrderStatus = errors.New("invalid order status")
+	// ErrInvalidOrder - слишком длинные место получения, комментарий или заметка к статусу.
+	ErrInvalidOrder = errors.New("invalid order details")
+	// ErrOrderTransitionNotAllowed - заказ нельзя перевести из текущего статуса в запрошенный.
+	ErrOrderTransitionNotAllowed = errors.New("order status transition is not allowed")
+	// ErrOrderStatusChanged - статус заказа изменился, пока запрос обрабатывался.
+	ErrOrderStatusChanged = errors.New("order status has changed, reload the order and try again")
+	// ErrOrderNotCancellable - пользователь может отменить только еще не одобренный заказ.
+	ErrOrderNotCancellable = errors.New("order can no longer be cancelled")
+)
+
+const (
+	maxPickupLocationLength = 128
+	maxOrderNotesLength     = 500
+)
+
+// orderTransitions - допустимые переходы между статусами заказа.
+// Выданный и отмененный заказы больше не меняются.
+var orderTransitions = map[string][]string{
+	repository.OrderStatusPlaced:         {repository.OrderStatusApproved, repository.OrderStatusCancelled},
+	repository.OrderStatusApproved:       {repository.OrderStatusReadyForPickup, repository.OrderStatusCancelled},
+	repository.OrderStatusReadyForPickup: {repository.OrderStatusDelivered, repository.OrderStatusCancelled},
+	repository.OrderStatusDelivered:      nil,
+	repository.OrderStatusCancelled:      nil,
+}
+
+// CheckoutInput - необязательные место получения и комментарий к заказу.
+type CheckoutInput struct {
+	PickupLocation *string
+	Notes          *string
+}
+
+// OrderStatusUpdate - новый статус заказа; место получения меняется, если задано.
+type OrderStatusUpdate struct {
+	Status         string
+	PickupLocation *string
+	Note           *string
+}
+
+// OrderDetails - заказ с составом и историей статусов.
+type OrderDetails struct {
+	Order   db.Order
+	Items   []db.GetOrderItemsRow
+	History []db.GetOrderEventsRow
+}
+
+// validateLength - проверка длины необязательного поля заказа.
+func validateLength(field string, value *string, limit int) error {
+	if value != nil && utf8.RuneCountInString(*value) > limit {
+		return fmt.Errorf("%w: %s must be at most %d characters", ErrInvalidOrder, field, limit)
+	}
+
+	return nil
+}
+
+// validate - проверка места получения и комментария.
+func (c CheckoutInput) validate() error {
+	if err := validateLength("pickup location", c.PickupLocation, maxPickupLocationLength); err != nil {
+		return err
+	}
+
+	return validateLength("notes", c.Notes, maxOrderNotesLength)
+}
+
+// ValidOrderStatus - существует ли статус заказа.
+func ValidOrderStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return ok
+}
+
+// orderError - перевод ошибок репозитория при работе с заказами в ошибки сервиса.
+func orderError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrOrderNotFound
+	case errors.Is(err, repository.ErrOrderStatusChanged):
+		return ErrOrderStatusChanged
+	default:
+		return err
+	}
+}
+
+// ListUserOrders - заказы пользователя, новые первыми.
+func (s *CoinService) ListUserOrders(ctx context.Context, userID int32) ([]db.Order, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.ListUserOrders")
+	defer span.End()
+
+	orders, err := s.repo.ListUserOrders(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// GetUserOrder - заказ пользователя с составом и историей. Чужой заказ считается отсутствующим.
+func (s *CoinService) GetUserOrder(ctx context.Context, userID, orderID int32) (OrderDetails, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetUserOrder")
+	defer span.End()
+
+	details, err := s.GetOrder(ctx, orderID)
+	if err != nil {
+		return OrderDetails{}, err
+	}
+
+	if details.Order.UserID != userID {
+		return OrderDetails{}, ErrOrderNotFound
+	}
+
+	return details, nil
+}
+
+// ListOrders - заказы для выдачи; если status пустой, возвращаются все заказы.
+func (s *CoinService) ListOrders(ctx context.Context, status string) ([]db.Order, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.ListOrders")
+	defer span.End()
+
+	if status != "" && !ValidOrderStatus(status) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidOrderStatus, status)
+	}
+
+	orders, err := s.repo.ListOrders(ctx, sql.NullString{String: status, Valid: status != ""})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// GetOrder - заказ с составом и историей статусов.
+func (s *CoinService) GetOrder(ctx context.Context, orderID int32) (OrderDetails, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetOrder")
+	defer span.End()
+
+	order, err := s.repo.GetOrder(ctx, orderID)
+	if err != nil {
+		return OrderDetails{}, orderError(err)
+	}
+
+	items, err := s.repo.GetOrderItems(ctx, orderID)
+	if err != nil {
+		return OrderDetails{}, fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	history, err := s.repo.GetOrderEvents(ctx, orderID)
+	if err != nil {
+		return OrderDetails{}, fmt.Errorf("failed to get order history: %w", err)
+	}
+
+	return OrderDetails{Order: order, Items: items, History: history}, nil
+}
+
+// UpdateOrderStatus - перевод заказа администратором магазина actorID в новый статус.
+// При отмене монеты возвращаются покупателю, а товары - на склад.
+func (s *CoinService) UpdateOrderStatus(ctx context.Context, actorID, orderID int32, update OrderStatusUpdate) (OrderDetails, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.UpdateOrderStatus")
+	defer span.End()
+
+	if !ValidOrderStatus(update.Status) {
+		return OrderDetails{}, fmt.Errorf("%w: %q", ErrInvalidOrderStatus, update.Status)
+	}
+
+	if err := validateLength("pickup location", update.PickupLocation, maxPickupLocationLength); err != nil {
+		return OrderDetails{}, err
+	}
+
+	if err := validateLength("note", update.Note, maxOrderNotesLength); err != nil {
+		return OrderDetails{}, err
+	}
+
+	order, err := s.repo.GetOrder(ctx, orderID)
+	if err != nil {
+		return OrderDetails{}, orderError(err)
+	}
+
+	if !slices.Contains(orderTransitions[order.Status], update.Status) {
+		return OrderDetails{}, fmt.Errorf("%w: from %q to %q", ErrOrderTransitionNotAllowed, order.Status, update.Status)
+	}
+
+	return s.changeOrderStatus(ctx, actorID, order, update)
+}
+
+// CancelOrder - отмена пользователем своего заказа, пока его не одобрили.
+func (s *CoinService) CancelOrder(ctx context.Context, userID, orderID int32) (OrderDetails, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.CancelOrder")
+	defer span.End()
+
+	order, err := s.repo.GetOrder(ctx, orderID)
+	if err != nil {
+		return OrderDetails{}, orderError(err)
+	}
+
+	if order.UserID != userID {
+		return OrderDetails{}, ErrOrderNotFound
+	}
+
+	if order.Status != repository.OrderStatusPlaced {
+		return OrderDetails{}, fmt.Errorf("%w: order is %q", ErrOrderNotCancellable, order.Status)
+	}
+
+	return s.changeOrderStatus(ctx, userID, order, OrderStatusUpdate{Status: repository.OrderStatusCancelled})
+}
+
+// changeOrderStatus - смена статуса заказа, если он не изменился после проверки перехода.
+func (s *CoinService) changeOrderStatus(ctx context.Context, actorID int32, order db.Order, update OrderStatusUpdate) (OrderDetails, error) {
+	updated, err := s.repo.UpdateOrderStatus(ctx, actorID, db.UpdateOrderStatusParams{
+		Status:         update.Status,
+		PickupLocation: nullString(update.PickupLocation),
+		ID:             order.ID,
+		FromStatus:     order.Status,
+	}, nullString(update.Note))
+	if err != nil {
+		return OrderDetails{}, orderError(err)
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"order_id": order.ID,
+		"actor_id": actorID,
+		"from":     order.Status,
+		"to":       updated.Status,
+	}).Info("Order status changed")
+
+	if updated.Status == repository.OrderStatusCancelled {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"order_id": order.ID,
+			"user_id":  order.UserID,
+			"amount":   order.Total,
+		}).Info("Order refunded")
+	}
+
+	return s.GetOrder(ctx, order.ID)
+}
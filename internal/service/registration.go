@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrInvalidUsername - имя пользователя не соответствует политике.
+	ErrInvalidUsername = errors.New("invalid username")
+	// ErrInvalidPassword - пароль не соответствует политике.
+	ErrInvalidPassword = errors.New("invalid password")
+	// ErrRegistrationForbidden - регистрация требует кода приглашения или адреса в разрешенном домене.
+	ErrRegistrationForbidden = errors.New("registration is not allowed")
+	// ErrUsernameTaken - имя пользователя уже занято.
+	ErrUsernameTaken = errors.New("username is already taken")
+)
+
+// usernamePattern - латинские буквы, цифры, точка, дефис и подчеркивание; необязательный домен после @.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*(@[A-Za-z0-9][A-Za-z0-9.-]*)?$`)
+
+// DefaultReservedUsernames - имена, которые нельзя занять при регистрации.
+var DefaultReservedUsernames = []string{
+	"admin", "administrator", "root", "system", "support", "shop", "issuance", "api", "null",
+}
+
+// RegistrationPolicy - правила регистрации новых пользователей.
+type RegistrationPolicy struct {
+	// MinUsernameLength и MaxUsernameLength - допустимая длина имени в символах.
+	MinUsernameLength int
+	MaxUsernameLength int
+	// MinPasswordLength - минимальная длина пароля в символах.
+	MinPasswordLength int
+	// ReservedUsernames - запрещенные имена (без учета регистра и домена).
+	ReservedUsernames []string
+	// InviteCodes - если задан, для регистрации нужен один из этих кодов.
+	InviteCodes []string
+	// AllowedDomains - если задан, имя пользователя должно быть адресом в одном из этих доменов.
+	AllowedDomains []string
+}
+
+// DefaultRegistrationPolicy - политика регистрации по умолчанию: без приглашений и ограничения доменов.
+func DefaultRegistrationPolicy() RegistrationPolicy {
+	return RegistrationPolicy{
+		MinUsernameLength: 3,
+		MaxUsernameLength: 32,
+		MinPasswordLength: 8,
+		ReservedUsernames: DefaultReservedUsernames,
+	}
+}
+
+// validateUsername - проверка имени на соответствие политике.
+func (p RegistrationPolicy) validateUsername(username string) error {
+	length := utf8.RuneCountInString(username)
+	if length < p.MinUsernameLength || length > p.MaxUsernameLength {
+		return fmt.Errorf("%w: length must be between %d and %d characters",
+			ErrInvalidUsername, p.MinUsernameLength, p.MaxUsernameLength)
+	}
+
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("%w: only latin letters, digits, '.', '_', '-' and an optional @domain are allowed", ErrInvalidUsername)
+	}
+
+	local, _, _ := strings.Cut(username, "@")
+	if containsFold(p.ReservedUsernames, local) {
+		return fmt.Errorf("%w: name %q is reserved", ErrInvalidUsername, local)
+	}
+
+	return nil
+}
+
+// checkGate - проверка кода приглашения и домена.
+func (p RegistrationPolicy) checkGate(username, inviteCode string) error {
+	if len(p.InviteCodes) > 0 && !p.validInviteCode(inviteCode) {
+		return fmt.Errorf("%w: valid invite code is required", ErrRegistrationForbidden)
+	}
+
+	if len(p.AllowedDomains) > 0 {
+		_, domain, found := strings.Cut(username, "@")
+		if !found || !containsFold(p.AllowedDomains, domain) {
+			return fmt.Errorf("%w: username must be an address in one of the allowed domains", ErrRegistrationForbidden)
+		}
+	}
+
+	return nil
+}
+
+// validInviteCode - сравнение кода с допустимыми за постоянное время.
+func (p RegistrationPolicy) validInviteCode(inviteCode string) bool {
+	valid := false
+
+	for _, code := range p.InviteCodes {
+		if subtle.ConstantTimeCompare([]byte(code), []byte(inviteCode)) == 1 {
+			valid = true
+		}
+	}
+
+	return valid
+}
+
+// containsFold - есть ли строка в списке без учета регистра.
+func containsFold(list []string, s string) bool {
+	return slices.ContainsFunc(list, func(item string) bool {
+		return strings.EqualFold(item, s)
+	})
+}
+
+// Register - регистрация нового пользователя по правилам политики регистрации.
+func (s *CoinService) Register(ctx context.Context, username, password, inviteCode string) (int32, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.Register")
+	defer span.End()
+
+	if err := s.registration.validateUsername(username); err != nil {
+		return 0, err
+	}
+
+	if utf8.RuneCountInString(password) < s.registration.MinPasswordLength {
+		return 0, fmt.Errorf("%w: must be at least %d characters long", ErrInvalidPassword, s.registration.MinPasswordLength)
+	}
+
+	if err := s.registration.checkGate(username, inviteCode); err != nil {
+		return 0, err
+	}
+
+	userID, err := s.CreateUser(ctx, username, password)
+	if errors.Is(err, ErrUsernameTaken) {
+		return 0, err
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"avito_coin/internal/db"
+	"avito_coin/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrTransferNotFound - перевода нет.
+	ErrTransferNotFound = errors.New("transfer not found")
+	// ErrPurchaseNotFound - покупки нет.
+	ErrPurchaseNotFound = errors.New("purchase not found")
+	// ErrInvalidReversal - не указана причина или сумма сторно неположительная.
+	ErrInvalidReversal = errors.New("invalid reversal")
+	// ErrAlreadyReversed - операция уже сторнирована полностью.
+	ErrAlreadyReversed = errors.New("operation is already fully reversed")
+	// ErrReversalExceedsAmount - сумма сторно больше несторнированного остатка операции.
+	ErrReversalExceedsAmount = errors.New("reversal exceeds the remaining amount")
+	// ErrPurchaseNotRefundable - покупка входит в невыданный заказ (его нужно отменить) или ее цена неизвестна.
+	ErrPurchaseNotRefundable = errors.New("purchase cannot be refunded, cancel its order instead")
+)
+
+const maxReversalReasonLength = 500
+
+// ReversalInput - сумма и причина сторно. Без Amount сторнируется весь остаток операции.
+type ReversalInput struct {
+	Amount *int32
+	Reason string
+}
+
+// validate - проверка суммы и причины сторно.
+func (r ReversalInput) validate() error {
+	reason := strings.TrimSpace(r.Reason)
+	if reason == "" || utf8.RuneCountInString(reason) > maxReversalReasonLength {
+		return fmt.Errorf("%w: reason must be 1-%d characters long", ErrInvalidReversal, maxReversalReasonLength)
+	}
+
+	if r.Amount != nil && *r.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidReversal)
+	}
+
+	return nil
+}
+
+// reversalError - перевод ошибок репозитория при сторно в ошибки сервиса.
+func reversalError(err error, notFound error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return notFound
+	case errors.Is(err, repository.ErrAlreadyReversed):
+		return ErrAlreadyReversed
+	case errors.Is(err, repository.ErrReversalExceedsAmount):
+		return ErrReversalExceedsAmount
+	case errors.Is(err, repository.ErrPurchaseNotRefundable):
+		return ErrPurchaseNotRefundable
+	case errors.Is(err, repository.ErrInsufficientBalance):
+		return ErrInsufficientBalance
+	default:
+		return err
+	}
+}
+
+// ReverseTransfer - сторно перевода администратором actorID: монеты возвращаются отправителю
+// со счета получателя, если у него их достаточно.
+func (s *CoinService) ReverseTransfer(ctx context.Context, actorID, transactionID int32, input ReversalInput) (db.Reversal, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.ReverseTransfer")
+	defer span.End()
+
+	if err := input.validate(); err != nil {
+		return db.Reversal{}, err
+	}
+
+	reversal, err := s.repo.ReverseTransfer(ctx, actorID, transactionID, nullInt32(input.Amount), strings.TrimSpace(input.Reason))
+	if err != nil {
+		return db.Reversal{}, reversalError(err, ErrTransferNotFound)
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"transaction_id": transactionID,
+		"reversal_id":    reversal.ID,
+		"actor_id":       actorID,
+		"amount":         reversal.Amount,
+	}).Info("Transfer reversed")
+
+	return reversal, nil
+}
+
+// RefundPurchase - возврат монет за покупку администратором actorID. Полностью возвращенная покупка
+// пропадает из инвентаря, а товар возвращается на склад.
+func (s *CoinService) RefundPurchase(ctx context.Context, actorID, purchaseID int32, input ReversalInput) (db.Reversal, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.RefundPurchase")
+	defer span.End()
+
+	if err := input.validate(); err != nil {
+		return db.Reversal{}, err
+	}
+
+	reversal, err := s.repo.RefundPurchase(ctx, actorID, purchaseID, nullInt32(input.Amount), strings.TrimSpace(input.Reason))
+	if err != nil {
+		return db.Reversal{}, reversalError(err, ErrPurchaseNotFound)
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"purchase_id": purchaseID,
+		"reversal_id": reversal.ID,
+		"actor_id":    actorID,
+		"amount":      reversal.Amount,
+	}).Info("Purchase refunded")
+
+	return reversal, nil
+}
+
+// GetUserReversals - последние InfoHistoryLimit сторно и возвратов, которые изменили баланс пользователя.
+func (s *CoinService) GetUserReversals(ctx context.Context, userID int32) ([]db.GetUserReversalsRow, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetUserReversals")
+	defer span.End()
+
+	reversals, err := s.repo.GetUserReversals(ctx, userID, InfoHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reversals: %w", err)
+	}
+
+	return reversals, nil
+}
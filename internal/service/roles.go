@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"avito_coin/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Permission - право на действие.
+type Permission string
+
+// Права.
+const (
+	PermTransferCoins Permission = "coins:transfer"
+	PermBuyMerch      Permission = "merch:buy"
+	PermViewInfo      Permission = "info:read"
+	PermViewUsers     Permission = "users:read"
+	PermManageMerch   Permission = "merch:manage"
+	PermAdjustBalance Permission = "balance:adjust"
+	PermAuditLedger   Permission = "ledger:audit"
+	PermManageRoles   Permission = "roles:manage"
+	PermFulfillOrders Permission = "orders:fulfill"
+	PermIssueRefunds  Permission = "refunds:issue"
+)
+
+// employeePermissions - права любого сотрудника.
+var employeePermissions = []Permission{PermTransferCoins, PermBuyMerch, PermViewInfo}
+
+// rolePermissions - права каждой роли. Административные роли не включают права сотрудника:
+// пользователь с отозванной ролью employee не может тратить монеты, даже если он администратор.
+var rolePermissions = map[string][]Permission{
+	repository.RoleEmployee:     employeePermissions,
+	repository.RoleManager:      {PermViewUsers},
+	repository.RoleShopAdmin:    {PermManageMerch, PermFulfillOrders},
+	repository.RoleFinanceAdmin: {PermViewUsers, PermAdjustBalance, PermAuditLedger, PermIssueRefunds},
+	repository.RoleAdmin: {
+		PermViewUsers, PermManageMerch, PermAdjustBalance, PermAuditLedger, PermManageRoles, PermFulfillOrders,
+		PermIssueRefunds,
+	},
+}
+
+var (
+	// ErrUnknownRole - такой роли нет.
+	ErrUnknownRole = errors.New("unknown role")
+	// ErrRevokeOwnAdmin - администратор не может отозвать роль admin у самого себя.
+	ErrRevokeOwnAdmin = errors.New("cannot revoke own admin role")
+)
+
+// ValidRole - существует ли роль.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission - дает ли хотя бы одна из ролей право.
+func HasPermission(roles []string, permission Permission) bool {
+	for _, role := range roles {
+		if slices.Contains(rolePermissions[role], permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetUserRoles - роли пользователя для выпуска токена.
+func (s *CoinService) GetUserRoles(ctx context.Context, userID int32) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetUserRoles")
+	defer span.End()
+
+	roles, err := s.repo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// GetUserRolesByName - роли пользователя по имени.
+func (s *CoinService) GetUserRolesByName(ctx context.Context, username string) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetUserRolesByName")
+	defer span.End()
+
+	userID, err := s.userID(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetUserRoles(ctx, userID)
+}
+
+// GrantRole - выдача роли пользователю администратором actorID.
+func (s *CoinService) GrantRole(ctx context.Context, actorID int32, username, role string) error {
+	ctx, span := tracer.Start(ctx, "CoinService.GrantRole")
+	defer span.End()
+
+	if !ValidRole(role) {
+		return fmt.Errorf("%w: %q", ErrUnknownRole, role)
+	}
+
+	userID, err := s.userID(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	granted, err := s.repo.GrantRole(ctx, userID, role, actorID)
+	if err != nil {
+		return fmt.Errorf("failed to grant role: %w", err)
+	}
+
+	if granted {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"user_id":  userID,
+			"role":     role,
+			"actor_id": actorID,
+		}).Info("Role granted")
+	}
+
+	return nil
+}
+
+// RevokeRole - отзыв роли у пользователя администратором actorID.
+func (s *CoinService) RevokeRole(ctx context.Context, actorID int32, username, role string) error {
+	ctx, span := tracer.Start(ctx, "CoinService.RevokeRole")
+	defer span.End()
+
+	if !ValidRole(role) {
+		return fmt.Errorf("%w: %q", ErrUnknownRole, role)
+	}
+
+	userID, err := s.userID(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	// Иначе можно остаться без администраторов
+	if userID == actorID && role == repository.RoleAdmin {
+		return ErrRevokeOwnAdmin
+	}
+
+	revoked, err := s.repo.RevokeRole(ctx, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to revoke role: %w", err)
+	}
+
+	if revoked {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"user_id":  userID,
+			"role":     role,
+			"actor_id": actorID,
+		}).Info("Role revoked")
+	}
+
+	return nil
+}
+
+// SeedRoles - выдача ролей из конфигурации при запуске. Пользователи, которых еще нет, пропускаются.
+func (s *CoinService) SeedRoles(ctx context.Context, seed map[string][]string) error {
+	ctx, span := tracer.Start(ctx, "CoinService.SeedRoles")
+	defer span.End()
+
+	for username, roles := range seed {
+		for _, role := range roles {
+			err := s.GrantRole(ctx, 0, username, role)
+
+			switch {
+			case errors.Is(err, ErrUserNotFound):
+				logrus.WithContext(ctx).WithField("username", username).Warn("User from roles seed does not exist yet")
+			case err != nil:
+				return fmt.Errorf("failed to seed role %q for %q: %w", role, username, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ParseRolesSeed - разбор списка ролей вида "alice=admin,shop-admin;bob=finance-admin".
+func ParseRolesSeed(value string) (map[string][]string, error) {
+	seed := make(map[string][]string)
+
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		username, list, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(username) == "" {
+			return nil, fmt.Errorf("invalid roles seed entry %q", entry)
+		}
+
+		for _, role := range strings.Split(list, ",") {
+			role = strings.TrimSpace(role)
+			if !ValidRole(role) {
+				return nil, fmt.Errorf("%w: %q", ErrUnknownRole, role)
+			}
+
+			username = strings.TrimSpace(username)
+			seed[username] = append(seed[username], role)
+		}
+	}
+
+	return seed, nil
+}
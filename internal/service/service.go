@@ -2,51 +2,189 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"avito_coin/api"
 	"avito_coin/internal/db"
+	"avito_coin/internal/password"
 	"avito_coin/internal/repository"
+	"go.opentelemetry.io/otel"
 )
 
+// tracer - спаны методов сервиса; экспорт настраивается глобальным провайдером трейсов.
+var tracer = otel.Tracer("avito_coin/internal/service")
+
 // CoinService - сервис для работы с монетками и мерчем.
 type CoinService struct {
 	repo repository.Repository
+	// idempotencyTTL - сколько хранится ответ на запрос с ключом идемпотентности.
+	idempotencyTTL time.Duration
+	// idempotencyLease - сколько ключ без ответа считается занятым выполняющимся запросом.
+	idempotencyLease time.Duration
+	// passwords - хеширование и проверка паролей.
+	passwords *password.Hasher
+	// refreshTokenTTL - время жизни refresh-токена.
+	refreshTokenTTL time.Duration
+	// registration - правила регистрации новых пользователей.
+	registration RegistrationPolicy
+	// reservationTTL - сколько держится резерв товара.
+	reservationTTL time.Duration
+	// lowStockThreshold - остаток, начиная с которого вызывается lowStockNotifier.
+	lowStockThreshold int32
+	// lowStockNotifier - уведомление о заканчивающемся товаре.
+	lowStockNotifier LowStockNotifier
+	// limits - лимиты переводов.
+	limits LimitPolicy
+	// metrics - учет переводов и покупок.
+	metrics Metrics
+}
+
+// Option - настройка сервиса.
+type Option func(*CoinService)
+
+// WithIdempotencyTTL - время хранения ключей идемпотентности.
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(s *CoinService) {
+		s.idempotencyTTL = ttl
+	}
+}
+
+// WithIdempotencyLease - сколько ключ без ответа считается занятым выполняющимся запросом.
+// Должно быть заметно больше времени выполнения запроса, иначе повтор выполнит операцию второй раз.
+func WithIdempotencyLease(lease time.Duration) Option {
+	return func(s *CoinService) {
+		s.idempotencyLease = lease
+	}
+}
+
+// WithPasswordHasher - алгоритм хеширования паролей.
+func WithPasswordHasher(hasher *password.Hasher) Option {
+	return func(s *CoinService) {
+		s.passwords = hasher
+	}
+}
+
+// WithRefreshTokenTTL - время жизни refresh-токенов.
+func WithRefreshTokenTTL(ttl time.Duration) Option {
+	return func(s *CoinService) {
+		s.refreshTokenTTL = ttl
+	}
+}
+
+// WithRegistrationPolicy - правила регистрации новых пользователей.
+func WithRegistrationPolicy(policy RegistrationPolicy) Option {
+	return func(s *CoinService) {
+		s.registration = policy
+	}
+}
+
+// WithReservationTTL - сколько держится резерв товара.
+func WithReservationTTL(ttl time.Duration) Option {
+	return func(s *CoinService) {
+		s.reservationTTL = ttl
+	}
+}
+
+// WithLowStockAlert - порог остатка и уведомление о заканчивающемся товаре.
+// Если notifier равен nil, предупреждение пишется в лог.
+func WithLowStockAlert(threshold int32, notifier LowStockNotifier) Option {
+	return func(s *CoinService) {
+		s.lowStockThreshold = threshold
+
+		if notifier != nil {
+			s.lowStockNotifier = notifier
+		}
+	}
+}
+
+// WithTransferLimits - глобальные лимиты переводов и лимиты ролей.
+func WithTransferLimits(policy LimitPolicy) Option {
+	return func(s *CoinService) {
+		s.limits = policy
+	}
+}
+
+// WithMetrics - учет переводов и покупок в метриках.
+func WithMetrics(metrics Metrics) Option {
+	return func(s *CoinService) {
+		s.metrics = metrics
+	}
 }
 
 // NewCoinService - функция для создания нового сервиса.
-func NewCoinService(repo repository.Repository) *CoinService {
-	return &CoinService{
-		repo: repo,
+func NewCoinService(repo repository.Repository, opts ...Option) *CoinService {
+	s := &CoinService{
+		repo:             repo,
+		idempotencyTTL:   DefaultIdempotencyTTL,
+		idempotencyLease: DefaultIdempotencyLease,
+		passwords:        password.NewHasher(password.DefaultArgon2id()),
+		refreshTokenTTL:  DefaultRefreshTokenTTL,
+		registration:     DefaultRegistrationPolicy(),
+		reservationTTL:   DefaultReservationTTL,
+
+		lowStockThreshold: DefaultLowStockThreshold,
+		lowStockNotifier:  logLowStock,
+		limits:            DefaultLimitPolicy(),
+		metrics:           noopMetrics{},
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
-// CreateUser - создание пользователя.
+// CreateUser - создание пользователя, пароль сохраняется в виде хеша. Если имя занято, возвращается ErrUsernameTaken.
 func (s *CoinService) CreateUser(ctx context.Context, username, password string) (int32, error) {
-	return s.repo.CreateUser(ctx, username, password)
+	ctx, span := tracer.Start(ctx, "CoinService.CreateUser")
+	defer span.End()
+
+	hash, err := s.passwords.Hash(password)
+	if err != nil {
+		return 0, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	userID, err := s.repo.CreateUser(ctx, username, hash)
+	if errors.Is(err, repository.ErrUserExists) {
+		return 0, ErrUsernameTaken
+	}
+
+	return userID, err
 }
 
 // CreateMerch - создание мерча.
 func (s *CoinService) CreateMerch(ctx context.Context, name string, price int32) error {
+	ctx, span := tracer.Start(ctx, "CoinService.CreateMerch")
+	defer span.End()
+
 	return s.repo.CreateMerch(ctx, name, price)
 }
 
-// BuyMerch - покупка мерча пользователем.
-func (s *CoinService) BuyMerch(ctx context.Context, userID, merchID int32) error {
+// BuyMerch - покупка мерча (или его варианта sku, если он не пустой) пользователем.
+func (s *CoinService) BuyMerch(ctx context.Context, userID, merchID int32, sku string) (err error) {
+	ctx, span := tracer.Start(ctx, "CoinService.BuyMerch")
+	defer span.End()
+
+	defer func() { s.recordPurchase(merchID, 1, err) }()
+
 	// Проверяем, существует ли пользователь и мерч.
 	balance, err := s.repo.GetUserBalance(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("user not found: %w", err)
 	}
 
-	price, err := s.repo.GetMerchPrice(ctx, merchID)
+	price, err := s.purchasePrice(ctx, merchID, sku)
 	if err != nil {
-		return fmt.Errorf("merch not found: %w", err)
+		return err
 	}
 
 	// Проверяем, достаточно ли монет для покупки.
 	if balance < price {
-		return fmt.Errorf("insufficient balance for purchase")
+		return ErrInsufficientBalance
 	}
 
 	// Проверяем, что баланс не станет отрицательным после покупки.
@@ -55,19 +193,71 @@ func (s *CoinService) BuyMerch(ctx context.Context, userID, merchID int32) error
 		return fmt.Errorf("balance cannot be negative")
 	}
 
-	// Выполняем покупку через репозиторий.
-	return s.repo.BuyMerch(ctx, userID, merchID)
+	// Выполняем покупку через репозиторий; товар могли снять с продажи или раскупить после проверки цены.
+	if err := s.repo.BuyMerch(ctx, userID, merchID, sku); err != nil {
+		return merchError(err)
+	}
+
+	s.checkLowStock(ctx, merchID, sku)
+
+	return nil
 }
 
-// TransferCoins - перевод монет от одного пользователя к другому.
-func (s *CoinService) TransferCoins(ctx context.Context, fromUserID int32, toUser string, amount int32) error {
+// purchasePrice - цена товара или его варианта sku в продаже.
+func (s *CoinService) purchasePrice(ctx context.Context, merchID int32, sku string) (int32, error) {
+	if sku != "" {
+		price, err := s.repo.GetMerchVariantPrice(ctx, merchID, sku)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrVariantNotFound
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("merch variant not found: %w", err)
+		}
+
+		return price, nil
+	}
+
+	price, err := s.repo.GetMerchPrice(ctx, merchID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrMerchNotFound
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("merch not found: %w", err)
+	}
+
+	return price, nil
+}
+
+// TransferCoins - перевод монет от одного пользователя к другому с необязательными сообщением и тегами.
+func (s *CoinService) TransferCoins(ctx context.Context, fromUserID int32, toUser string, amount int32, note TransferNote) error {
+	ctx, span := tracer.Start(ctx, "CoinService.TransferCoins")
+	defer span.End()
+
+	// Проверяем сообщение и теги до обращения к базе.
+	transferNote, err := note.normalize()
+	if err != nil {
+		return err
+	}
+
 	toUserData, err := s.repo.UserExists(ctx, toUser)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, toUser)
+	}
+
 	if err != nil {
-		return fmt.Errorf("user not found: %w", err)
+		return fmt.Errorf("failed to find receiver: %w", err)
 	}
 
 	if fromUserID == toUserData.ID {
-		return fmt.Errorf("sender and receiver cannot be the same")
+		return ErrSelfTransfer
+	}
+
+	// Проверяем сумму перевода; лимиты за день и неделю проверит репозиторий при переводе.
+	limitCheck, err := s.transferCheck(ctx, fromUserID, toUserData.ID, amount)
+	if err != nil {
+		return err
 	}
 
 	// Проверяем, существуют ли пользователи.
@@ -83,7 +273,7 @@ func (s *CoinService) TransferCoins(ctx context.Context, fromUserID int32, toUse
 
 	// Проверяем, достаточно ли монет для перевода.
 	if senderBalance < amount {
-		return fmt.Errorf("insufficient balance to transfer")
+		return ErrInsufficientBalance
 	}
 
 	// Проверяем, что баланс отправителя не станет отрицательным после перевода.
@@ -92,14 +282,32 @@ func (s *CoinService) TransferCoins(ctx context.Context, fromUserID int32, toUse
 		return fmt.Errorf("sender balance cannot be negative")
 	}
 
-	// Выполняем перевод через репозиторий.
-	return s.repo.TransferCoins(ctx, fromUserID, toUserData.ID, amount)
+	// Выполняем перевод через репозиторий; баланс мог измениться после проверки.
+	err = s.repo.TransferCoins(ctx, fromUserID, toUserData.ID, amount, transferNote, limitCheck)
+	if errors.Is(err, repository.ErrInsufficientBalance) {
+		return ErrInsufficientBalance
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.metrics.TransferCompleted(amount)
+
+	return nil
 }
 
 // GetMerchPrice - получение цены мерча.
 func (s *CoinService) GetMerchPrice(ctx context.Context, id int32) (int32, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetMerchPrice")
+	defer span.End()
+
 	// Проверяем, существует ли пользователь.
 	balance, err := s.repo.GetMerchPrice(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrMerchNotFound
+	}
+
 	if err != nil {
 		return 0, fmt.Errorf("merch not found: %w", err)
 	}
@@ -109,6 +317,9 @@ func (s *CoinService) GetMerchPrice(ctx context.Context, id int32) (int32, error
 
 // GetUserBalance - получение баланса пользователя.
 func (s *CoinService) GetUserBalance(ctx context.Context, userID int32) (*api.InfoResponse, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetUserBalance")
+	defer span.End()
+
 	// Проверяем, существует ли пользователь.
 	balance, err := s.repo.GetUserBalance(ctx, userID)
 	if err != nil {
@@ -120,10 +331,13 @@ func (s *CoinService) GetUserBalance(ctx context.Context, userID int32) (*api.In
 	return &api.InfoResponse{Coins: &resBalance}, err
 }
 
-// GetUserPurchases - получение всех покупок пользователя.
+// GetUserPurchases - получение инвентаря пользователя: количество купленных товаров по вариантам.
 func (s *CoinService) GetUserPurchases(ctx context.Context, userID int32) (*api.InfoResponse, error) {
-	// Получаем покупки из репозитория.
-	purchases, err := s.repo.GetUserPurchases(ctx, userID)
+	ctx, span := tracer.Start(ctx, "CoinService.GetUserPurchases")
+	defer span.End()
+
+	// Получаем инвентарь из репозитория: покупки уже сгруппированы в базе.
+	inventory, err := s.repo.GetUserInventory(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user purchases: %w", err)
 	}
@@ -133,30 +347,34 @@ func (s *CoinService) GetUserPurchases(ctx context.Context, userID int32) (*api.
 		Inventory: &[]struct {
 			Quantity *int    `json:"quantity,omitempty"`
 			Type     *string `json:"type,omitempty"`
+			Variant  *string `json:"variant,omitempty"`
 		}{},
 	}
 
-	// Обрабатываем покупки.
-	itemCounts := make(map[string]int)
-	for _, purchase := range purchases {
-		// Подсчитываем количество каждого типа предмета.
-		itemCounts[purchase.Name]++
-	}
-
 	// Временная переменная для хранения списка предметов в инвентаре.
 	var inventoryList = make([]struct {
 		Quantity *int    `json:"quantity,omitempty"`
 		Type     *string `json:"type,omitempty"`
-	}, 0, len(itemCounts))
+		Variant  *string `json:"variant,omitempty"`
+	}, 0, len(inventory))
+
+	// Обрабатываем предметы инвентаря, варианты показываются отдельно.
+	for _, item := range inventory {
+		quantity := int(item.Quantity)
+
+		var variant *string
+		if item.Variant.Valid {
+			variant = &item.Variant.String
+		}
 
-	// Обрабатываем покупки.
-	for itemType, quantity := range itemCounts {
 		inventoryList = append(inventoryList, struct {
 			Quantity *int    `json:"quantity,omitempty"`
 			Type     *string `json:"type,omitempty"`
+			Variant  *string `json:"variant,omitempty"`
 		}{
 			Quantity: &quantity,
-			Type:     &itemType,
+			Type:     &item.Name,
+			Variant:  variant,
 		})
 	}
 
@@ -166,10 +384,14 @@ func (s *CoinService) GetUserPurchases(ctx context.Context, userID int32) (*api.
 	return infoResponse, nil
 }
 
-// GetTransactions - получение списка транзакций пользователя, сгруппированных по полученным и отправленным монетам.
+// GetTransactions - получение последних InfoHistoryLimit транзакций пользователя,
+// сгруппированных по полученным и отправленным монетам.
 func (s *CoinService) GetTransactions(ctx context.Context, userID int32) (*api.InfoResponse, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetTransactions")
+	defer span.End()
+
 	// Получаем транзакции из репозитория.
-	transactions, err := s.repo.GetTransactions(ctx, userID)
+	transactions, err := s.repo.GetTransactions(ctx, userID, InfoHistoryLimit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -178,52 +400,94 @@ func (s *CoinService) GetTransactions(ctx context.Context, userID int32) (*api.I
 	infoResponse := &api.InfoResponse{
 		CoinHistory: &struct {
 			Received *[]struct {
-				Amount   *int    `json:"amount,omitempty"`
-				FromUser *string `json:"fromUser,omitempty"`
+				Amount    *int       `json:"amount,omitempty"`
+				CreatedAt *time.Time `json:"createdAt,omitempty"`
+				FromUser  *string    `json:"fromUser,omitempty"`
+				Id        *int       `json:"id,omitempty"`
+				Message   *string    `json:"message,omitempty"`
+				Tags      *[]string  `json:"tags,omitempty"`
 			} `json:"received,omitempty"`
-			Sent *[]struct {
-				Amount *int    `json:"amount,omitempty"`
-				ToUser *string `json:"toUser,omitempty"`
+			Reversals *[]api.Reversal `json:"reversals,omitempty"`
+			Sent      *[]struct {
+				Amount    *int       `json:"amount,omitempty"`
+				CreatedAt *time.Time `json:"createdAt,omitempty"`
+				Id        *int       `json:"id,omitempty"`
+				Message   *string    `json:"message,omitempty"`
+				Tags      *[]string  `json:"tags,omitempty"`
+				ToUser    *string    `json:"toUser,omitempty"`
 			} `json:"sent,omitempty"`
 		}{},
 	}
 
 	// Временные переменные для хранения полученных и отправленных транзакций.
 	var received []struct {
-		Amount   *int    `json:"amount,omitempty"`
-		FromUser *string `json:"fromUser,omitempty"`
+		Amount    *int       `json:"amount,omitempty"`
+		CreatedAt *time.Time `json:"createdAt,omitempty"`
+		FromUser  *string    `json:"fromUser,omitempty"`
+		Id        *int       `json:"id,omitempty"`
+		Message   *string    `json:"message,omitempty"`
+		Tags      *[]string  `json:"tags,omitempty"`
 	}
 
 	var sent []struct {
-		Amount *int    `json:"amount,omitempty"`
-		ToUser *string `json:"toUser,omitempty"`
+		Amount    *int       `json:"amount,omitempty"`
+		CreatedAt *time.Time `json:"createdAt,omitempty"`
+		Id        *int       `json:"id,omitempty"`
+		Message   *string    `json:"message,omitempty"`
+		Tags      *[]string  `json:"tags,omitempty"`
+		ToUser    *string    `json:"toUser,omitempty"`
 	}
 
 	// Обрабатываем транзакции.
 	for _, tx := range transactions {
+		amount := int(tx.Amount)
+		id := int(tx.ID)
+
+		var createdAt *time.Time
+		if tx.TransactionTime.Valid {
+			createdAt = &tx.TransactionTime.Time
+		}
+
+		var message *string
+		if tx.Message.Valid {
+			message = &tx.Message.String
+		}
+
+		tags := SplitTags(tx.Tags)
+
 		if tx.ToUser.Int32 == userID {
 			// Это полученные монеты.
-			amount := int(tx.Amount)
-			fromUser := tx.FromUser.Int32
-			fromUserName := fmt.Sprintf("user%d", fromUser) // Здесь можно получить имя пользователя из БД.
 			received = append(received, struct {
-				Amount   *int    `json:"amount,omitempty"`
-				FromUser *string `json:"fromUser,omitempty"`
+				Amount    *int       `json:"amount,omitempty"`
+				CreatedAt *time.Time `json:"createdAt,omitempty"`
+				FromUser  *string    `json:"fromUser,omitempty"`
+				Id        *int       `json:"id,omitempty"`
+				Message   *string    `json:"message,omitempty"`
+				Tags      *[]string  `json:"tags,omitempty"`
 			}{
-				Amount:   &amount,
-				FromUser: &fromUserName,
+				Amount:    &amount,
+				CreatedAt: createdAt,
+				FromUser:  &tx.FromUsername,
+				Id:        &id,
+				Message:   message,
+				Tags:      &tags,
 			})
 		} else if tx.FromUser.Int32 == userID {
 			// Это отправленные монеты.
-			amount := int(tx.Amount)
-			toUser := tx.ToUser.Int32
-			toUserName := fmt.Sprintf("user%d", toUser) // Здесь можно получить имя пользователя из БД.
 			sent = append(sent, struct {
-				Amount *int    `json:"amount,omitempty"`
-				ToUser *string `json:"toUser,omitempty"`
+				Amount    *int       `json:"amount,omitempty"`
+				CreatedAt *time.Time `json:"createdAt,omitempty"`
+				Id        *int       `json:"id,omitempty"`
+				Message   *string    `json:"message,omitempty"`
+				Tags      *[]string  `json:"tags,omitempty"`
+				ToUser    *string    `json:"toUser,omitempty"`
 			}{
-				Amount: &amount,
-				ToUser: &toUserName,
+				Amount:    &amount,
+				CreatedAt: createdAt,
+				Id:        &id,
+				Message:   message,
+				Tags:      &tags,
+				ToUser:    &tx.ToUsername,
 			})
 		}
 	}
@@ -237,6 +501,9 @@ func (s *CoinService) GetTransactions(ctx context.Context, userID int32) (*api.I
 
 // UpdateUserBalance - обновление баланса пользователя.
 func (s *CoinService) UpdateUserBalance(ctx context.Context, userID int32, balance int32) error {
+	ctx, span := tracer.Start(ctx, "CoinService.UpdateUserBalance")
+	defer span.End()
+
 	// Проверяем, существует ли пользователь.
 	_, err := s.repo.GetUserBalance(ctx, userID)
 	if err != nil {
@@ -254,5 +521,8 @@ func (s *CoinService) UpdateUserBalance(ctx context.Context, userID int32, balan
 
 // UserExists - проверка на сущестование пользователя.
 func (s *CoinService) UserExists(ctx context.Context, username string) (db.UserExistsRow, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.UserExists")
+	defer span.End()
+
 	return s.repo.UserExists(ctx, username)
 }
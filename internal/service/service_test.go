@@ -3,25 +3,102 @@ package service_test
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"avito_coin/internal/catalog"
 	"avito_coin/internal/db"
+	"avito_coin/internal/password"
+	"avito_coin/internal/repository"
 	"avito_coin/internal/service"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // MockRepository - мок-репозиторий для тестирования.
 type MockRepository struct {
-	CreateUserFunc        func(ctx context.Context, username, password string) (int32, error)
-	CreateMerchFunc       func(ctx context.Context, name string, price int32) error
-	BuyMerchFunc          func(ctx context.Context, userID, merchID int32) error
-	GetMerchPriceFunc     func(ctx context.Context, merchID int32) (int32, error)
-	TransferCoinsFunc     func(ctx context.Context, fromUser, toUser, amount int32) error
-	GetUserBalanceFunc    func(ctx context.Context, userID int32) (int32, error)
-	GetUserPurchasesFunc  func(ctx context.Context, userID int32) ([]db.GetUserPurchasesRow, error)
-	GetTransactionsFunc   func(ctx context.Context, userID int32) ([]db.GetTransactionsRow, error)
-	UpdateUserBalanceFunc func(ctx context.Context, userID int32, balance int32) error
-	UserExistsFunc        func(ctx context.Context, username string) (db.UserExistsRow, error)
+	CreateUserFunc         func(ctx context.Context, username, password string) (int32, error)
+	CreateMerchFunc        func(ctx context.Context, name string, price int32) error
+	BuyMerchFunc           func(ctx context.Context, userID, merchID int32, sku string) error
+	GetMerchPriceFunc      func(ctx context.Context, merchID int32) (int32, error)
+	TransferCoinsFunc      func(ctx context.Context, fromUser, toUser, amount int32, note repository.TransferNote, check *repository.TransferCheck) error
+	GetUserBalanceFunc     func(ctx context.Context, userID int32) (int32, error)
+	GetUserPurchasesFunc   func(ctx context.Context, userID int32) ([]db.GetUserPurchasesRow, error)
+	GetUserInventoryFunc   func(ctx context.Context, userID int32) ([]db.GetUserInventoryRow, error)
+	GetTransactionsFunc    func(ctx context.Context, userID, limit int32) ([]db.GetTransactionsRow, error)
+	GetUserHistoryFunc     func(ctx context.Context, arg db.GetUserHistoryParams) ([]db.GetUserHistoryRow, error)
+	UpdateUserBalanceFunc  func(ctx context.Context, userID int32, balance int32) error
+	UserExistsFunc         func(ctx context.Context, username string) (db.UserExistsRow, error)
+	UpdateUserPasswordFunc func(ctx context.Context, userID int32, passwordHash string) error
+
+	GetAccountPostingsFunc      func(ctx context.Context, userID int32) ([]db.GetAccountPostingsRow, error)
+	GetLedgerTotalsFunc         func(ctx context.Context) ([]db.GetLedgerTotalsRow, error)
+	GetUserBalancesTotalFunc    func(ctx context.Context) (int64, error)
+	GetUnbalancedEntriesFunc    func(ctx context.Context) ([]db.GetUnbalancedEntriesRow, error)
+	GetAccountDiscrepanciesFunc func(ctx context.Context) ([]db.GetAccountDiscrepanciesRow, error)
+
+	CreateIdempotencyKeyFunc         func(ctx context.Context, userID int32, key, fingerprint string, ttl, lease time.Duration) (bool, error)
+	GetIdempotencyKeyFunc            func(ctx context.Context, userID int32, key string) (db.GetIdempotencyKeyRow, error)
+	CompleteIdempotencyKeyFunc       func(ctx context.Context, userID int32, key string, statusCode int32, body []byte) error
+	DeleteIdempotencyKeyFunc         func(ctx context.Context, userID int32, key string) error
+	DeleteExpiredIdempotencyKeysFunc func(ctx context.Context) (int64, error)
+
+	CreateRefreshTokenFunc         func(ctx context.Context, userID int32, familyID, tokenHash string, ttl time.Duration) error
+	RotateRefreshTokenFunc         func(ctx context.Context, tokenHash, newTokenHash string, ttl time.Duration) (int32, error)
+	RevokeRefreshTokenFamilyFunc   func(ctx context.Context, userID int32, tokenHash string) (int64, error)
+	RevokeUserTokensFunc           func(ctx context.Context, userID int32) error
+	RevokeAccessTokenFunc          func(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevokedFunc             func(ctx context.Context, userID int32, jti string, issuedAt time.Time) (bool, error)
+	DeleteExpiredRefreshTokensFunc func(ctx context.Context) (int64, error)
+	DeleteExpiredRevokedTokensFunc func(ctx context.Context) (int64, error)
+
+	GetUserRolesFunc func(ctx context.Context, userID int32) ([]string, error)
+	GrantRoleFunc    func(ctx context.Context, userID int32, role string, grantedBy int32) (bool, error)
+	RevokeRoleFunc   func(ctx context.Context, userID int32, role string) (bool, error)
+
+	AddMerchFunc      func(ctx context.Context, actorID int32, name string, price int32) (db.Merch, error)
+	UpdateMerchFunc   func(ctx context.Context, actorID int32, arg db.UpdateMerchParams) (db.Merch, error)
+	ListMerchFunc     func(ctx context.Context) ([]db.Merch, error)
+	GetMerchAuditFunc func(ctx context.Context, merchID int32) ([]db.GetMerchAuditRow, error)
+
+	GetMerchFunc                   func(ctx context.Context, merchID int32) (db.Merch, error)
+	RestockMerchFunc               func(ctx context.Context, actorID, merchID, quantity int32) (db.Merch, error)
+	ListLowStockMerchFunc          func(ctx context.Context, threshold int32) ([]db.ListLowStockMerchRow, error)
+	ReserveMerchFunc               func(ctx context.Context, userID, merchID int32, sku string, expiresAt time.Time) (int32, error)
+	BuyReservedMerchFunc           func(ctx context.Context, userID, merchID, reservationID int32) error
+	CancelReservationFunc          func(ctx context.Context, userID, reservationID int32) error
+	ReleaseExpiredReservationsFunc func(ctx context.Context) (int64, error)
+
+	AddMerchVariantFunc      func(ctx context.Context, actorID int32, arg db.AddMerchVariantParams) (db.MerchVariant, error)
+	UpdateMerchVariantFunc   func(ctx context.Context, actorID int32, arg db.UpdateMerchVariantParams) (db.MerchVariant, error)
+	RestockMerchVariantFunc  func(ctx context.Context, actorID, merchID int32, sku string, quantity int32) (db.MerchVariant, error)
+	ListMerchVariantsFunc    func(ctx context.Context, merchID int32) ([]db.MerchVariant, error)
+	GetMerchVariantFunc      func(ctx context.Context, merchID int32, sku string) (db.MerchVariant, error)
+	GetMerchVariantPriceFunc func(ctx context.Context, merchID int32, sku string) (int32, error)
+
+	AddCartItemFunc    func(ctx context.Context, userID, merchID int32, sku string, quantity int32) error
+	ListCartItemsFunc  func(ctx context.Context, userID int32) ([]db.ListCartItemsRow, error)
+	RemoveCartItemFunc func(ctx context.Context, userID, itemID int32) error
+	CheckoutFunc       func(ctx context.Context, userID int32, pickupLocation, notes sql.NullString) (db.Order, error)
+
+	GetOrderFunc          func(ctx context.Context, orderID int32) (db.Order, error)
+	ListUserOrdersFunc    func(ctx context.Context, userID int32) ([]db.Order, error)
+	ListOrdersFunc        func(ctx context.Context, status sql.NullString) ([]db.Order, error)
+	GetOrderItemsFunc     func(ctx context.Context, orderID int32) ([]db.GetOrderItemsRow, error)
+	GetOrderEventsFunc    func(ctx context.Context, orderID int32) ([]db.GetOrderEventsRow, error)
+	UpdateOrderStatusFunc func(ctx context.Context, actorID int32, arg db.UpdateOrderStatusParams, note sql.NullString) (db.Order, error)
+
+	ReverseTransferFunc  func(ctx context.Context, actorID, transactionID int32, amount sql.NullInt32, reason string) (db.Reversal, error)
+	RefundPurchaseFunc   func(ctx context.Context, actorID, purchaseID int32, amount sql.NullInt32, reason string) (db.Reversal, error)
+	GetUserReversalsFunc func(ctx context.Context, userID, limit int32) ([]db.GetUserReversalsRow, error)
+
+	ListPublicTransfersFunc func(ctx context.Context, tag sql.NullString, limit int32) ([]db.ListPublicTransfersRow, error)
 }
 
 func (m *MockRepository) CreateUser(ctx context.Context, username, password string) (int32, error) {
@@ -32,16 +109,21 @@ func (m *MockRepository) CreateMerch(ctx context.Context, name string, price int
 	return m.CreateMerchFunc(ctx, name, price)
 }
 
-func (m *MockRepository) BuyMerch(ctx context.Context, userID, merchID int32) error {
-	return m.BuyMerchFunc(ctx, userID, merchID)
+func (m *MockRepository) BuyMerch(ctx context.Context, userID, merchID int32, sku string) error {
+	return m.BuyMerchFunc(ctx, userID, merchID, sku)
 }
 
 func (m *MockRepository) GetMerchPrice(ctx context.Context, merchID int32) (int32, error) {
 	return m.GetMerchPriceFunc(ctx, merchID)
 }
 
-func (m *MockRepository) TransferCoins(ctx context.Context, fromUser, toUser, amount int32) error {
-	return m.TransferCoinsFunc(ctx, fromUser, toUser, amount)
+func (m *MockRepository) TransferCoins(
+	ctx context.Context,
+	fromUser, toUser, amount int32,
+	note repository.TransferNote,
+	check *repository.TransferCheck,
+) error {
+	return m.TransferCoinsFunc(ctx, fromUser, toUser, amount, note, check)
 }
 
 func (m *MockRepository) GetUserBalance(ctx context.Context, userID int32) (int32, error) {
@@ -52,8 +134,16 @@ func (m *MockRepository) GetUserPurchases(ctx context.Context, userID int32) ([]
 	return m.GetUserPurchasesFunc(ctx, userID)
 }
 
-func (m *MockRepository) GetTransactions(ctx context.Context, userID int32) ([]db.GetTransactionsRow, error) {
-	return m.GetTransactionsFunc(ctx, userID)
+func (m *MockRepository) GetUserInventory(ctx context.Context, userID int32) ([]db.GetUserInventoryRow, error) {
+	return m.GetUserInventoryFunc(ctx, userID)
+}
+
+func (m *MockRepository) GetTransactions(ctx context.Context, userID, limit int32) ([]db.GetTransactionsRow, error) {
+	return m.GetTransactionsFunc(ctx, userID, limit)
+}
+
+func (m *MockRepository) GetUserHistory(ctx context.Context, arg db.GetUserHistoryParams) ([]db.GetUserHistoryRow, error) {
+	return m.GetUserHistoryFunc(ctx, arg)
 }
 
 func (m *MockRepository) UpdateUserBalance(ctx context.Context, userID int32, balance int32) error {
@@ -64,10 +154,246 @@ func (m *MockRepository) UserExists(ctx context.Context, username string) (db.Us
 	return m.UserExistsFunc(ctx, username)
 }
 
+func (m *MockRepository) UpdateUserPassword(ctx context.Context, userID int32, passwordHash string) error {
+	return m.UpdateUserPasswordFunc(ctx, userID, passwordHash)
+}
+
+func (m *MockRepository) GetAccountPostings(ctx context.Context, userID int32) ([]db.GetAccountPostingsRow, error) {
+	return m.GetAccountPostingsFunc(ctx, userID)
+}
+
+func (m *MockRepository) GetLedgerTotals(ctx context.Context) ([]db.GetLedgerTotalsRow, error) {
+	return m.GetLedgerTotalsFunc(ctx)
+}
+
+func (m *MockRepository) GetUserBalancesTotal(ctx context.Context) (int64, error) {
+	return m.GetUserBalancesTotalFunc(ctx)
+}
+
+func (m *MockRepository) GetUnbalancedEntries(ctx context.Context) ([]db.GetUnbalancedEntriesRow, error) {
+	return m.GetUnbalancedEntriesFunc(ctx)
+}
+
+func (m *MockRepository) GetAccountDiscrepancies(ctx context.Context) ([]db.GetAccountDiscrepanciesRow, error) {
+	return m.GetAccountDiscrepanciesFunc(ctx)
+}
+
+func (m *MockRepository) CreateIdempotencyKey(
+	ctx context.Context,
+	userID int32,
+	key, fingerprint string,
+	ttl, lease time.Duration,
+) (bool, error) {
+	return m.CreateIdempotencyKeyFunc(ctx, userID, key, fingerprint, ttl, lease)
+}
+
+func (m *MockRepository) GetIdempotencyKey(ctx context.Context, userID int32, key string) (db.GetIdempotencyKeyRow, error) {
+	return m.GetIdempotencyKeyFunc(ctx, userID, key)
+}
+
+func (m *MockRepository) CompleteIdempotencyKey(
+	ctx context.Context,
+	userID int32,
+	key string,
+	statusCode int32,
+	body []byte,
+) error {
+	return m.CompleteIdempotencyKeyFunc(ctx, userID, key, statusCode, body)
+}
+
+func (m *MockRepository) DeleteIdempotencyKey(ctx context.Context, userID int32, key string) error {
+	return m.DeleteIdempotencyKeyFunc(ctx, userID, key)
+}
+
+func (m *MockRepository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	return m.DeleteExpiredIdempotencyKeysFunc(ctx)
+}
+
+func (m *MockRepository) CreateRefreshToken(
+	ctx context.Context,
+	userID int32,
+	familyID, tokenHash string,
+	ttl time.Duration,
+) error {
+	return m.CreateRefreshTokenFunc(ctx, userID, familyID, tokenHash, ttl)
+}
+
+func (m *MockRepository) RotateRefreshToken(
+	ctx context.Context,
+	tokenHash, newTokenHash string,
+	ttl time.Duration,
+) (int32, error) {
+	return m.RotateRefreshTokenFunc(ctx, tokenHash, newTokenHash, ttl)
+}
+
+func (m *MockRepository) RevokeRefreshTokenFamily(ctx context.Context, userID int32, tokenHash string) (int64, error) {
+	return m.RevokeRefreshTokenFamilyFunc(ctx, userID, tokenHash)
+}
+
+func (m *MockRepository) RevokeUserTokens(ctx context.Context, userID int32) error {
+	return m.RevokeUserTokensFunc(ctx, userID)
+}
+
+func (m *MockRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return m.RevokeAccessTokenFunc(ctx, jti, expiresAt)
+}
+
+func (m *MockRepository) IsTokenRevoked(ctx context.Context, userID int32, jti string, issuedAt time.Time) (bool, error) {
+	return m.IsTokenRevokedFunc(ctx, userID, jti, issuedAt)
+}
+
+func (m *MockRepository) DeleteExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	return m.DeleteExpiredRefreshTokensFunc(ctx)
+}
+
+func (m *MockRepository) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	return m.DeleteExpiredRevokedTokensFunc(ctx)
+}
+
+func (m *MockRepository) GetUserRoles(ctx context.Context, userID int32) ([]string, error) {
+	return m.GetUserRolesFunc(ctx, userID)
+}
+
+func (m *MockRepository) GrantRole(ctx context.Context, userID int32, role string, grantedBy int32) (bool, error) {
+	return m.GrantRoleFunc(ctx, userID, role, grantedBy)
+}
+
+func (m *MockRepository) RevokeRole(ctx context.Context, userID int32, role string) (bool, error) {
+	return m.RevokeRoleFunc(ctx, userID, role)
+}
+
+func (m *MockRepository) AddMerch(ctx context.Context, actorID int32, name string, price int32) (db.Merch, error) {
+	return m.AddMerchFunc(ctx, actorID, name, price)
+}
+
+func (m *MockRepository) UpdateMerch(ctx context.Context, actorID int32, arg db.UpdateMerchParams) (db.Merch, error) {
+	return m.UpdateMerchFunc(ctx, actorID, arg)
+}
+
+func (m *MockRepository) ListMerch(ctx context.Context) ([]db.Merch, error) {
+	return m.ListMerchFunc(ctx)
+}
+
+func (m *MockRepository) GetMerchAudit(ctx context.Context, merchID int32) ([]db.GetMerchAuditRow, error) {
+	return m.GetMerchAuditFunc(ctx, merchID)
+}
+
+func (m *MockRepository) GetMerch(ctx context.Context, merchID int32) (db.Merch, error) {
+	return m.GetMerchFunc(ctx, merchID)
+}
+
+func (m *MockRepository) RestockMerch(ctx context.Context, actorID, merchID, quantity int32) (db.Merch, error) {
+	return m.RestockMerchFunc(ctx, actorID, merchID, quantity)
+}
+
+func (m *MockRepository) ListLowStockMerch(ctx context.Context, threshold int32) ([]db.ListLowStockMerchRow, error) {
+	return m.ListLowStockMerchFunc(ctx, threshold)
+}
+
+func (m *MockRepository) ReserveMerch(ctx context.Context, userID, merchID int32, sku string, expiresAt time.Time) (int32, error) {
+	return m.ReserveMerchFunc(ctx, userID, merchID, sku, expiresAt)
+}
+
+func (m *MockRepository) BuyReservedMerch(ctx context.Context, userID, merchID, reservationID int32) error {
+	return m.BuyReservedMerchFunc(ctx, userID, merchID, reservationID)
+}
+
+func (m *MockRepository) CancelReservation(ctx context.Context, userID, reservationID int32) error {
+	return m.CancelReservationFunc(ctx, userID, reservationID)
+}
+
+func (m *MockRepository) ReleaseExpiredReservations(ctx context.Context) (int64, error) {
+	return m.ReleaseExpiredReservationsFunc(ctx)
+}
+
+func (m *MockRepository) AddMerchVariant(ctx context.Context, actorID int32, arg db.AddMerchVariantParams) (db.MerchVariant, error) {
+	return m.AddMerchVariantFunc(ctx, actorID, arg)
+}
+
+func (m *MockRepository) UpdateMerchVariant(ctx context.Context, actorID int32, arg db.UpdateMerchVariantParams) (db.MerchVariant, error) {
+	return m.UpdateMerchVariantFunc(ctx, actorID, arg)
+}
+
+func (m *MockRepository) RestockMerchVariant(ctx context.Context, actorID, merchID int32, sku string, quantity int32) (db.MerchVariant, error) {
+	return m.RestockMerchVariantFunc(ctx, actorID, merchID, sku, quantity)
+}
+
+func (m *MockRepository) ListMerchVariants(ctx context.Context, merchID int32) ([]db.MerchVariant, error) {
+	return m.ListMerchVariantsFunc(ctx, merchID)
+}
+
+func (m *MockRepository) GetMerchVariant(ctx context.Context, merchID int32, sku string) (db.MerchVariant, error) {
+	return m.GetMerchVariantFunc(ctx, merchID, sku)
+}
+
+func (m *MockRepository) GetMerchVariantPrice(ctx context.Context, merchID int32, sku string) (int32, error) {
+	return m.GetMerchVariantPriceFunc(ctx, merchID, sku)
+}
+
+func (m *MockRepository) AddCartItem(ctx context.Context, userID, merchID int32, sku string, quantity int32) error {
+	return m.AddCartItemFunc(ctx, userID, merchID, sku, quantity)
+}
+
+func (m *MockRepository) ListCartItems(ctx context.Context, userID int32) ([]db.ListCartItemsRow, error) {
+	return m.ListCartItemsFunc(ctx, userID)
+}
+
+func (m *MockRepository) RemoveCartItem(ctx context.Context, userID, itemID int32) error {
+	return m.RemoveCartItemFunc(ctx, userID, itemID)
+}
+
+func (m *MockRepository) Checkout(ctx context.Context, userID int32, pickupLocation, notes sql.NullString) (db.Order, error) {
+	return m.CheckoutFunc(ctx, userID, pickupLocation, notes)
+}
+
+func (m *MockRepository) GetOrder(ctx context.Context, orderID int32) (db.Order, error) {
+	return m.GetOrderFunc(ctx, orderID)
+}
+
+func (m *MockRepository) ListUserOrders(ctx context.Context, userID int32) ([]db.Order, error) {
+	return m.ListUserOrdersFunc(ctx, userID)
+}
+
+func (m *MockRepository) ListOrders(ctx context.Context, status sql.NullString) ([]db.Order, error) {
+	return m.ListOrdersFunc(ctx, status)
+}
+
+func (m *MockRepository) GetOrderItems(ctx context.Context, orderID int32) ([]db.GetOrderItemsRow, error) {
+	return m.GetOrderItemsFunc(ctx, orderID)
+}
+
+func (m *MockRepository) GetOrderEvents(ctx context.Context, orderID int32) ([]db.GetOrderEventsRow, error) {
+	return m.GetOrderEventsFunc(ctx, orderID)
+}
+
+func (m *MockRepository) UpdateOrderStatus(ctx context.Context, actorID int32, arg db.UpdateOrderStatusParams, note sql.NullString) (db.Order, error) {
+	return m.UpdateOrderStatusFunc(ctx, actorID, arg, note)
+}
+
+func (m *MockRepository) ReverseTransfer(ctx context.Context, actorID, transactionID int32, amount sql.NullInt32, reason string) (db.Reversal, error) {
+	return m.ReverseTransferFunc(ctx, actorID, transactionID, amount, reason)
+}
+
+func (m *MockRepository) RefundPurchase(ctx context.Context, actorID, purchaseID int32, amount sql.NullInt32, reason string) (db.Reversal, error) {
+	return m.RefundPurchaseFunc(ctx, actorID, purchaseID, amount, reason)
+}
+
+func (m *MockRepository) GetUserReversals(ctx context.Context, userID, limit int32) ([]db.GetUserReversalsRow, error) {
+	return m.GetUserReversalsFunc(ctx, userID, limit)
+}
+
+func (m *MockRepository) ListPublicTransfers(ctx context.Context, tag sql.NullString, limit int32) ([]db.ListPublicTransfersRow, error) {
+	return m.ListPublicTransfersFunc(ctx, tag, limit)
+}
+
 func TestCreateUser(t *testing.T) {
 	// Создаем мок-репозиторий
 	mockRepo := &MockRepository{
-		CreateUserFunc: func(_ context.Context, _, _ string) (int32, error) {
+		CreateUserFunc: func(_ context.Context, username, _ string) (int32, error) {
+			if username == "taken" {
+				return 0, repository.ErrUserExists
+			}
+
 			return 1, nil // Возвращаем ID нового пользователя
 		},
 	}
@@ -81,6 +407,10 @@ func TestCreateUser(t *testing.T) {
 	// Проверяем, что ошибок нет и ID пользователя корректный
 	assert.NoError(t, err)
 	assert.Equal(t, int32(1), userID)
+
+	// Имя заняли раньше - например, параллельной регистрацией
+	_, err = coinService.CreateUser(context.Background(), "taken", "testpassword")
+	assert.ErrorIs(t, err, service.ErrUsernameTaken)
 }
 
 func TestBuyMerch(t *testing.T) {
@@ -92,16 +422,19 @@ func TestBuyMerch(t *testing.T) {
 		GetMerchPriceFunc: func(_ context.Context, _ int32) (int32, error) {
 			return 500, nil // Цена мерча
 		},
-		BuyMerchFunc: func(_ context.Context, _, _ int32) error {
+		BuyMerchFunc: func(_ context.Context, _, _ int32, _ string) error {
 			return nil // Успешная покупка
 		},
+		GetMerchFunc: func(_ context.Context, merchID int32) (db.Merch, error) {
+			return db.Merch{ID: merchID, Active: true}, nil // Остаток не учитывается
+		},
 	}
 
 	// Создаем сервис с мок-репозиторием
 	coinService := service.NewCoinService(mockRepo)
 
 	// Вызываем метод BuyMerch
-	err := coinService.BuyMerch(context.Background(), 1, 1)
+	err := coinService.BuyMerch(context.Background(), 1, 1, "")
 
 	// Проверяем, что ошибок нет
 	assert.NoError(t, err)
@@ -119,7 +452,7 @@ func TestTransferCoins(t *testing.T) {
 			}
 			return 500, nil // Баланс получателя
 		},
-		TransferCoinsFunc: func(_ context.Context, _, _, _ int32) error {
+		TransferCoinsFunc: func(_ context.Context, _, _, _ int32, _ repository.TransferNote, _ *repository.TransferCheck) error {
 			return nil // Успешный перевод
 		},
 	}
@@ -128,7 +461,7 @@ func TestTransferCoins(t *testing.T) {
 	coinService := service.NewCoinService(mockRepo)
 
 	// Вызываем метод TransferCoins
-	err := coinService.TransferCoins(context.Background(), 1, "testuser2", 200)
+	err := coinService.TransferCoins(context.Background(), 1, "testuser2", 200, service.TransferNote{})
 
 	// Проверяем, что ошибок нет
 	assert.NoError(t, err)
@@ -156,10 +489,10 @@ func TestGetUserBalance(t *testing.T) {
 func TestGetUserPurchases(t *testing.T) {
 	// Создаем мок-репозиторий
 	mockRepo := &MockRepository{
-		GetUserPurchasesFunc: func(_ context.Context, _ int32) ([]db.GetUserPurchasesRow, error) {
-			return []db.GetUserPurchasesRow{
-				{Name: "t-shirt"},
-				{Name: "cup"},
+		GetUserInventoryFunc: func(_ context.Context, _ int32) ([]db.GetUserInventoryRow, error) {
+			return []db.GetUserInventoryRow{
+				{Name: "t-shirt", Quantity: 2},
+				{Name: "cup", Quantity: 1},
 			}, nil
 		},
 	}
@@ -178,10 +511,20 @@ func TestGetUserPurchases(t *testing.T) {
 func TestGetTransactions(t *testing.T) {
 	// Создаем мок-репозиторий
 	mockRepo := &MockRepository{
-		GetTransactionsFunc: func(_ context.Context, _ int32) ([]db.GetTransactionsRow, error) {
+		GetTransactionsFunc: func(_ context.Context, _, limit int32) ([]db.GetTransactionsRow, error) {
+			assert.Equal(t, int32(service.InfoHistoryLimit), limit)
+
 			return []db.GetTransactionsRow{
-				{FromUser: sql.NullInt32{Int32: 2, Valid: true}, ToUser: sql.NullInt32{Int32: 1, Valid: true}, Amount: 100},
-				{FromUser: sql.NullInt32{Int32: 1, Valid: true}, ToUser: sql.NullInt32{Int32: 3, Valid: true}, Amount: 50},
+				{
+					ID: 7, Amount: 100, TransactionTime: sql.NullTime{Time: time.Now(), Valid: true},
+					FromUser: sql.NullInt32{Int32: 2, Valid: true}, FromUsername: "bob",
+					ToUser: sql.NullInt32{Int32: 1, Valid: true}, ToUsername: "alice",
+				},
+				{
+					ID: 8, Amount: 50,
+					FromUser: sql.NullInt32{Int32: 1, Valid: true}, FromUsername: "alice",
+					ToUser: sql.NullInt32{Int32: 3, Valid: true}, ToUsername: "carol",
+				},
 			}, nil
 		},
 	}
@@ -196,4 +539,1165 @@ func TestGetTransactions(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(*infoResponse.CoinHistory.Received))
 	assert.Equal(t, 1, len(*infoResponse.CoinHistory.Sent))
+
+	// Имена участников, ID и время перевода берутся из базы
+	received := (*infoResponse.CoinHistory.Received)[0]
+	assert.Equal(t, "bob", *received.FromUser)
+	assert.Equal(t, 7, *received.Id)
+	assert.NotNil(t, received.CreatedAt)
+
+	sent := (*infoResponse.CoinHistory.Sent)[0]
+	assert.Equal(t, "carol", *sent.ToUser)
+	assert.Equal(t, 8, *sent.Id)
+	assert.Nil(t, sent.CreatedAt)
+}
+
+func TestAuditLedger(t *testing.T) {
+	// Создаем мок-репозиторий: выпущено 2000 монет, 1800 у пользователей, 200 потрачено в магазине
+	mockRepo := &MockRepository{
+		GetLedgerTotalsFunc: func(_ context.Context) ([]db.GetLedgerTotalsRow, error) {
+			return []db.GetLedgerTotalsRow{
+				{Kind: repository.AccountIssuance, Total: -2000},
+				{Kind: repository.AccountShop, Total: 200},
+				{Kind: repository.AccountUser, Total: 1800},
+			}, nil
+		},
+		GetUnbalancedEntriesFunc: func(_ context.Context) ([]db.GetUnbalancedEntriesRow, error) {
+			return nil, nil
+		},
+		GetAccountDiscrepanciesFunc: func(_ context.Context) ([]db.GetAccountDiscrepanciesRow, error) {
+			return nil, nil
+		},
+	}
+
+	// Создаем сервис с мок-репозиторием
+	coinService := service.NewCoinService(mockRepo)
+
+	// Вызываем метод AuditLedger
+	audit, err := coinService.AuditLedger(context.Background())
+
+	// Проверяем, что журнал сходится
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2000), audit.Issued)
+	assert.True(t, audit.Consistent())
+
+	// Расхождение кэша баланса с проводками делает журнал несогласованным
+	mockRepo.GetAccountDiscrepanciesFunc = func(_ context.Context) ([]db.GetAccountDiscrepanciesRow, error) {
+		return []db.GetAccountDiscrepanciesRow{{ID: 3, Balance: 900, Posted: 1000}}, nil
+	}
+
+	audit, err = coinService.AuditLedger(context.Background())
+
+	assert.NoError(t, err)
+	assert.False(t, audit.Consistent())
+}
+
+func TestBeginIdempotentRequest(t *testing.T) {
+	stored := db.GetIdempotencyKeyRow{
+		Fingerprint:  "fingerprint",
+		StatusCode:   sql.NullInt32{Int32: 200, Valid: true},
+		ResponseBody: []byte(`"Coins transferred successfully"`),
+	}
+
+	// Создаем мок-репозиторий: ключ уже занят выполненным запросом
+	mockRepo := &MockRepository{
+		CreateIdempotencyKeyFunc: func(_ context.Context, _ int32, _, _ string, ttl, _ time.Duration) (bool, error) {
+			assert.Equal(t, time.Hour, ttl)
+			return false, nil
+		},
+		GetIdempotencyKeyFunc: func(_ context.Context, _ int32, _ string) (db.GetIdempotencyKeyRow, error) {
+			return stored, nil
+		},
+	}
+
+	// Создаем сервис с мок-репозиторием
+	coinService := service.NewCoinService(mockRepo, service.WithIdempotencyTTL(time.Hour))
+
+	// Повтор того же запроса получает сохраненный ответ
+	response, err := coinService.BeginIdempotentRequest(context.Background(), 1, "key", "fingerprint")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, stored.ResponseBody, response.Body)
+
+	// Тот же ключ с другим запросом - конфликт
+	_, err = coinService.BeginIdempotentRequest(context.Background(), 1, "key", "other")
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyReused)
+
+	// Запрос с этим ключом еще выполняется
+	stored.StatusCode = sql.NullInt32{}
+	_, err = coinService.BeginIdempotentRequest(context.Background(), 1, "key", "fingerprint")
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyInProgress)
+
+	// Новый ключ - запрос нужно выполнить
+	mockRepo.CreateIdempotencyKeyFunc = func(_ context.Context, _ int32, _, _ string, _, _ time.Duration) (bool, error) {
+		return true, nil
+	}
+
+	response, err = coinService.BeginIdempotentRequest(context.Background(), 1, "new-key", "fingerprint")
+	assert.NoError(t, err)
+	assert.Nil(t, response)
+}
+
+func TestBeginIdempotentRequestExpiredLease(t *testing.T) {
+	// Создаем мок-репозиторий: ключ зарезервирован запросом, который так и не сохранил ответ
+	// (процесс упал посреди запроса), резерв занимается заново, когда он старше lease
+	reservedAt := time.Now().Add(-5 * time.Minute)
+	stored := db.GetIdempotencyKeyRow{Fingerprint: "fingerprint"}
+
+	mockRepo := &MockRepository{
+		CreateIdempotencyKeyFunc: func(_ context.Context, _ int32, _, fingerprint string, _, lease time.Duration) (bool, error) {
+			if stored.StatusCode.Valid || fingerprint != stored.Fingerprint || time.Since(reservedAt) < lease {
+				return false, nil
+			}
+
+			reservedAt = time.Now()
+
+			return true, nil
+		},
+		GetIdempotencyKeyFunc: func(_ context.Context, _ int32, _ string) (db.GetIdempotencyKeyRow, error) {
+			return stored, nil
+		},
+	}
+
+	ctx := context.Background()
+
+	// Резерв моложе lease - запрос считается выполняющимся
+	coinService := service.NewCoinService(mockRepo, service.WithIdempotencyLease(10*time.Minute))
+
+	_, err := coinService.BeginIdempotentRequest(ctx, 1, "key", "fingerprint")
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyInProgress)
+
+	// Резерв старше lease - повтор того же запроса занимает ключ и выполняется
+	coinService = service.NewCoinService(mockRepo, service.WithIdempotencyLease(time.Minute))
+
+	response, err := coinService.BeginIdempotentRequest(ctx, 1, "key", "fingerprint")
+	require.NoError(t, err)
+	assert.Nil(t, response)
+
+	// Новый резерв снова держит ключ, а другой запрос с тем же ключом по-прежнему отклоняется
+	_, err = coinService.BeginIdempotentRequest(ctx, 1, "key", "fingerprint")
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyInProgress)
+
+	_, err = coinService.BeginIdempotentRequest(ctx, 1, "key", "other")
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyReused)
+}
+
+func TestAuthenticateRehashesLegacyPassword(t *testing.T) {
+	var storedHash string
+
+	// Создаем мок-репозиторий: пароль пользователя хранится в открытом виде
+	mockRepo := &MockRepository{
+		UserExistsFunc: func(_ context.Context, _ string) (db.UserExistsRow, error) {
+			return db.UserExistsRow{ID: 1, Password: "test"}, nil
+		},
+		UpdateUserPasswordFunc: func(_ context.Context, _ int32, passwordHash string) error {
+			storedHash = passwordHash
+			return nil
+		},
+	}
+
+	// Создаем сервис с облегченными параметрами argon2id
+	hasher := password.NewHasher(password.Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	coinService := service.NewCoinService(mockRepo, service.WithPasswordHasher(hasher))
+
+	// Неверный пароль отклоняется и не перехешируется
+	_, err := coinService.Authenticate(context.Background(), "testuser", "wrong")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	assert.Empty(t, storedHash)
+
+	// Верный пароль принимается, вместо открытого пароля сохраняется хеш
+	userID, err := coinService.Authenticate(context.Background(), "testuser", "test")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), userID)
+	assert.True(t, strings.HasPrefix(storedHash, "$argon2id$"))
+}
+
+func TestRefreshSession(t *testing.T) {
+	var issuedHash, presentedHash string
+
+	// Создаем мок-репозиторий
+	mockRepo := &MockRepository{
+		CreateRefreshTokenFunc: func(_ context.Context, _ int32, _, tokenHash string, _ time.Duration) error {
+			issuedHash = tokenHash
+			return nil
+		},
+		RotateRefreshTokenFunc: func(_ context.Context, tokenHash, _ string, _ time.Duration) (int32, error) {
+			presentedHash = tokenHash
+			return 1, nil
+		},
+	}
+
+	// Создаем сервис с мок-репозиторием
+	coinService := service.NewCoinService(mockRepo)
+
+	// В базе хранится только хеш токена
+	token, err := coinService.IssueRefreshToken(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, issuedHash)
+
+	// Обмен токена выдает новый токен
+	userID, newToken, err := coinService.RefreshSession(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), userID)
+	assert.Equal(t, issuedHash, presentedHash)
+	assert.NotEqual(t, token, newToken)
+
+	// Повторное использование и недействительный токен не различаются для клиента
+	for _, repoErr := range []error{repository.ErrRefreshTokenReused, repository.ErrRefreshTokenInvalid} {
+		mockRepo.RotateRefreshTokenFunc = func(_ context.Context, _, _ string, _ time.Duration) (int32, error) {
+			return 1, repoErr
+		}
+
+		_, _, err = coinService.RefreshSession(context.Background(), token)
+		assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	// Создаем мок-репозиторий: имя taken уже занято
+	mockRepo := &MockRepository{
+		CreateUserFunc: func(_ context.Context, username, _ string) (int32, error) {
+			if username == "taken@avito.ru" {
+				return 0, repository.ErrUserExists
+			}
+
+			return 1, nil
+		},
+	}
+
+	// Создаем сервис с облегченными параметрами хеширования и регистрацией по приглашениям
+	policy := service.DefaultRegistrationPolicy()
+	policy.InviteCodes = []string{"invite"}
+	policy.AllowedDomains = []string{"avito.ru"}
+
+	hasher := password.NewHasher(password.Bcrypt{Cost: 4})
+	coinService := service.NewCoinService(mockRepo,
+		service.WithPasswordHasher(hasher),
+		service.WithRegistrationPolicy(policy),
+	)
+
+	tests := []struct {
+		name       string
+		username   string
+		password   string
+		inviteCode string
+		err        error
+	}{
+		{name: "ok", username: "user@avito.ru", password: "password", inviteCode: "invite"},
+		{name: "domain case", username: "user@AVITO.ru", password: "password", inviteCode: "invite"},
+		{name: "too short", username: "u", password: "password", inviteCode: "invite", err: service.ErrInvalidUsername},
+		{name: "charset", username: "us er@avito.ru", password: "password", inviteCode: "invite", err: service.ErrInvalidUsername},
+		{name: "reserved", username: "Admin@avito.ru", password: "password", inviteCode: "invite", err: service.ErrInvalidUsername},
+		{name: "short password", username: "user@avito.ru", password: "pass", inviteCode: "invite", err: service.ErrInvalidPassword},
+		{name: "no invite", username: "user@avito.ru", password: "password", err: service.ErrRegistrationForbidden},
+		{name: "wrong domain", username: "user@gmail.com", password: "password", inviteCode: "invite", err: service.ErrRegistrationForbidden},
+		{name: "no domain", username: "user", password: "password", inviteCode: "invite", err: service.ErrRegistrationForbidden},
+		{name: "taken", username: "taken@avito.ru", password: "password", inviteCode: "invite", err: service.ErrUsernameTaken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, err := coinService.Register(context.Background(), tt.username, tt.password, tt.inviteCode)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, int32(1), userID)
+		})
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	// Сотрудник переводит монеты, но не управляет магазином
+	employee := []string{repository.RoleEmployee}
+	assert.True(t, service.HasPermission(employee, service.PermTransferCoins))
+	assert.False(t, service.HasPermission(employee, service.PermManageMerch))
+
+	// Права ролей суммируются
+	roles := []string{repository.RoleEmployee, repository.RoleShopAdmin}
+	assert.True(t, service.HasPermission(roles, service.PermManageMerch))
+	assert.True(t, service.HasPermission(roles, service.PermFulfillOrders))
+	assert.False(t, service.HasPermission(roles, service.PermAdjustBalance))
+
+	// Неизвестная роль прав не дает
+	assert.False(t, service.HasPermission([]string{"superuser"}, service.PermViewInfo))
+}
+
+func TestRevokeRole(t *testing.T) {
+	// Создаем мок-репозиторий: alice - администратор с ID 1, bob - пользователь с ID 2
+	users := map[string]int32{"alice": 1, "bob": 2}
+	revoked := 0
+
+	mockRepo := &MockRepository{
+		UserExistsFunc: func(_ context.Context, username string) (db.UserExistsRow, error) {
+			id, ok := users[username]
+			if !ok {
+				return db.UserExistsRow{}, sql.ErrNoRows
+			}
+
+			return db.UserExistsRow{ID: id}, nil
+		},
+		RevokeRoleFunc: func(_ context.Context, _ int32, _ string) (bool, error) {
+			revoked++
+			return true, nil
+		},
+	}
+
+	coinService := service.NewCoinService(mockRepo)
+	ctx := context.Background()
+
+	// Нельзя отозвать роль администратора у себя
+	err := coinService.RevokeRole(ctx, 1, "alice", repository.RoleAdmin)
+	assert.ErrorIs(t, err, service.ErrRevokeOwnAdmin)
+
+	err = coinService.RevokeRole(ctx, 1, "bob", "superuser")
+	assert.ErrorIs(t, err, service.ErrUnknownRole)
+
+	err = coinService.RevokeRole(ctx, 1, "carol", repository.RoleManager)
+	assert.ErrorIs(t, err, service.ErrUserNotFound)
+
+	assert.Equal(t, 0, revoked)
+
+	// У другого пользователя - можно
+	assert.NoError(t, coinService.RevokeRole(ctx, 1, "bob", repository.RoleAdmin))
+	assert.Equal(t, 1, revoked)
+}
+
+func TestParseRolesSeed(t *testing.T) {
+	seed, err := service.ParseRolesSeed(" alice = admin, shop-admin ; bob=finance-admin;")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"alice": {repository.RoleAdmin, repository.RoleShopAdmin},
+		"bob":   {repository.RoleFinanceAdmin},
+	}, seed)
+
+	_, err = service.ParseRolesSeed("alice=root")
+	assert.ErrorIs(t, err, service.ErrUnknownRole)
+
+	_, err = service.ParseRolesSeed("alice")
+	assert.Error(t, err)
+}
+
+func TestUpdateMerch(t *testing.T) {
+	// Создаем мок-репозиторий: в каталоге один товар с ID 1
+	merch := db.Merch{ID: 1, Name: "t-shirt", Price: 80, Active: true}
+
+	mockRepo := &MockRepository{
+		UpdateMerchFunc: func(_ context.Context, _ int32, arg db.UpdateMerchParams) (db.Merch, error) {
+			if arg.ID != merch.ID {
+				return db.Merch{}, sql.ErrNoRows
+			}
+
+			if arg.Name.Valid && arg.Name.String == "cup" {
+				return db.Merch{}, repository.ErrMerchExists
+			}
+
+			updated := merch
+			if arg.Price.Valid {
+				updated.Price = arg.Price.Int32
+			}
+
+			if arg.Active.Valid {
+				updated.Active = arg.Active.Bool
+			}
+
+			return updated, nil
+		},
+	}
+
+	coinService := service.NewCoinService(mockRepo)
+	ctx := context.Background()
+
+	price := int32(100)
+	updated, err := coinService.UpdateMerch(ctx, 1, 1, service.MerchUpdate{Price: &price})
+	require.NoError(t, err)
+	assert.Equal(t, int32(100), updated.Price)
+	assert.True(t, updated.Active)
+
+	// Снятие с продажи - изменение флага active
+	deactivated, err := coinService.DeactivateMerch(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.False(t, deactivated.Active)
+
+	_, err = coinService.DeactivateMerch(ctx, 1, 2)
+	assert.ErrorIs(t, err, service.ErrMerchNotFound)
+
+	name := "cup"
+	_, err = coinService.UpdateMerch(ctx, 1, 1, service.MerchUpdate{Name: &name})
+	assert.ErrorIs(t, err, service.ErrMerchNameTaken)
+
+	// Невалидные значения не доходят до репозитория
+	blank, zero := " ", int32(0)
+	_, err = coinService.UpdateMerch(ctx, 1, 1, service.MerchUpdate{Name: &blank})
+	assert.ErrorIs(t, err, service.ErrInvalidMerch)
+
+	_, err = coinService.UpdateMerch(ctx, 1, 1, service.MerchUpdate{Price: &zero})
+	assert.ErrorIs(t, err, service.ErrInvalidMerch)
+}
+
+func TestBuyMerchStock(t *testing.T) {
+	// Создаем мок-репозиторий: товар 1 в наличии (после покупки остается 2, у варианта "tshirt-m" - 1),
+	// товар 2 закончился
+	mockRepo := &MockRepository{
+		GetUserBalanceFunc: func(_ context.Context, _ int32) (int32, error) {
+			return 1000, nil
+		},
+		GetMerchPriceFunc: func(_ context.Context, _ int32) (int32, error) {
+			return 10, nil
+		},
+		GetMerchVariantPriceFunc: func(_ context.Context, _ int32, _ string) (int32, error) {
+			return 10, nil
+		},
+		GetMerchVariantFunc: func(_ context.Context, merchID int32, sku string) (db.MerchVariant, error) {
+			return db.MerchVariant{MerchID: merchID, Sku: sku, Stock: sql.NullInt32{Int32: 1, Valid: true}}, nil
+		},
+		BuyMerchFunc: func(_ context.Context, _, merchID int32, _ string) error {
+			if merchID == 2 {
+				return fmt.Errorf("error buying merch: %w", repository.ErrSoldOut)
+			}
+
+			return nil
+		},
+		GetMerchFunc: func(_ context.Context, merchID int32) (db.Merch, error) {
+			return db.Merch{ID: merchID, Stock: sql.NullInt32{Int32: 2, Valid: true}}, nil
+		},
+		RestockMerchFunc: func(_ context.Context, _, merchID, quantity int32) (db.Merch, error) {
+			return db.Merch{ID: merchID, Stock: sql.NullInt32{Int32: quantity, Valid: true}}, nil
+		},
+	}
+
+	var alerts []service.LowStockItem
+
+	coinService := service.NewCoinService(mockRepo, service.WithLowStockAlert(3, func(_ context.Context, item service.LowStockItem) {
+		alerts = append(alerts, item)
+	}))
+	ctx := context.Background()
+
+	// Остаток после покупки не больше порога - приходит уведомление
+	require.NoError(t, coinService.BuyMerch(ctx, 1, 1, ""))
+	assert.Equal(t, []service.LowStockItem{{MerchID: 1, Stock: 2}}, alerts)
+
+	err := coinService.BuyMerch(ctx, 1, 2, "")
+	assert.ErrorIs(t, err, service.ErrSoldOut)
+	assert.Len(t, alerts, 1)
+
+	// У варианта остаток свой - уведомление по его артикулу
+	require.NoError(t, coinService.BuyMerch(ctx, 1, 1, "tshirt-m"))
+	require.Len(t, alerts, 2)
+	assert.Equal(t, service.LowStockItem{MerchID: 1, Sku: "tshirt-m", Stock: 1}, alerts[1])
+
+	// Пополнение склада только на положительное количество
+	_, err = coinService.RestockMerch(ctx, 1, 1, 0)
+	assert.ErrorIs(t, err, service.ErrInvalidQuantity)
+
+	merch, err := coinService.RestockMerch(ctx, 1, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int32(10), merch.Stock.Int32)
+}
+
+func TestBuyMerchVariant(t *testing.T) {
+	// Создаем мок-репозиторий: у товара 1 есть вариант "tshirt-m" со своей ценой
+	var bought string
+
+	mockRepo := &MockRepository{
+		GetUserBalanceFunc: func(_ context.Context, _ int32) (int32, error) {
+			return 100, nil
+		},
+		GetMerchPriceFunc: func(_ context.Context, _ int32) (int32, error) {
+			return 80, nil
+		},
+		GetMerchVariantPriceFunc: func(_ context.Context, _ int32, sku string) (int32, error) {
+			if sku != "tshirt-m" {
+				return 0, sql.ErrNoRows
+			}
+
+			return 120, nil
+		},
+		BuyMerchFunc: func(_ context.Context, _, _ int32, sku string) error {
+			if sku == "" {
+				return fmt.Errorf("error buying merch: %w", repository.ErrVariantRequired)
+			}
+
+			bought = sku
+
+			return nil
+		},
+		GetMerchFunc: func(_ context.Context, merchID int32) (db.Merch, error) {
+			return db.Merch{ID: merchID, Active: true}, nil
+		},
+		GetMerchVariantFunc: func(_ context.Context, merchID int32, sku string) (db.MerchVariant, error) {
+			return db.MerchVariant{MerchID: merchID, Sku: sku, Active: true}, nil
+		},
+	}
+
+	coinService := service.NewCoinService(mockRepo)
+	ctx := context.Background()
+
+	// Без варианта товар с вариантами не купить
+	err := coinService.BuyMerch(ctx, 1, 1, "")
+	assert.ErrorIs(t, err, service.ErrVariantRequired)
+
+	// Неизвестный вариант
+	err = coinService.BuyMerch(ctx, 1, 1, "tshirt-xxl")
+	assert.ErrorIs(t, err, service.ErrVariantNotFound)
+
+	// Цена варианта больше баланса, хотя цена товара меньше
+	err = coinService.BuyMerch(ctx, 1, 1, "tshirt-m")
+	require.Error(t, err)
+	assert.Empty(t, bought)
+
+	mockRepo.GetUserBalanceFunc = func(_ context.Context, _ int32) (int32, error) {
+		return 200, nil
+	}
+
+	require.NoError(t, coinService.BuyMerch(ctx, 1, 1, "tshirt-m"))
+	assert.Equal(t, "tshirt-m", bought)
+}
+
+func TestAddMerchVariant(t *testing.T) {
+	mockRepo := &MockRepository{
+		AddMerchVariantFunc: func(_ context.Context, _ int32, arg db.AddMerchVariantParams) (db.MerchVariant, error) {
+			if arg.Sku == "taken" {
+				return db.MerchVariant{}, repository.ErrVariantExists
+			}
+
+			return db.MerchVariant{ID: 1, MerchID: arg.MerchID, Sku: arg.Sku, Size: arg.Size, Stock: arg.Stock, Active: true}, nil
+		},
+	}
+
+	coinService := service.NewCoinService(mockRepo)
+	ctx := context.Background()
+
+	size := " M "
+	stock := int32(3)
+	negative := int32(-1)
+
+	variant, err := coinService.AddMerchVariant(ctx, 1, 1, service.MerchVariantInput{Sku: " tshirt-m ", Size: &size, Stock: &stock})
+	require.NoError(t, err)
+	assert.Equal(t, "tshirt-m", variant.Sku)
+	assert.Equal(t, sql.NullString{String: "M", Valid: true}, variant.Size)
+	assert.Equal(t, sql.NullInt32{Int32: 3, Valid: true}, variant.Stock)
+
+	_, err = coinService.AddMerchVariant(ctx, 1, 1, service.MerchVariantInput{Sku: "t shirt"})
+	assert.ErrorIs(t, err, service.ErrInvalidVariant)
+
+	_, err = coinService.AddMerchVariant(ctx, 1, 1, service.MerchVariantInput{Sku: "tshirt-s", Stock: &negative})
+	assert.ErrorIs(t, err, service.ErrInvalidVariant)
+
+	_, err = coinService.AddMerchVariant(ctx, 1, 1, service.MerchVariantInput{Sku: "taken"})
+	assert.ErrorIs(t, err, service.ErrVariantSkuTaken)
+}
+
+func TestCart(t *testing.T) {
+	// Создаем мок-репозиторий: в корзине футболка двух размеров и две кружки
+	items := []db.ListCartItemsRow{
+		{ID: 1, MerchID: 1, Name: "t-shirt", Variant: sql.NullString{String: "tshirt-m", Valid: true}, Quantity: 1, Price: 80},
+		{ID: 2, MerchID: 2, Name: "cup", Quantity: 2, Price: 20},
+	}
+
+	var checkedStock []int32
+
+	var checkedVariants []string
+
+	mockRepo := &MockRepository{
+		AddCartItemFunc: func(_ context.Context, _, _ int32, _ string, _ int32) error {
+			return nil
+		},
+		ListCartItemsFunc: func(_ context.Context, _ int32) ([]db.ListCartItemsRow, error) {
+			return items, nil
+		},
+		CheckoutFunc: func(_ context.Context, _ int32, _, _ sql.NullString) (db.Order, error) {
+			return db.Order{}, fmt.Errorf("checkout: %w", repository.ErrCartStale)
+		},
+		GetMerchFunc: func(_ context.Context, merchID int32) (db.Merch, error) {
+			checkedStock = append(checkedStock, merchID)
+			return db.Merch{ID: merchID}, nil
+		},
+		GetMerchVariantFunc: func(_ context.Context, merchID int32, sku string) (db.MerchVariant, error) {
+			checkedVariants = append(checkedVariants, sku)
+			return db.MerchVariant{MerchID: merchID, Sku: sku}, nil
+		},
+	}
+
+	coinService := service.NewCoinService(mockRepo)
+	ctx := context.Background()
+
+	// Стоимость корзины считается по сохраненным ценам
+	cart, err := coinService.AddToCart(ctx, 1, 2, "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(120), cart.Total)
+
+	_, err = coinService.AddToCart(ctx, 1, 2, "", 0)
+	assert.ErrorIs(t, err, service.ErrInvalidQuantity)
+
+	_, err = coinService.AddToCart(ctx, 1, 2, "", service.MaxCartItemQuantity+1)
+	assert.ErrorIs(t, err, service.ErrInvalidQuantity)
+
+	// Цены изменились - заказ не оформляется
+	_, err = coinService.Checkout(ctx, 1, service.CheckoutInput{})
+	assert.ErrorIs(t, err, service.ErrCartStale)
+
+	notes := strings.Repeat("a", 501)
+	_, err = coinService.Checkout(ctx, 1, service.CheckoutInput{Notes: &notes})
+	assert.ErrorIs(t, err, service.ErrInvalidOrder)
+
+	mockRepo.CheckoutFunc = func(_ context.Context, userID int32, pickupLocation, _ sql.NullString) (db.Order, error) {
+		return db.Order{ID: 7, UserID: userID, Total: 120, PickupLocation: pickupLocation}, nil
+	}
+
+	location := " office 5 "
+	order, err := coinService.Checkout(ctx, 1, service.CheckoutInput{PickupLocation: &location})
+	require.NoError(t, err)
+	assert.Equal(t, int32(7), order.ID)
+	assert.Equal(t, "office 5", order.PickupLocation.String)
+
+	// Остаток после заказа проверяется у каждой позиции, у вариантов - по артикулу
+	assert.Equal(t, []int32{1, 2}, checkedStock)
+	assert.Equal(t, []string{"tshirt-m"}, checkedVariants)
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	// Создаем мок-репозиторий: заказ пользователя 1 одобрен
+	order := db.Order{ID: 5, UserID: 1, Total: 100, Status: repository.OrderStatusApproved}
+
+	var updates []db.UpdateOrderStatusParams
+
+	mockRepo := &MockRepository{
+		GetOrderFunc: func(_ context.Context, orderID int32) (db.Order, error) {
+			if orderID != order.ID {
+				return db.Order{}, sql.ErrNoRows
+			}
+
+			return order, nil
+		},
+		UpdateOrderStatusFunc: func(_ context.Context, _ int32, arg db.UpdateOrderStatusParams, _ sql.NullString) (db.Order, error) {
+			updates = append(updates, arg)
+			if arg.FromStatus != order.Status {
+				return db.Order{}, repository.ErrOrderStatusChanged
+			}
+
+			order.Status = arg.Status
+			return order, nil
+		},
+		GetOrderItemsFunc: func(_ context.Context, _ int32) ([]db.GetOrderItemsRow, error) {
+			return nil, nil
+		},
+		GetOrderEventsFunc: func(_ context.Context, _ int32) ([]db.GetOrderEventsRow, error) {
+			return nil, nil
+		},
+	}
+
+	coinService := service.NewCoinService(mockRepo)
+	ctx := context.Background()
+
+	_, err := coinService.UpdateOrderStatus(ctx, 2, order.ID, service.OrderStatusUpdate{Status: "lost"})
+	assert.ErrorIs(t, err, service.ErrInvalidOrderStatus)
+
+	_, err = coinService.UpdateOrderStatus(ctx, 2, 404, service.OrderStatusUpdate{Status: repository.OrderStatusReadyForPickup})
+	assert.ErrorIs(t, err, service.ErrOrderNotFound)
+
+	// Шаги выдачи нельзя пропускать
+	_, err = coinService.UpdateOrderStatus(ctx, 2, order.ID, service.OrderStatusUpdate{Status: repository.OrderStatusDelivered})
+	assert.ErrorIs(t, err, service.ErrOrderTransitionNotAllowed)
+
+	details, err := coinService.UpdateOrderStatus(ctx, 2, order.ID, service.OrderStatusUpdate{Status: repository.OrderStatusReadyForPickup})
+	require.NoError(t, err)
+	assert.Equal(t, repository.OrderStatusReadyForPickup, details.Order.Status)
+	assert.Equal(t, repository.OrderStatusApproved, updates[0].FromStatus)
+
+	// Пользователь не может отменить одобренный заказ и не видит чужие заказы
+	_, err = coinService.CancelOrder(ctx, 1, order.ID)
+	assert.ErrorIs(t, err, service.ErrOrderNotCancellable)
+
+	_, err = coinService.CancelOrder(ctx, 3, order.ID)
+	assert.ErrorIs(t, err, service.ErrOrderNotFound)
+
+	_, err = coinService.GetUserOrder(ctx, 3, order.ID)
+	assert.ErrorIs(t, err, service.ErrOrderNotFound)
+
+	// Выданный заказ больше не меняется
+	_, err = coinService.UpdateOrderStatus(ctx, 2, order.ID, service.OrderStatusUpdate{Status: repository.OrderStatusDelivered})
+	require.NoError(t, err)
+
+	_, err = coinService.UpdateOrderStatus(ctx, 2, order.ID, service.OrderStatusUpdate{Status: repository.OrderStatusCancelled})
+	assert.ErrorIs(t, err, service.ErrOrderTransitionNotAllowed)
+	assert.Len(t, updates, 2)
+}
+
+func TestReverseTransfer(t *testing.T) {
+	// Создаем мок-репозиторий: перевод 3 на 100 монет уже сторнирован на 60
+	var reasons []string
+
+	mockRepo := &MockRepository{
+		ReverseTransferFunc: func(_ context.Context, _, transactionID int32, amount sql.NullInt32, reason string) (db.Reversal, error) {
+			if transactionID != 3 {
+				return db.Reversal{}, sql.ErrNoRows
+			}
+
+			if !amount.Valid || amount.Int32 > 40 {
+				return db.Reversal{}, repository.ErrReversalExceedsAmount
+			}
+
+			reasons = append(reasons, reason)
+			return db.Reversal{ID: 1, Amount: amount.Int32, Reason: reason}, nil
+		},
+	}
+
+	coinService := service.NewCoinService(mockRepo)
+	ctx := context.Background()
+
+	// Причина обязательна, сумма должна быть положительной
+	_, err := coinService.ReverseTransfer(ctx, 1, 3, service.ReversalInput{Reason: "  "})
+	assert.ErrorIs(t, err, service.ErrInvalidReversal)
+
+	zero := int32(0)
+	_, err = coinService.ReverseTransfer(ctx, 1, 3, service.ReversalInput{Amount: &zero, Reason: "mistake"})
+	assert.ErrorIs(t, err, service.ErrInvalidReversal)
+
+	_, err = coinService.ReverseTransfer(ctx, 1, 4, service.ReversalInput{Reason: "mistake"})
+	assert.ErrorIs(t, err, service.ErrTransferNotFound)
+
+	tooMuch := int32(50)
+	_, err = coinService.ReverseTransfer(ctx, 1, 3, service.ReversalInput{Amount: &tooMuch, Reason: "mistake"})
+	assert.ErrorIs(t, err, service.ErrReversalExceedsAmount)
+
+	amount := int32(40)
+	reversal, err := coinService.ReverseTransfer(ctx, 1, 3, service.ReversalInput{Amount: &amount, Reason: " wrong recipient "})
+	require.NoError(t, err)
+	assert.Equal(t, int32(40), reversal.Amount)
+	assert.Equal(t, []string{"wrong recipient"}, reasons)
+}
+
+func TestGetHistory(t *testing.T) {
+	// Создаем мок-репозиторий: у пользователя 3 операции, отсортированные от новых к старым
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	history := []db.GetUserHistoryRow{
+		{ID: 7, Type: service.HistoryTypeTransfer, Amount: 50, CreatedAt: sql.NullTime{Time: now, Valid: true}},
+		{ID: 3, Type: service.HistoryTypePurchase, Amount: 80, CreatedAt: sql.NullTime{Time: now.Add(-time.Minute), Valid: true}},
+		{ID: 5, Type: service.HistoryTypeTransfer, Amount: 20, CreatedAt: sql.NullTime{Time: now.Add(-time.Hour), Valid: true}},
+	}
+
+	var calls []db.GetUserHistoryParams
+
+	mockRepo := &MockRepository{
+		GetUserHistoryFunc: func(_ context.Context, arg db.GetUserHistoryParams) ([]db.GetUserHistoryRow, error) {
+			calls = append(calls, arg)
+
+			rows := history
+			if arg.CursorID.Valid {
+				rows = history[2:]
+			}
+
+			return rows[:min(len(rows), int(arg.PageSize))], nil
+		},
+		UserExistsFunc: func(_ context.Context, _ string) (db.UserExistsRow, error) {
+			return db.UserExistsRow{}, sql.ErrNoRows
+		},
+	}
+
+	coinService := service.NewCoinService(mockRepo)
+	ctx := context.Background()
+
+	// Первая страница: курсор указывает на ее последнюю запись
+	page, err := coinService.GetHistory(ctx, 1, service.HistoryFilter{Limit: 2, Type: service.HistoryTypeTransfer})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	require.NotEmpty(t, page.NextCursor)
+	assert.Equal(t, int32(3), calls[0].PageSize)
+	assert.Equal(t, service.HistoryTypeTransfer, calls[0].Type.String)
+
+	page, err = coinService.GetHistory(ctx, 1, service.HistoryFilter{Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Empty(t, page.NextCursor)
+	assert.Equal(t, int32(3), calls[1].CursorID.Int32)
+	assert.Equal(t, service.HistoryTypePurchase, calls[1].CursorType.String)
+	assert.True(t, calls[1].CursorTime.Time.Equal(now.Add(-time.Minute)))
+
+	// Переводов с несуществующим пользователем нет
+	page, err = coinService.GetHistory(ctx, 1, service.HistoryFilter{Counterparty: "nobody"})
+	require.NoError(t, err)
+	assert.Empty(t, page.Items)
+	assert.Len(t, calls, 2)
+
+	// Неверные фильтры и курсор
+	minAmount, maxAmount := int32(100), int32(10)
+	for _, filter := range []service.HistoryFilter{
+		{Type: "refund"},
+		{Direction: "sideways"},
+		{MinAmount: &minAmount, MaxAmount: &maxAmount},
+		{From: &now, To: &now},
+		{Limit: service.MaxHistoryPageSize + 1},
+		{Cursor: "not-a-cursor"},
+	} {
+		_, err = coinService.GetHistory(ctx, 1, filter)
+		assert.ErrorIs(t, err, service.ErrInvalidHistoryFilter)
+	}
+}
+
+func TestTransferCoinsErrors(t *testing.T) {
+	// Создаем мок-репозиторий: пользователь 1 с балансом 100, получателя "nobody" нет
+	mockRepo := &MockRepository{
+		UserExistsFunc: func(_ context.Context, username string) (db.UserExistsRow, error) {
+			switch username {
+			case "nobody":
+				return db.UserExistsRow{}, sql.ErrNoRows
+			case "self":
+				return db.UserExistsRow{ID: 1}, nil
+			}
+
+			return db.UserExistsRow{ID: 2}, nil
+		},
+		GetUserBalanceFunc: func(_ context.Context, _ int32) (int32, error) {
+			return 100, nil
+		},
+		TransferCoinsFunc: func(_ context.Context, _, _, _ int32, _ repository.TransferNote, _ *repository.TransferCheck) error {
+			return repository.ErrInsufficientBalance // Баланс изменился после проверки
+		},
+	}
+
+	coinService := service.NewCoinService(mockRepo)
+	ctx := context.Background()
+
+	err := coinService.TransferCoins(ctx, 1, "nobody", 10, service.TransferNote{})
+	assert.ErrorIs(t, err, service.ErrUserNotFound)
+
+	err = coinService.TransferCoins(ctx, 1, "self", 10, service.TransferNote{})
+	assert.ErrorIs(t, err, service.ErrSelfTransfer)
+
+	err = coinService.TransferCoins(ctx, 1, "testuser2", 101, service.TransferNote{})
+	assert.ErrorIs(t, err, service.ErrInsufficientBalance)
+
+	err = coinService.TransferCoins(ctx, 1, "testuser2", 50, service.TransferNote{})
+	assert.ErrorIs(t, err, service.ErrInsufficientBalance)
+}
+
+func TestTransferNote(t *testing.T) {
+	// Создаем мок-репозиторий, который запоминает сохраненную заметку
+	var saved repository.TransferNote
+
+	mockRepo := &MockRepository{
+		UserExistsFunc: func(_ context.Context, _ string) (db.UserExistsRow, error) {
+			return db.UserExistsRow{ID: 2}, nil
+		},
+		GetUserBalanceFunc: func(_ context.Context, _ int32) (int32, error) {
+			return 1000, nil
+		},
+		TransferCoinsFunc: func(_ context.Context, _, _, _ int32, note repository.TransferNote, _ *repository.TransferCheck) error {
+			saved = note
+			return nil
+		},
+	}
+
+	coinService := service.NewCoinService(mockRepo)
+	ctx := context.Background()
+
+	// Управляющие символы удаляются, теги приводятся к нижнему регистру, дубликаты отбрасываются
+	err := coinService.TransferCoins(ctx, 1, "testuser2", 10, service.TransferNote{
+		Message: "  Thanks\x1b[31m for\tthe help!\u200b ",
+		Tags:    []string{"Teamwork", "ownership", " teamwork "},
+		Public:  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Thanks[31m for the help!", saved.Message.String)
+	assert.Equal(t, []string{"ownership", "teamwork"}, saved.Tags)
+	assert.True(t, saved.Public)
+
+	// Пустое сообщение не сохраняется
+	require.NoError(t, coinService.TransferCoins(ctx, 1, "testuser2", 10, service.TransferNote{Message: " \n "}))
+	assert.False(t, saved.Message.Valid)
+	assert.Empty(t, saved.Tags)
+
+	// Слишком длинное сообщение, неверный тег и слишком много тегов
+	for _, note := range []service.TransferNote{
+		{Message: strings.Repeat("a", service.MaxTransferMessageLength+1)},
+		{Tags: []string{"team work"}},
+		{Tags: []string{"a", "b", "c", "d", "e", "f"}},
+	} {
+		err = coinService.TransferCoins(ctx, 1, "testuser2", 10, note)
+		assert.ErrorIs(t, err, service.ErrInvalidTransferNote)
+	}
+}
+
+func TestGetRecognitionFeed(t *testing.T) {
+	var calls []sql.NullString
+
+	mockRepo := &MockRepository{
+		ListPublicTransfersFunc: func(_ context.Context, tag sql.NullString, limit int32) ([]db.ListPublicTransfersRow, error) {
+			calls = append(calls, tag)
+			assert.Equal(t, int32(service.DefaultHistoryPageSize), limit)
+
+			return []db.ListPublicTransfersRow{{ID: 1, Tags: "teamwork"}}, nil
+		},
+	}
+
+	coinService := service.NewCoinService(mockRepo)
+	ctx := context.Background()
+
+	feed, err := coinService.GetRecognitionFeed(ctx, " TeamWork", 0)
+	require.NoError(t, err)
+	require.Len(t, feed, 1)
+	assert.Equal(t, sql.NullString{String: "teamwork", Valid: true}, calls[0])
+
+	_, err = coinService.GetRecognitionFeed(ctx, "", 0)
+	require.NoError(t, err)
+	assert.False(t, calls[1].Valid)
+
+	_, err = coinService.GetRecognitionFeed(ctx, "team work", 0)
+	assert.ErrorIs(t, err, service.ErrInvalidFeedFilter)
+
+	_, err = coinService.GetRecognitionFeed(ctx, "", service.MaxHistoryPageSize+1)
+	assert.ErrorIs(t, err, service.ErrInvalidFeedFilter)
+	assert.Len(t, calls, 2)
+}
+
+func TestTransferLimits(t *testing.T) {
+	// Создаем мок-репозиторий: пользователь 1 - сотрудник, 3 - менеджер; сегодня уже отправлено 150 монет двум получателям
+	var period repository.TransferCheck
+
+	transfers := 0
+
+	mockRepo := &MockRepository{
+		UserExistsFunc: func(_ context.Context, username string) (db.UserExistsRow, error) {
+			if username == "manager" {
+				return db.UserExistsRow{ID: 3}, nil
+			}
+
+			return db.UserExistsRow{ID: 2}, nil
+		},
+		GetUserBalanceFunc: func(_ context.Context, _ int32) (int32, error) {
+			return 1000, nil
+		},
+		GetUserRolesFunc: func(_ context.Context, userID int32) ([]string, error) {
+			if userID == 3 {
+				return []string{repository.RoleEmployee, repository.RoleManager}, nil
+			}
+
+			return []string{repository.RoleEmployee}, nil
+		},
+		TransferCoinsFunc: func(_ context.Context, _, _, _ int32, _ repository.TransferNote, check *repository.TransferCheck) error {
+			// Репозиторий проверяет лимиты за периоды в транзакции перевода
+			period = *check
+
+			err := check.Check(db.GetTransferTotalsRow{SentToday: 150, SentThisWeek: 400, ReceivedToday: 90, OtherRecipientsToday: 2})
+			if err != nil {
+				return err
+			}
+
+			transfers++
+
+			return nil
+		},
+	}
+
+	limits := service.TransferLimits{MinAmount: 5, MaxAmount: 100, DailyOutgoing: 200, DailyIncoming: 100}
+	roles, err := service.ParseRoleLimits("manager:max_amount=500,daily_outgoing=0,daily_recipients=2", limits)
+	require.NoError(t, err)
+
+	coinService := service.NewCoinService(mockRepo, service.WithTransferLimits(service.LimitPolicy{Default: limits, Roles: roles}))
+	ctx := context.Background()
+
+	// Границы суммы одного перевода
+	err = coinService.TransferCoins(ctx, 1, "user2", 4, service.TransferNote{})
+	assert.ErrorIs(t, err, service.ErrLimitExceeded)
+
+	err = coinService.TransferCoins(ctx, 1, "user2", 0, service.TransferNote{})
+	assert.ErrorIs(t, err, service.ErrLimitExceeded)
+
+	var limitErr *service.LimitError
+
+	err = coinService.TransferCoins(ctx, 1, "user2", 101, service.TransferNote{})
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, service.LimitMaxAmount, limitErr.Limit)
+	assert.True(t, limitErr.ResetsAt.IsZero())
+
+	// Дневной расход отправителя: лимит обновляется в полночь UTC, неделя начинается с понедельника
+	err = coinService.TransferCoins(ctx, 1, "user2", 60, service.TransferNote{})
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, service.LimitDailyOutgoing, limitErr.Limit)
+	assert.Equal(t, int64(150), limitErr.Used)
+	assert.Equal(t, period.DayStart.AddDate(0, 0, 1), limitErr.ResetsAt)
+	assert.Equal(t, time.Monday, period.WeekStart.Weekday())
+	assert.False(t, period.WeekStart.After(period.DayStart))
+
+	// Дневные поступления получателя
+	err = coinService.TransferCoins(ctx, 1, "user2", 20, service.TransferNote{})
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, service.LimitDailyIncoming, limitErr.Limit)
+
+	require.NoError(t, coinService.TransferCoins(ctx, 1, "user2", 10, service.TransferNote{}))
+
+	// Менеджер не ограничен дневным расходом, но ограничен количеством получателей
+	err = coinService.TransferCoins(ctx, 3, "user2", 5, service.TransferNote{})
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, service.LimitDailyRecipients, limitErr.Limit)
+	assert.Equal(t, 1, transfers)
+}
+
+func TestParseRoleLimits(t *testing.T) {
+	base := service.TransferLimits{MinAmount: 1, DailyOutgoing: 100}
+
+	roles, err := service.ParseRoleLimits(" manager: max_amount=500 ; admin:daily_outgoing=0,weekly_incoming=1000", base)
+	require.NoError(t, err)
+	assert.Equal(t, service.TransferLimits{MinAmount: 1, MaxAmount: 500, DailyOutgoing: 100}, roles[repository.RoleManager])
+	assert.Equal(t, service.TransferLimits{MinAmount: 1, WeeklyIncoming: 1000}, roles[repository.RoleAdmin])
+
+	roles, err = service.ParseRoleLimits("", base)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	for _, value := range []string{"manager", "manager:max_amount", "manager:per_hour=5", "manager:max_amount=-1", "wizard:max_amount=1"} {
+		_, err = service.ParseRoleLimits(value, base)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestSyncCatalog(t *testing.T) {
+	// Создаем мок-репозиторий: в каталоге уже есть cup и pen
+	var added []string
+
+	updated := make(map[int32]db.UpdateMerchParams)
+
+	mockRepo := &MockRepository{
+		ListMerchFunc: func(_ context.Context) ([]db.Merch, error) {
+			return []db.Merch{
+				{ID: 1, Name: "cup", Price: 20, Active: true},
+				{ID: 2, Name: "pen", Price: 10, Active: true},
+			}, nil
+		},
+		AddMerchFunc: func(_ context.Context, _ int32, name string, price int32) (db.Merch, error) {
+			added = append(added, name)
+			return db.Merch{ID: 3, Name: name, Price: price, Active: true}, nil
+		},
+		UpdateMerchFunc: func(_ context.Context, _ int32, arg db.UpdateMerchParams) (db.Merch, error) {
+			updated[arg.ID] = arg
+			return db.Merch{ID: arg.ID}, nil
+		},
+	}
+
+	coinService := service.NewCoinService(mockRepo)
+	items := []catalog.Item{
+		{Name: "cup", Price: 20},
+		{Name: "pen", Price: 15},
+		{Name: "hoody", Price: 300},
+	}
+
+	// Пробный запуск ничего не меняет
+	report, err := coinService.SyncCatalog(context.Background(), 0, items, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hoody"}, report.Created)
+	assert.Equal(t, []string{"pen"}, report.Updated)
+	assert.Equal(t, []string{"cup"}, report.Unchanged)
+	assert.Empty(t, added)
+	assert.Empty(t, updated)
+
+	report, err = coinService.SyncCatalog(context.Background(), 0, items, false)
+	require.NoError(t, err)
+	assert.True(t, report.Changed())
+	assert.Equal(t, []string{"hoody"}, added)
+	require.Contains(t, updated, int32(2))
+	assert.Equal(t, int32(15), updated[2].Price.Int32)
+	assert.False(t, updated[2].Active.Valid)
+}
+
+// recordingMetrics - метрики, которые запоминают учтенные операции.
+type recordingMetrics struct {
+	transferred int32
+	purchased   map[int32]int32
+	failures    []string
+}
+
+func (m *recordingMetrics) TransferCompleted(amount int32) {
+	m.transferred += amount
+}
+
+func (m *recordingMetrics) PurchaseCompleted(merchID, quantity int32) {
+	m.purchased[merchID] += quantity
+}
+
+func (m *recordingMetrics) PurchaseFailed(reason string) {
+	m.failures = append(m.failures, reason)
+}
+
+func TestMetrics(t *testing.T) {
+	// Создаем мок-репозиторий: на складе одна единица товара 2
+	stock := int32(1)
+
+	mockRepo := &MockRepository{
+		UserExistsFunc: func(_ context.Context, _ string) (db.UserExistsRow, error) {
+			return db.UserExistsRow{ID: 2}, nil
+		},
+		GetUserBalanceFunc: func(_ context.Context, _ int32) (int32, error) {
+			return 1000, nil
+		},
+		TransferCoinsFunc: func(_ context.Context, _, _, _ int32, _ repository.TransferNote, _ *repository.TransferCheck) error {
+			return nil
+		},
+		GetMerchPriceFunc: func(_ context.Context, merchID int32) (int32, error) {
+			if merchID == 3 {
+				return 0, sql.ErrNoRows
+			}
+
+			return 100, nil
+		},
+		BuyMerchFunc: func(_ context.Context, _, _ int32, _ string) error {
+			if stock == 0 {
+				return repository.ErrSoldOut
+			}
+
+			stock--
+
+			return nil
+		},
+		GetMerchFunc: func(_ context.Context, merchID int32) (db.Merch, error) {
+			return db.Merch{ID: merchID, Active: true}, nil
+		},
+	}
+
+	recorder := &recordingMetrics{purchased: make(map[int32]int32)}
+	coinService := service.NewCoinService(mockRepo, service.WithMetrics(recorder))
+	ctx := context.Background()
+
+	require.NoError(t, coinService.TransferCoins(ctx, 1, "bob", 150, service.TransferNote{}))
+	require.NoError(t, coinService.BuyMerch(ctx, 1, 2, ""))
+
+	// Закончившийся и несуществующий товар учитываются как неудачные покупки
+	assert.ErrorIs(t, coinService.BuyMerch(ctx, 1, 2, ""), service.ErrSoldOut)
+	assert.ErrorIs(t, coinService.BuyMerch(ctx, 1, 3, ""), service.ErrMerchNotFound)
+
+	assert.Equal(t, int32(150), recorder.transferred)
+	assert.Equal(t, map[int32]int32{2: 1}, recorder.purchased)
+	assert.Equal(t, []string{service.PurchaseFailureSoldOut, service.PurchaseFailureNotFound}, recorder.failures)
+}
+
+// Тест: методы сервиса создают дочерние спаны запроса, и репозиторий получает контекст спана метода.
+func TestTracing(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() { otel.SetTracerProvider(provider) })
+
+	var repoSpan trace.SpanContext
+
+	mockRepo := &MockRepository{
+		GetUserBalanceFunc: func(ctx context.Context, _ int32) (int32, error) {
+			repoSpan = trace.SpanContextFromContext(ctx)
+			return 1000, nil
+		},
+	}
+
+	ctx, request := otel.Tracer("test").Start(context.Background(), "request")
+
+	_, err := service.NewCoinService(mockRepo).GetUserBalance(ctx, 1)
+	require.NoError(t, err)
+	request.End()
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	assert.Equal(t, "CoinService.GetUserBalance", ended[0].Name())
+	assert.Equal(t, request.SpanContext().SpanID(), ended[0].Parent().SpanID())
+	assert.Equal(t, ended[0].SpanContext(), repoSpan)
 }
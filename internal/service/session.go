@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"avito_coin/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultRefreshTokenTTL - время жизни refresh-токена по умолчанию.
+const DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+// ErrInvalidRefreshToken - refresh-токен недействителен: не найден, истек, отозван или уже использован.
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// IssueRefreshToken - выпуск refresh-токена для нового входа пользователя.
+func (s *CoinService) IssueRefreshToken(ctx context.Context, userID int32) (string, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.IssueRefreshToken")
+	defer span.End()
+
+	familyID, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.CreateRefreshToken(ctx, userID, familyID, hashToken(token), s.refreshTokenTTL); err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// RefreshSession - обмен refresh-токена на новый, возвращает ID пользователя и новый токен.
+func (s *CoinService) RefreshSession(ctx context.Context, refreshToken string) (int32, string, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.RefreshSession")
+	defer span.End()
+
+	token, err := randomToken(32)
+	if err != nil {
+		return 0, "", err
+	}
+
+	userID, err := s.repo.RotateRefreshToken(ctx, hashToken(refreshToken), hashToken(token), s.refreshTokenTTL)
+
+	switch {
+	case errors.Is(err, repository.ErrRefreshTokenReused):
+		logrus.WithContext(ctx).WithField("user_id", userID).Warn("Refresh token reuse detected, token family revoked")
+		return 0, "", ErrInvalidRefreshToken
+	case errors.Is(err, repository.ErrRefreshTokenInvalid):
+		return 0, "", ErrInvalidRefreshToken
+	case err != nil:
+		return 0, "", fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return userID, token, nil
+}
+
+// RevokeSession - выход из сеанса: отзыв цепочки, к которой относится refresh-токен пользователя.
+func (s *CoinService) RevokeSession(ctx context.Context, userID int32, refreshToken string) error {
+	ctx, span := tracer.Start(ctx, "CoinService.RevokeSession")
+	defer span.End()
+
+	if _, err := s.repo.RevokeRefreshTokenFamily(ctx, userID, hashToken(refreshToken)); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllSessions - выход со всех устройств: отзыв всех refresh- и access-токенов пользователя.
+func (s *CoinService) RevokeAllSessions(ctx context.Context, userID int32) error {
+	ctx, span := tracer.Start(ctx, "CoinService.RevokeAllSessions")
+	defer span.End()
+
+	if err := s.repo.RevokeUserTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAccessToken - отзыв access-токена по его jti.
+func (s *CoinService) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "CoinService.RevokeAccessToken")
+	defer span.End()
+
+	if err := s.repo.RevokeAccessToken(ctx, jti, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+
+	return nil
+}
+
+// IsTokenRevoked - отозван ли access-токен сам по себе или вместе со всеми токенами пользователя.
+func (s *CoinService) IsTokenRevoked(ctx context.Context, userID int32, jti string, issuedAt time.Time) (bool, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.IsTokenRevoked")
+	defer span.End()
+
+	return s.repo.IsTokenRevoked(ctx, userID, jti, issuedAt)
+}
+
+// randomToken - случайная строка из n байт в base64url.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken - SHA-256 токена; в базе токены хранятся только в виде хеша.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"avito_coin/internal/db"
+	"avito_coin/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultReservationTTL - сколько держится резерв товара по умолчанию.
+	DefaultReservationTTL = 5 * time.Minute
+	// DefaultLowStockThreshold - остаток, начиная с которого отправляется предупреждение.
+	DefaultLowStockThreshold = 5
+)
+
+var (
+	// ErrSoldOut - товар закончился на складе.
+	ErrSoldOut = errors.New("merch is sold out")
+	// ErrReservationNotFound - резерва нет, он истек или относится к другому товару.
+	ErrReservationNotFound = errors.New("reservation not found")
+	// ErrInvalidQuantity - количество для пополнения склада должно быть положительным.
+	ErrInvalidQuantity = errors.New("quantity must be positive")
+)
+
+// LowStockItem - товар или его вариант, остаток которого опустился до порога.
+type LowStockItem struct {
+	MerchID int32
+	Name    string
+	// Sku - артикул варианта; пустой, если остаток ведется у самого товара.
+	Sku   string
+	Stock int32
+}
+
+// LowStockNotifier - уведомление о том, что остаток товара или варианта опустился до порога.
+type LowStockNotifier func(ctx context.Context, item LowStockItem)
+
+// logLowStock - уведомление по умолчанию: предупреждение в логе.
+func logLowStock(ctx context.Context, item LowStockItem) {
+	fields := logrus.Fields{
+		"merch_id": item.MerchID,
+		"name":     item.Name,
+		"stock":    item.Stock,
+	}
+
+	if item.Sku != "" {
+		fields["sku"] = item.Sku
+	}
+
+	logrus.WithContext(ctx).WithFields(fields).Warn("Merch stock is low")
+}
+
+// merchError - перевод ошибок репозитория при покупке и резервировании в ошибки сервиса.
+func merchError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrMerchNotFound
+	case errors.Is(err, repository.ErrSoldOut):
+		return ErrSoldOut
+	case errors.Is(err, repository.ErrReservationNotFound):
+		return ErrReservationNotFound
+	case errors.Is(err, repository.ErrVariantRequired):
+		return ErrVariantRequired
+	case errors.Is(err, repository.ErrVariantNotFound):
+		return ErrVariantNotFound
+	case errors.Is(err, repository.ErrInsufficientBalance):
+		return ErrInsufficientBalance
+	default:
+		return err
+	}
+}
+
+// checkLowStock - уведомление, если после списания остаток товара (или его варианта sku) не больше порога.
+func (s *CoinService) checkLowStock(ctx context.Context, merchID int32, sku string) {
+	log := logrus.WithContext(ctx).WithFields(logrus.Fields{"merch_id": merchID, "sku": sku})
+
+	merch, err := s.repo.GetMerch(ctx, merchID)
+	if err != nil {
+		log.WithError(err).Error("Failed to check merch stock")
+		return
+	}
+
+	stock := merch.Stock
+
+	if sku != "" {
+		variant, err := s.repo.GetMerchVariant(ctx, merchID, sku)
+		if err != nil {
+			log.WithError(err).Error("Failed to check merch stock")
+			return
+		}
+
+		stock = variant.Stock
+	}
+
+	if stock.Valid && stock.Int32 <= s.lowStockThreshold {
+		s.lowStockNotifier(ctx, LowStockItem{MerchID: merch.ID, Name: merch.Name, Sku: sku, Stock: stock.Int32})
+	}
+}
+
+// RestockMerch - пополнение склада администратором actorID.
+func (s *CoinService) RestockMerch(ctx context.Context, actorID, merchID, quantity int32) (db.Merch, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.RestockMerch")
+	defer span.End()
+
+	if quantity <= 0 {
+		return db.Merch{}, ErrInvalidQuantity
+	}
+
+	merch, err := s.repo.RestockMerch(ctx, actorID, merchID, quantity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Merch{}, ErrMerchNotFound
+	}
+
+	if err != nil {
+		return db.Merch{}, fmt.Errorf("failed to restock merch: %w", err)
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"merch_id": merch.ID,
+		"quantity": quantity,
+		"stock":    merch.Stock.Int32,
+		"actor_id": actorID,
+	}).Info("Merch restocked")
+
+	return merch, nil
+}
+
+// ListLowStockMerch - товары и варианты в продаже, остаток которых не больше порога.
+func (s *CoinService) ListLowStockMerch(ctx context.Context) ([]db.ListLowStockMerchRow, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.ListLowStockMerch")
+	defer span.End()
+
+	merch, err := s.repo.ListLowStockMerch(ctx, s.lowStockThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list low stock merch: %w", err)
+	}
+
+	return merch, nil
+}
+
+// ReserveMerch - резерв единицы товара (или его варианта sku) на время, пока пользователь подтверждает покупку.
+func (s *CoinService) ReserveMerch(ctx context.Context, userID, merchID int32, sku string) (int32, time.Time, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.ReserveMerch")
+	defer span.End()
+
+	expiresAt := time.Now().Add(s.reservationTTL)
+
+	reservationID, err := s.repo.ReserveMerch(ctx, userID, merchID, sku, expiresAt)
+	if err != nil {
+		return 0, time.Time{}, merchError(err)
+	}
+
+	s.checkLowStock(ctx, merchID, sku)
+
+	return reservationID, expiresAt, nil
+}
+
+// BuyReservedMerch - покупка товара по резерву. Остаток уже списан при резервировании.
+func (s *CoinService) BuyReservedMerch(ctx context.Context, userID, merchID, reservationID int32) error {
+	ctx, span := tracer.Start(ctx, "CoinService.BuyReservedMerch")
+	defer span.End()
+
+	err := merchError(s.repo.BuyReservedMerch(ctx, userID, merchID, reservationID))
+	s.recordPurchase(merchID, 1, err)
+
+	return err
+}
+
+// CancelReservation - отмена резерва с возвратом товара на склад.
+func (s *CoinService) CancelReservation(ctx context.Context, userID, reservationID int32) error {
+	ctx, span := tracer.Start(ctx, "CoinService.CancelReservation")
+	defer span.End()
+
+	return merchError(s.repo.CancelReservation(ctx, userID, reservationID))
+}
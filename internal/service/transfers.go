@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"avito_coin/internal/db"
+	"avito_coin/internal/repository"
+)
+
+const (
+	// MaxTransferMessageLength - наибольшая длина сообщения к переводу в символах.
+	MaxTransferMessageLength = 280
+	// MaxTransferTags - наибольшее количество тегов у перевода.
+	MaxTransferTags = 5
+)
+
+var (
+	// ErrInvalidTransferNote - сообщение или теги перевода не соответствуют ограничениям.
+	ErrInvalidTransferNote = errors.New("invalid transfer message")
+	// ErrInvalidFeedFilter - неверный тег или размер ленты благодарностей.
+	ErrInvalidFeedFilter = errors.New("invalid feed filter")
+	// ErrSelfTransfer - перевод самому себе.
+	ErrSelfTransfer = errors.New("sender and receiver cannot be the same")
+)
+
+// tagPattern - строчные латинские буквы, цифры, дефис и подчеркивание, до 32 символов.
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// TransferNote - необязательные сообщение, теги и видимость перевода в ленте благодарностей.
+type TransferNote struct {
+	Message string
+	Tags    []string
+	// Public - показывать перевод в ленте благодарностей.
+	Public bool
+}
+
+// normalize - очистка сообщения и проверка тегов.
+func (n TransferNote) normalize() (repository.TransferNote, error) {
+	message := sanitizeMessage(n.Message)
+	if utf8.RuneCountInString(message) > MaxTransferMessageLength {
+		return repository.TransferNote{}, fmt.Errorf("%w: message must be at most %d characters long",
+			ErrInvalidTransferNote, MaxTransferMessageLength)
+	}
+
+	tags := make([]string, 0, len(n.Tags))
+
+	for _, tag := range n.Tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return repository.TransferNote{}, fmt.Errorf("%w: %w", ErrInvalidTransferNote, err)
+		}
+
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	if len(tags) > MaxTransferTags {
+		return repository.TransferNote{}, fmt.Errorf("%w: at most %d tags are allowed", ErrInvalidTransferNote, MaxTransferTags)
+	}
+
+	slices.Sort(tags)
+
+	return repository.TransferNote{
+		Message: sql.NullString{String: message, Valid: message != ""},
+		Tags:    tags,
+		Public:  n.Public,
+	}, nil
+}
+
+// sanitizeMessage - сообщение без управляющих символов (кроме перевода строки), некорректного UTF-8
+// и пробелов по краям.
+func sanitizeMessage(message string) string {
+	message = strings.Map(func(r rune) rune {
+		if r == '\n' {
+			return r
+		}
+
+		if r == '\t' {
+			return ' '
+		}
+
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+
+		return r
+	}, strings.ToValidUTF8(message, ""))
+
+	return strings.TrimSpace(message)
+}
+
+// normalizeTag - тег в нижнем регистре без пробелов по краям.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !tagPattern.MatchString(tag) {
+		return "", fmt.Errorf("tag %q must be 1-32 latin letters, digits, '-' or '_'", tag)
+	}
+
+	return tag, nil
+}
+
+// SplitTags - список тегов из строки через запятую, как их возвращают запросы к БД.
+func SplitTags(tags string) []string {
+	if tags == "" {
+		return []string{}
+	}
+
+	return strings.Split(tags, ",")
+}
+
+// GetRecognitionFeed - последние открытые переводы компании, необязательно с тегом tag.
+// limit 0 - DefaultHistoryPageSize записей.
+func (s *CoinService) GetRecognitionFeed(ctx context.Context, tag string, limit int) ([]db.ListPublicTransfersRow, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.GetRecognitionFeed")
+	defer span.End()
+
+	if limit == 0 {
+		limit = DefaultHistoryPageSize
+	}
+
+	if limit < 0 || limit > MaxHistoryPageSize {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFeedFilter, MaxHistoryPageSize)
+	}
+
+	filter := sql.NullString{}
+
+	if tag != "" {
+		normalized, err := normalizeTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFeedFilter, err)
+		}
+
+		filter = sql.NullString{String: normalized, Valid: true}
+	}
+
+	transfers, err := s.repo.ListPublicTransfers(ctx, filter, int32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recognition feed: %w", err)
+	}
+
+	return transfers, nil
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"avito_coin/internal/db"
+	"avito_coin/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrVariantRequired - у товара есть варианты, и нужно указать, какой из них купить.
+	ErrVariantRequired = errors.New("merch variant is required")
+	// ErrVariantNotFound - варианта нет или он снят с продажи.
+	ErrVariantNotFound = errors.New("merch variant not found")
+	// ErrInvalidVariant - некорректный артикул, размер, цвет, цена или остаток варианта.
+	ErrInvalidVariant = errors.New("invalid merch variant")
+	// ErrVariantSkuTaken - вариант с таким артикулом уже есть.
+	ErrVariantSkuTaken = errors.New("merch variant SKU is already taken")
+)
+
+// skuPattern - латинские буквы, цифры, точка, дефис и подчеркивание, до 64 символов.
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+const (
+	maxVariantSizeLength  = 16
+	maxVariantColorLength = 32
+)
+
+// MerchVariantInput - новый вариант товара. Price и Stock необязательны:
+// без цены действует цена товара, без остатка вариант не заканчивается.
+type MerchVariantInput struct {
+	Sku   string
+	Size  *string
+	Color *string
+	Price *int32
+	Stock *int32
+}
+
+// VariantUpdate - изменяемые поля варианта; nil - поле не меняется.
+type VariantUpdate struct {
+	Price  *int32
+	Active *bool
+}
+
+// validate - проверка полей нового варианта.
+func (v MerchVariantInput) validate() error {
+	if !skuPattern.MatchString(v.Sku) {
+		return fmt.Errorf("%w: SKU must be 1-64 latin letters, digits, '.', '_' or '-'", ErrInvalidVariant)
+	}
+
+	if v.Size != nil && utf8.RuneCountInString(*v.Size) > maxVariantSizeLength {
+		return fmt.Errorf("%w: size must be at most %d characters", ErrInvalidVariant, maxVariantSizeLength)
+	}
+
+	if v.Color != nil && utf8.RuneCountInString(*v.Color) > maxVariantColorLength {
+		return fmt.Errorf("%w: color must be at most %d characters", ErrInvalidVariant, maxVariantColorLength)
+	}
+
+	if v.Price != nil && *v.Price <= 0 {
+		return fmt.Errorf("%w: price must be positive", ErrInvalidVariant)
+	}
+
+	if v.Stock != nil && *v.Stock < 0 {
+		return fmt.Errorf("%w: stock must not be negative", ErrInvalidVariant)
+	}
+
+	return nil
+}
+
+// nullString - необязательная строка для запроса; пустая строка считается отсутствующей.
+func nullString(value *string) sql.NullString {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: strings.TrimSpace(*value), Valid: true}
+}
+
+// nullInt32 - необязательное число для запроса.
+func nullInt32(value *int32) sql.NullInt32 {
+	if value == nil {
+		return sql.NullInt32{}
+	}
+
+	return sql.NullInt32{Int32: *value, Valid: true}
+}
+
+// ListMerchVariants - все варианты товара, включая снятые с продажи.
+func (s *CoinService) ListMerchVariants(ctx context.Context, merchID int32) ([]db.MerchVariant, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.ListMerchVariants")
+	defer span.End()
+
+	variants, err := s.repo.ListMerchVariants(ctx, merchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merch variants: %w", err)
+	}
+
+	return variants, nil
+}
+
+// ListActiveMerchVariants - варианты товара в продаже.
+func (s *CoinService) ListActiveMerchVariants(ctx context.Context, merchID int32) ([]db.MerchVariant, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.ListActiveMerchVariants")
+	defer span.End()
+
+	variants, err := s.ListMerchVariants(ctx, merchID)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(variants, func(v db.MerchVariant) bool { return !v.Active }), nil
+}
+
+// AddMerchVariant - добавление варианта товара администратором actorID.
+func (s *CoinService) AddMerchVariant(ctx context.Context, actorID, merchID int32, input MerchVariantInput) (db.MerchVariant, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.AddMerchVariant")
+	defer span.End()
+
+	input.Sku = strings.TrimSpace(input.Sku)
+	if err := input.validate(); err != nil {
+		return db.MerchVariant{}, err
+	}
+
+	variant, err := s.repo.AddMerchVariant(ctx, actorID, db.AddMerchVariantParams{
+		MerchID: merchID,
+		Sku:     input.Sku,
+		Size:    nullString(input.Size),
+		Color:   nullString(input.Color),
+		Price:   nullInt32(input.Price),
+		Stock:   nullInt32(input.Stock),
+	})
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return db.MerchVariant{}, ErrMerchNotFound
+	case errors.Is(err, repository.ErrVariantExists):
+		return db.MerchVariant{}, ErrVariantSkuTaken
+	case err != nil:
+		return db.MerchVariant{}, fmt.Errorf("failed to add merch variant: %w", err)
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"merch_id": merchID,
+		"sku":      variant.Sku,
+		"actor_id": actorID,
+	}).Info("Merch variant added")
+
+	return variant, nil
+}
+
+// UpdateMerchVariant - изменение цены или доступности варианта администратором actorID.
+func (s *CoinService) UpdateMerchVariant(ctx context.Context, actorID, merchID int32, sku string, update VariantUpdate) (db.MerchVariant, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.UpdateMerchVariant")
+	defer span.End()
+
+	if update.Price != nil && *update.Price <= 0 {
+		return db.MerchVariant{}, fmt.Errorf("%w: price must be positive", ErrInvalidVariant)
+	}
+
+	arg := db.UpdateMerchVariantParams{
+		Price:   nullInt32(update.Price),
+		MerchID: merchID,
+		Sku:     sku,
+	}
+
+	if update.Active != nil {
+		arg.Active = sql.NullBool{Bool: *update.Active, Valid: true}
+	}
+
+	variant, err := s.repo.UpdateMerchVariant(ctx, actorID, arg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.MerchVariant{}, ErrVariantNotFound
+	}
+
+	if err != nil {
+		return db.MerchVariant{}, fmt.Errorf("failed to update merch variant: %w", err)
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"merch_id": merchID,
+		"sku":      variant.Sku,
+		"active":   variant.Active,
+		"actor_id": actorID,
+	}).Info("Merch variant updated")
+
+	return variant, nil
+}
+
+// RestockMerchVariant - пополнение склада варианта администратором actorID.
+func (s *CoinService) RestockMerchVariant(ctx context.Context, actorID, merchID int32, sku string, quantity int32) (db.MerchVariant, error) {
+	ctx, span := tracer.Start(ctx, "CoinService.RestockMerchVariant")
+	defer span.End()
+
+	if quantity <= 0 {
+		return db.MerchVariant{}, ErrInvalidQuantity
+	}
+
+	variant, err := s.repo.RestockMerchVariant(ctx, actorID, merchID, sku, quantity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.MerchVariant{}, ErrVariantNotFound
+	}
+
+	if err != nil {
+		return db.MerchVariant{}, fmt.Errorf("failed to restock merch variant: %w", err)
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"merch_id": merchID,
+		"sku":      variant.Sku,
+		"quantity": quantity,
+		"stock":    variant.Stock.Int32,
+		"actor_id": actorID,
+	}).Info("Merch variant restocked")
+
+	return variant, nil
+}
@@ -0,0 +1,89 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: оформление заказа списывает сумму корзины и остатки одной транзакцией,
+// а корзина с устаревшими ценами отклоняется.
+func TestCheckout(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	adminID := createTestUser(t, repo, "shop-admin")
+	userID := createTestUser(t, repo, "buyer")
+
+	cup, err := coinService.AddMerch(ctx, adminID, uniqueName("cup"), 20)
+	require.NoError(t, err)
+
+	hoody, err := coinService.AddMerch(ctx, adminID, uniqueName("hoody"), 300)
+	require.NoError(t, err)
+
+	_, err = coinService.RestockMerch(ctx, adminID, hoody.ID, 1)
+	require.NoError(t, err)
+
+	// Пустую корзину оформить нельзя
+	_, err = coinService.Checkout(ctx, userID, service.CheckoutInput{})
+	assert.ErrorIs(t, err, service.ErrCartEmpty)
+
+	_, err = coinService.AddToCart(ctx, userID, cup.ID, "", 2)
+	require.NoError(t, err)
+
+	cart, err := coinService.AddToCart(ctx, userID, hoody.ID, "", 1)
+	require.NoError(t, err)
+	require.Len(t, cart.Items, 2)
+	assert.Equal(t, int64(340), cart.Total)
+
+	// Цена изменилась после добавления в корзину: заказ отклоняется, корзина получает новую цену
+	price := int32(30)
+	_, err = coinService.UpdateMerch(ctx, adminID, cup.ID, service.MerchUpdate{Price: &price})
+	require.NoError(t, err)
+
+	_, err = coinService.Checkout(ctx, userID, service.CheckoutInput{})
+	assert.ErrorIs(t, err, service.ErrCartStale)
+
+	cart, err = coinService.GetCart(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(360), cart.Total)
+
+	order, err := coinService.Checkout(ctx, userID, service.CheckoutInput{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(360), order.Total)
+
+	balance, err := repo.GetUserBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1000-360), balance)
+
+	purchases, err := repo.GetUserPurchases(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, purchases, 3)
+
+	cart, err = coinService.GetCart(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, cart.Items)
+
+	// Закончившийся товар откатывает весь заказ
+	_, err = coinService.AddToCart(ctx, userID, cup.ID, "", 1)
+	require.NoError(t, err)
+
+	_, err = coinService.AddToCart(ctx, userID, hoody.ID, "", 1)
+	require.NoError(t, err)
+
+	_, err = coinService.Checkout(ctx, userID, service.CheckoutInput{})
+	assert.ErrorIs(t, err, service.ErrSoldOut)
+
+	balance, err = repo.GetUserBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1000-360), balance)
+
+	cart, err = coinService.GetCart(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, cart.Items, 2)
+}
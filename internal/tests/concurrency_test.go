@@ -0,0 +1,274 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"avito_coin/internal/config"
+	"avito_coin/internal/db"
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Количество горутин, одновременно работающих с одним кошельком.
+const workers = 100
+
+// Тест: много горутин переводят монеты с одного кошелька, монеты не появляются и не пропадают.
+func TestConcurrentTransfersFromOneWallet(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	ctx := context.Background()
+
+	sender := createTestUser(t, repo, "sender")
+	receivers := []int32{createTestUser(t, repo, "receiver"), createTestUser(t, repo, "receiver")}
+	wallets := append([]int32{sender}, receivers...)
+	initialTotal := totalBalance(t, repo, wallets...)
+	initialSender := balanceOf(t, repo, sender)
+
+	const amount = 30
+
+	var succeeded atomic.Int32
+
+	runConcurrently(workers, func(i int) {
+		err := repo.TransferCoins(ctx, sender, receivers[i%len(receivers)], amount, repository.TransferNote{}, nil)
+		switch {
+		case err == nil:
+			succeeded.Add(1)
+		case !errors.Is(err, repository.ErrInsufficientBalance):
+			t.Errorf("unexpected transfer error: %v", err)
+		}
+	})
+
+	// Переводы прошли ровно до исчерпания баланса
+	assert.Equal(t, initialSender/amount, succeeded.Load())
+	assert.Equal(t, initialSender-succeeded.Load()*amount, balanceOf(t, repo, sender))
+	assert.Equal(t, initialTotal, totalBalance(t, repo, wallets...))
+	assertLedgerConsistent(t, repo)
+}
+
+// Тест: параллельные переводы одного отправителя вместе не превышают дневной лимит расхода.
+func TestConcurrentTransfersWithinDailyLimit(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	ctx := context.Background()
+
+	const (
+		dailyLimit = 100
+		amount     = 7
+	)
+
+	coinService := service.NewCoinService(repo, service.WithTransferLimits(service.LimitPolicy{
+		Default: service.TransferLimits{MinAmount: 1, DailyOutgoing: dailyLimit},
+	}))
+
+	sender := createTestUser(t, repo, "limited")
+	initialSender := balanceOf(t, repo, sender)
+
+	receivers := []string{uniqueName("limited_receiver"), uniqueName("limited_receiver")}
+	for _, name := range receivers {
+		_, err := repo.CreateUser(ctx, name, "test")
+		require.NoError(t, err)
+	}
+
+	var succeeded atomic.Int32
+
+	runConcurrently(workers, func(i int) {
+		err := coinService.TransferCoins(ctx, sender, receivers[i%len(receivers)], amount, service.TransferNote{})
+		switch {
+		case err == nil:
+			succeeded.Add(1)
+		case !errors.Is(err, service.ErrLimitExceeded):
+			t.Errorf("unexpected transfer error: %v", err)
+		}
+	})
+
+	// Прошли ровно те переводы, что укладываются в лимит
+	assert.Equal(t, int32(dailyLimit/amount), succeeded.Load())
+	assert.LessOrEqual(t, initialSender-balanceOf(t, repo, sender), int32(dailyLimit))
+	assertLedgerConsistent(t, repo)
+}
+
+// Тест: встречные переводы между двумя кошельками не приводят к дедлокам и потере монет.
+func TestConcurrentCrossTransfers(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	ctx := context.Background()
+
+	first := createTestUser(t, repo, "cross")
+	second := createTestUser(t, repo, "cross")
+	initialTotal := totalBalance(t, repo, first, second)
+
+	runConcurrently(workers, func(i int) {
+		from, to := first, second
+		if i%2 == 1 {
+			from, to = second, first
+		}
+
+		err := repo.TransferCoins(ctx, from, to, int32(i%7+1), repository.TransferNote{}, nil)
+		if err != nil && !errors.Is(err, repository.ErrInsufficientBalance) {
+			t.Errorf("unexpected transfer error: %v", err)
+		}
+	})
+
+	assert.Equal(t, initialTotal, totalBalance(t, repo, first, second))
+	assert.GreaterOrEqual(t, balanceOf(t, repo, first), int32(0))
+	assert.GreaterOrEqual(t, balanceOf(t, repo, second), int32(0))
+	assertLedgerConsistent(t, repo)
+}
+
+// Тест: параллельные покупки списывают ровно цену каждой успешной покупки.
+func TestConcurrentPurchases(t *testing.T) {
+	database := newTestDB(t)
+	repo := repository.NewRepository(database)
+	ctx := context.Background()
+
+	buyer := createTestUser(t, repo, "buyer")
+	initialBalance := balanceOf(t, repo, buyer)
+
+	const price = 70
+
+	merchID := createTestMerch(t, database, repo, price)
+
+	var succeeded atomic.Int32
+
+	runConcurrently(workers, func(int) {
+		err := repo.BuyMerch(ctx, buyer, merchID, "")
+		switch {
+		case err == nil:
+			succeeded.Add(1)
+		case !errors.Is(err, repository.ErrInsufficientBalance):
+			t.Errorf("unexpected purchase error: %v", err)
+		}
+	})
+
+	purchases, err := repo.GetUserPurchases(ctx, buyer)
+	require.NoError(t, err)
+
+	assert.Equal(t, initialBalance/price, succeeded.Load())
+	assert.Len(t, purchases, int(succeeded.Load()))
+	assert.Equal(t, initialBalance-succeeded.Load()*price, balanceOf(t, repo, buyer))
+	assertLedgerConsistent(t, repo)
+}
+
+// newTestDB подключается к БД из окружения, тест пропускается, если БД недоступна.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	cfg, _ := config.LoadConfig(nil)
+
+	database, err := db.NewPostgresDB(cfg)
+	if err != nil {
+		t.Skipf("database is not available: %v", err)
+	}
+
+	t.Cleanup(func() { database.Close() })
+
+	return database
+}
+
+// createTestUser создает пользователя с уникальным именем.
+func createTestUser(t *testing.T, repo repository.Repository, prefix string) int32 {
+	t.Helper()
+
+	id, err := repo.CreateUser(context.Background(), uniqueName(prefix), "test")
+	require.NoError(t, err)
+
+	return id
+}
+
+// createTestMerch создает мерч с уникальным именем и возвращает его ID.
+func createTestMerch(t *testing.T, database *sql.DB, repo repository.Repository, price int32) int32 {
+	t.Helper()
+
+	name := uniqueName("merch")
+	require.NoError(t, repo.CreateMerch(context.Background(), name, price))
+
+	var id int32
+
+	err := database.QueryRowContext(context.Background(), "SELECT id FROM merch WHERE name = $1", name).Scan(&id)
+	require.NoError(t, err)
+
+	return id
+}
+
+// balanceOf возвращает баланс пользователя.
+func balanceOf(t *testing.T, repo repository.Repository, userID int32) int32 {
+	t.Helper()
+
+	balance, err := repo.GetUserBalance(context.Background(), userID)
+	require.NoError(t, err)
+
+	return balance
+}
+
+// totalBalance возвращает сумму балансов пользователей.
+func totalBalance(t *testing.T, repo repository.Repository, userIDs ...int32) int32 {
+	t.Helper()
+
+	var total int32
+	for _, id := range userIDs {
+		total += balanceOf(t, repo, id)
+	}
+
+	return total
+}
+
+// assertLedgerConsistent проверяет, что проводки сходятся и совпадают с кэшем балансов,
+// а монеты в обращении по кэшу совпадают с суммой проводок по счетам пользователей.
+func assertLedgerConsistent(t *testing.T, repo repository.Repository) {
+	t.Helper()
+
+	unbalanced, err := repo.GetUnbalancedEntries(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, unbalanced, "journal entries must sum to zero")
+
+	discrepancies, err := repo.GetAccountDiscrepancies(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, discrepancies, "cached balances must match postings")
+
+	totals, err := repo.GetLedgerTotals(context.Background())
+	require.NoError(t, err)
+
+	circulation, err := repo.GetUserBalancesTotal(context.Background())
+	require.NoError(t, err)
+
+	for _, total := range totals {
+		if total.Kind == repository.AccountUser {
+			assert.Equal(t, total.Total, circulation, "coins in circulation must match user postings")
+		}
+	}
+}
+
+// runConcurrently запускает fn в n горутинах одновременно и ждет их завершения.
+func runConcurrently(n int, fn func(i int)) {
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+	)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			fn(i)
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+}
+
+// nameSeq различает имена, созданные в одну и ту же наносекунду.
+var nameSeq atomic.Int64
+
+// uniqueName возвращает имя, не пересекающееся с другими запусками тестов.
+func uniqueName(prefix string) string {
+	return fmt.Sprintf("%s_%d_%d", prefix, time.Now().UnixNano(), nameSeq.Add(1))
+}
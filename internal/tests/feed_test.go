@@ -0,0 +1,64 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: открытые переводы попадают в ленту благодарностей и фильтруются по тегу, закрытые - нет;
+// сообщение и теги видны участникам в истории.
+func TestRecognitionFeed(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	senderName, receiverName := uniqueName("sender"), uniqueName("receiver")
+
+	sender, err := repo.CreateUser(ctx, senderName, "test")
+	require.NoError(t, err)
+
+	receiver, err := repo.CreateUser(ctx, receiverName, "test")
+	require.NoError(t, err)
+
+	tag := uniqueName("kudos")
+
+	require.NoError(t, coinService.TransferCoins(ctx, sender, receiverName, 25, service.TransferNote{
+		Message: "Thanks for the release!",
+		Tags:    []string{tag, "teamwork"},
+		Public:  true,
+	}))
+	require.NoError(t, coinService.TransferCoins(ctx, sender, receiverName, 5, service.TransferNote{
+		Message: "private thanks",
+		Tags:    []string{tag},
+	}))
+
+	feed, err := coinService.GetRecognitionFeed(ctx, tag, 0)
+	require.NoError(t, err)
+	require.Len(t, feed, 1)
+	assert.Equal(t, senderName, feed[0].FromUsername)
+	assert.Equal(t, receiverName, feed[0].ToUsername)
+	assert.Equal(t, int32(25), feed[0].Amount)
+	assert.Equal(t, "Thanks for the release!", feed[0].Message.String)
+	assert.ElementsMatch(t, []string{tag, "teamwork"}, service.SplitTags(feed[0].Tags))
+
+	// Закрытый перевод виден участникам в истории вместе с сообщением
+	page, err := coinService.GetHistory(ctx, receiver, service.HistoryFilter{Type: service.HistoryTypeTransfer})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, "private thanks", page.Items[0].Message.String)
+	assert.Equal(t, tag, page.Items[0].Tags)
+
+	info, err := coinService.GetTransactions(ctx, sender)
+	require.NoError(t, err)
+	require.Len(t, *info.CoinHistory.Sent, 2)
+
+	for _, sent := range *info.CoinHistory.Sent {
+		require.NotNil(t, sent.Message)
+		assert.Contains(t, *sent.Tags, tag)
+	}
+}
@@ -0,0 +1,23 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"avito_coin/internal/health"
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест: после миграций при подключении проверки готовности БД проходят.
+func TestReadinessChecks(t *testing.T) {
+	database := newTestDB(t)
+
+	results, ok := health.Run(context.Background(), []health.Check{
+		health.Database(database),
+		health.Migrations(database),
+		health.Pool(database),
+	})
+
+	assert.True(t, ok, "checks: %+v", results)
+	assert.Equal(t, results[1].Details["current"], results[1].Details["expected"])
+}
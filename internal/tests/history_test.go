@@ -0,0 +1,132 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: история листается курсором без пропусков и повторов, фильтры сужают выборку.
+func TestHistoryPagination(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	adminID := createTestUser(t, repo, "shop-admin")
+	userID := createTestUser(t, repo, "history")
+	friendName := uniqueName("friend")
+	friend, err := repo.CreateUser(ctx, friendName, "test")
+	require.NoError(t, err)
+
+	other := createTestUser(t, repo, "other")
+
+	for _, amount := range []int32{10, 20, 30} {
+		require.NoError(t, repo.TransferCoins(ctx, userID, friend, amount, repository.TransferNote{}, nil))
+	}
+
+	require.NoError(t, repo.TransferCoins(ctx, other, userID, 40, repository.TransferNote{}, nil))
+
+	merch, err := coinService.AddMerch(ctx, adminID, uniqueName("cup"), 25)
+	require.NoError(t, err)
+	require.NoError(t, coinService.BuyMerch(ctx, userID, merch.ID, ""))
+
+	// historyKey - операция в истории: ID переводов и покупок пересекаются.
+	type historyKey struct {
+		kind string
+		id   int32
+	}
+
+	// Постранично по 2 записи: 5 операций на 3 страницах
+	var (
+		seen   = make(map[historyKey]bool)
+		cursor string
+		pages  int
+	)
+
+	for {
+		page, err := coinService.GetHistory(ctx, userID, service.HistoryFilter{Limit: 2, Cursor: cursor})
+		require.NoError(t, err)
+
+		for _, item := range page.Items {
+			key := historyKey{kind: item.Type, id: item.ID}
+			assert.False(t, seen[key], "duplicate history item %v", key)
+			seen[key] = true
+		}
+
+		pages++
+		cursor = page.NextCursor
+
+		if cursor == "" {
+			break
+		}
+	}
+
+	assert.Equal(t, 3, pages)
+	assert.Len(t, seen, 5)
+
+	// Новые операции первыми: последней была покупка
+	page, err := coinService.GetHistory(ctx, userID, service.HistoryFilter{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, service.HistoryTypePurchase, page.Items[0].Type)
+	assert.Equal(t, merch.Name, page.Items[0].Item.String)
+	assert.Equal(t, int32(25), page.Items[0].Amount)
+
+	// Фильтры по направлению, участнику и сумме
+	page, err = coinService.GetHistory(ctx, userID, service.HistoryFilter{Direction: service.HistoryDirectionIncoming})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, int32(40), page.Items[0].Amount)
+
+	minAmount, maxAmount := int32(15), int32(30)
+	page, err = coinService.GetHistory(ctx, userID, service.HistoryFilter{
+		Type:         service.HistoryTypeTransfer,
+		Counterparty: friendName,
+		MinAmount:    &minAmount,
+		MaxAmount:    &maxAmount,
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, int32(30), page.Items[0].Amount)
+	assert.Equal(t, int32(20), page.Items[1].Amount)
+	assert.Equal(t, service.HistoryDirectionOutgoing, page.Items[0].Direction)
+}
+
+// Тест: в истории /api/info настоящие имена участников, ID и время переводов.
+func TestInfoCoinHistory(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	senderName, receiverName := uniqueName("sender"), uniqueName("receiver")
+
+	sender, err := repo.CreateUser(ctx, senderName, "test")
+	require.NoError(t, err)
+
+	receiver, err := repo.CreateUser(ctx, receiverName, "test")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.TransferCoins(ctx, sender, receiver, 15, repository.TransferNote{}, nil))
+
+	info, err := coinService.GetTransactions(ctx, receiver)
+	require.NoError(t, err)
+	require.Len(t, *info.CoinHistory.Received, 1)
+
+	received := (*info.CoinHistory.Received)[0]
+	assert.Equal(t, senderName, *received.FromUser)
+	assert.Equal(t, 15, *received.Amount)
+	require.NotNil(t, received.Id)
+	require.NotNil(t, received.CreatedAt)
+
+	info, err = coinService.GetTransactions(ctx, sender)
+	require.NoError(t, err)
+	require.Len(t, *info.CoinHistory.Sent, 1)
+
+	sent := (*info.CoinHistory.Sent)[0]
+	assert.Equal(t, receiverName, *sent.ToUser)
+	assert.Equal(t, *received.Id, *sent.Id)
+}
@@ -0,0 +1,45 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: ключ, резерв которого брошен упавшим запросом, после истечения lease занимается повтором того же запроса.
+func TestIdempotencyKeyLeaseTakeover(t *testing.T) {
+	database := newTestDB(t)
+	repo := repository.NewRepository(database)
+	coinService := service.NewCoinService(repo, service.WithIdempotencyLease(time.Minute))
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, "idempotency")
+	key := uniqueName("key")
+
+	response, err := coinService.BeginIdempotentRequest(ctx, userID, key, "fingerprint")
+	require.NoError(t, err)
+	assert.Nil(t, response)
+
+	_, err = coinService.BeginIdempotentRequest(ctx, userID, key, "fingerprint")
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyInProgress)
+
+	// Запрос не сохранил ответ и не освободил ключ, а lease уже истек
+	_, err = database.ExecContext(ctx, `UPDATE idempotency_keys SET created_at = created_at - INTERVAL '2 minutes'
+WHERE user_id = $1 AND key = $2`, userID, key)
+	require.NoError(t, err)
+
+	_, err = coinService.BeginIdempotentRequest(ctx, userID, key, "other")
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyReused)
+
+	response, err = coinService.BeginIdempotentRequest(ctx, userID, key, "fingerprint")
+	require.NoError(t, err)
+	assert.Nil(t, response)
+
+	_, err = coinService.BeginIdempotentRequest(ctx, userID, key, "fingerprint")
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyInProgress)
+}
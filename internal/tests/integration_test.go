@@ -146,19 +146,31 @@ func TestBuyMerchWithJWT(t *testing.T) {
 // Тест для сценария регистрации и авторизации пользователя.
 func TestAuthAndRegistration(t *testing.T) {
 	// Данные для регистрации нового пользователя
-	newUser := api.AuthRequest{
-		Username: "newuser",
+	newUser := api.RegisterRequest{
+		Username: uniqueName("newuser"),
 		Password: "newpassword",
 	}
 
 	// Регистрация нового пользователя
-	token, err := authenticateUser(t, newUser.Username, newUser.Password)
+	token, err := registerUser(t, newUser)
 	if err != nil {
 		t.Fatalf("Failed to register new user: %v", err)
 	}
 
 	assert.NotEmpty(t, token, "Token should not be empty after registration")
 
+	// Повторная регистрация с тем же именем
+	_, err = registerUser(t, newUser)
+	assert.Error(t, err, "Expected error for taken username")
+
+	// Вход под новым пользователем
+	token, err = authenticateUser(t, newUser.Username, newUser.Password)
+	if err != nil {
+		t.Fatalf("Failed to authenticate registered user: %v", err)
+	}
+
+	assert.NotEmpty(t, token, "Token should not be empty after authentication")
+
 	// Авторизация существующего пользователя
 	existingUser := api.AuthRequest{
 		Username: "testbro",
@@ -326,6 +338,47 @@ func authenticateUser(t *testing.T, username, password string) (*api.AuthRespons
 	return &authResp, nil
 }
 
+// registerUser отправляет запрос на регистрацию и получение JWT токена.
+func registerUser(t *testing.T, request api.RegisterRequest) (*api.AuthResponse, error) {
+	t.Helper()
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal register request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(
+		context.Background(),
+		http.MethodPost,
+		fmt.Sprintf("%s/api/register", baseURL),
+		bytes.NewBuffer(body),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send register request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var authResp api.AuthResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
+		return nil, fmt.Errorf("failed to decode register response: %w", err)
+	}
+
+	return &authResp, nil
+}
+
 // getInfo отправляет запрос для получения информации о балансе и истории транзакций.
 func getInfo(t *testing.T, token string) (*api.InfoResponse, error) {
 	t.Helper() // Сообщаем Go, что это вспомогательная функция
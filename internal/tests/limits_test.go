@@ -0,0 +1,59 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: дневные лимиты считаются по переводам в БД, сторно возвращает израсходованный лимит,
+// повторный перевод тому же получателю не расходует лимит получателей.
+func TestTransferLimits(t *testing.T) {
+	database := newTestDB(t)
+	repo := repository.NewRepository(database)
+	coinService := service.NewCoinService(repo, service.WithTransferLimits(service.LimitPolicy{
+		Default: service.TransferLimits{MinAmount: 1, DailyOutgoing: 100, DailyRecipients: 2},
+	}))
+	ctx := context.Background()
+
+	adminID := createTestUser(t, repo, "finance-admin")
+	sender := createTestUser(t, repo, "sender")
+
+	names := []string{uniqueName("first"), uniqueName("second"), uniqueName("third")}
+	for _, name := range names {
+		_, err := repo.CreateUser(ctx, name, "test")
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, coinService.TransferCoins(ctx, sender, names[0], 60, service.TransferNote{}))
+	require.NoError(t, coinService.TransferCoins(ctx, sender, names[1], 30, service.TransferNote{}))
+
+	var limitErr *service.LimitError
+
+	err := coinService.TransferCoins(ctx, sender, names[0], 20, service.TransferNote{})
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, service.LimitDailyOutgoing, limitErr.Limit)
+	assert.Equal(t, int64(90), limitErr.Used)
+
+	// Третий получатель за день сверх лимита, прежнему получателю переводить можно
+	err = coinService.TransferCoins(ctx, sender, names[2], 5, service.TransferNote{})
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, service.LimitDailyRecipients, limitErr.Limit)
+
+	require.NoError(t, coinService.TransferCoins(ctx, sender, names[1], 10, service.TransferNote{}))
+
+	// Сторнированный перевод не расходует ни сумму, ни получателя
+	var transactionID int32
+	err = database.QueryRowContext(ctx, "SELECT id FROM transactions WHERE from_user = $1 AND amount = 60", sender).Scan(&transactionID)
+	require.NoError(t, err)
+
+	_, err = coinService.ReverseTransfer(ctx, adminID, transactionID, service.ReversalInput{Reason: "wrong recipient"})
+	require.NoError(t, err)
+
+	require.NoError(t, coinService.TransferCoins(ctx, sender, names[2], 50, service.TransferNote{}))
+	assert.Equal(t, int32(1000-30-10-50), balanceOf(t, repo, sender))
+}
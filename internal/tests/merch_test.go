@@ -0,0 +1,45 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: снятый с продажи товар нельзя купить, но он остается в истории покупок, а изменения попадают в журнал.
+func TestDeactivatedMerch(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	adminID := createTestUser(t, repo, "shop-admin")
+	userID := createTestUser(t, repo, "buyer")
+
+	merch, err := coinService.AddMerch(ctx, adminID, uniqueName("merch"), 10)
+	require.NoError(t, err)
+	require.True(t, merch.Active)
+
+	require.NoError(t, coinService.BuyMerch(ctx, userID, merch.ID, ""))
+
+	_, err = coinService.DeactivateMerch(ctx, adminID, merch.ID)
+	require.NoError(t, err)
+
+	err = coinService.BuyMerch(ctx, userID, merch.ID, "")
+	assert.ErrorIs(t, err, service.ErrMerchNotFound)
+
+	purchases, err := repo.GetUserPurchases(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, purchases, 1)
+	assert.Equal(t, merch.Name, purchases[0].Name)
+
+	audit, err := coinService.GetMerchAudit(ctx, merch.ID)
+	require.NoError(t, err)
+	require.Len(t, audit, 2)
+	assert.Equal(t, repository.MerchActionCreate, audit[0].Action)
+	assert.Equal(t, repository.MerchActionDeactivate, audit[1].Action)
+	assert.False(t, audit[1].Active)
+}
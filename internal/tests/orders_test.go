@@ -0,0 +1,124 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: заказ проходит этапы выдачи, а отмена возвращает монеты на баланс и товары на склад.
+func TestOrderLifecycle(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	adminID := createTestUser(t, repo, "shop-admin")
+	userID := createTestUser(t, repo, "buyer")
+
+	hoody, err := coinService.AddMerch(ctx, adminID, uniqueName("hoody"), 300)
+	require.NoError(t, err)
+
+	_, err = coinService.RestockMerch(ctx, adminID, hoody.ID, 2)
+	require.NoError(t, err)
+
+	_, err = coinService.AddToCart(ctx, userID, hoody.ID, "", 2)
+	require.NoError(t, err)
+
+	location := "office 5"
+	order, err := coinService.Checkout(ctx, userID, service.CheckoutInput{PickupLocation: &location})
+	require.NoError(t, err)
+	assert.Equal(t, repository.OrderStatusPlaced, order.Status)
+	assert.Equal(t, location, order.PickupLocation.String)
+
+	// Заказ одобряют и готовят к выдаче
+	details, err := coinService.UpdateOrderStatus(ctx, adminID, order.ID, service.OrderStatusUpdate{Status: repository.OrderStatusApproved})
+	require.NoError(t, err)
+	assert.Equal(t, repository.OrderStatusApproved, details.Order.Status)
+
+	_, err = coinService.CancelOrder(ctx, userID, order.ID)
+	assert.ErrorIs(t, err, service.ErrOrderNotCancellable)
+
+	desk := "reception"
+	_, err = coinService.UpdateOrderStatus(ctx, adminID, order.ID, service.OrderStatusUpdate{
+		Status: repository.OrderStatusReadyForPickup, PickupLocation: &desk,
+	})
+	require.NoError(t, err)
+
+	details, err = coinService.GetUserOrder(ctx, userID, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, desk, details.Order.PickupLocation.String)
+	require.Len(t, details.Items, 1)
+	assert.Equal(t, int32(2), details.Items[0].Quantity)
+	require.Len(t, details.History, 3)
+	assert.Equal(t, repository.OrderStatusReadyForPickup, details.History[2].Status)
+
+	// Отмена возвращает монеты и остаток, покупки пропадают из инвентаря
+	note := "out of sizes"
+	details, err = coinService.UpdateOrderStatus(ctx, adminID, order.ID, service.OrderStatusUpdate{
+		Status: repository.OrderStatusCancelled, Note: &note,
+	})
+	require.NoError(t, err)
+	assert.True(t, details.Order.RefundEntryID.Valid)
+	assert.Equal(t, note, details.History[3].Note.String)
+
+	balance, err := repo.GetUserBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1000), balance)
+
+	merch, err := repo.GetMerch(ctx, hoody.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), merch.Stock.Int32)
+
+	purchases, err := repo.GetUserPurchases(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, purchases)
+
+	audit, err := coinService.AuditLedger(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, audit.UnbalancedEntries)
+	assert.Empty(t, audit.Discrepancies)
+}
+
+// Тест: покупка одного товара тоже оформляется заказом, который пользователь может отменить до одобрения.
+func TestCancelOrder(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	adminID := createTestUser(t, repo, "shop-admin")
+	userID := createTestUser(t, repo, "buyer")
+	otherID := createTestUser(t, repo, "other")
+
+	cup, err := coinService.AddMerch(ctx, adminID, uniqueName("cup"), 20)
+	require.NoError(t, err)
+
+	require.NoError(t, coinService.BuyMerch(ctx, userID, cup.ID, ""))
+
+	orders, err := coinService.ListUserOrders(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, int32(20), orders[0].Total)
+
+	_, err = coinService.CancelOrder(ctx, otherID, orders[0].ID)
+	assert.ErrorIs(t, err, service.ErrOrderNotFound)
+
+	details, err := coinService.CancelOrder(ctx, userID, orders[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, repository.OrderStatusCancelled, details.Order.Status)
+
+	balance, err := repo.GetUserBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1000), balance)
+
+	// Повторная отмена не возвращает монеты еще раз
+	_, err = coinService.CancelOrder(ctx, userID, orders[0].ID)
+	assert.ErrorIs(t, err, service.ErrOrderNotCancellable)
+
+	cancelled, err := coinService.ListOrders(ctx, repository.OrderStatusCancelled)
+	require.NoError(t, err)
+	assert.Contains(t, cancelled, details.Order)
+}
@@ -0,0 +1,32 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: имена пользователей не различаются по регистру - второй раз то же имя в другом регистре
+// не зарегистрировать, а войти можно под любым написанием.
+func TestUsernameCaseInsensitive(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	name := uniqueName("Alice")
+
+	userID, err := coinService.CreateUser(ctx, name, "password")
+	require.NoError(t, err)
+
+	_, err = coinService.CreateUser(ctx, strings.ToLower(name), "password")
+	assert.ErrorIs(t, err, service.ErrUsernameTaken)
+
+	authenticated, err := coinService.Authenticate(ctx, strings.ToUpper(name), "password")
+	require.NoError(t, err)
+	assert.Equal(t, userID, authenticated)
+}
@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: перевод можно сторнировать частями, но не больше его суммы,
+// а сторно видно в истории обоих участников.
+func TestReverseTransfer(t *testing.T) {
+	database := newTestDB(t)
+	repo := repository.NewRepository(database)
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	adminID := createTestUser(t, repo, "finance-admin")
+	sender := createTestUser(t, repo, "sender")
+	receiver := createTestUser(t, repo, "receiver")
+
+	require.NoError(t, repo.TransferCoins(ctx, sender, receiver, 100, repository.TransferNote{}, nil))
+
+	var transactionID int32
+	err := database.QueryRowContext(ctx, "SELECT id FROM transactions WHERE from_user = $1", sender).Scan(&transactionID)
+	require.NoError(t, err)
+
+	amount := int32(30)
+	_, err = coinService.ReverseTransfer(ctx, adminID, transactionID, service.ReversalInput{Amount: &amount, Reason: "typo in amount"})
+	require.NoError(t, err)
+
+	tooMuch := int32(71)
+	_, err = coinService.ReverseTransfer(ctx, adminID, transactionID, service.ReversalInput{Amount: &tooMuch, Reason: "wrong recipient"})
+	assert.ErrorIs(t, err, service.ErrReversalExceedsAmount)
+
+	// Без суммы сторнируется остаток, повторное сторно отклоняется
+	reversal, err := coinService.ReverseTransfer(ctx, adminID, transactionID, service.ReversalInput{Reason: "wrong recipient"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(70), reversal.Amount)
+
+	_, err = coinService.ReverseTransfer(ctx, adminID, transactionID, service.ReversalInput{Reason: "again"})
+	assert.ErrorIs(t, err, service.ErrAlreadyReversed)
+
+	assert.Equal(t, int32(1000), balanceOf(t, repo, sender))
+	assert.Equal(t, int32(1000), balanceOf(t, repo, receiver))
+
+	senderHistory, err := coinService.GetUserReversals(ctx, sender)
+	require.NoError(t, err)
+	require.Len(t, senderHistory, 2)
+	assert.Equal(t, int32(70), senderHistory[0].Amount)
+	assert.Equal(t, "transfer", senderHistory[0].Type)
+
+	receiverHistory, err := coinService.GetUserReversals(ctx, receiver)
+	require.NoError(t, err)
+	require.Len(t, receiverHistory, 2)
+	assert.Equal(t, int32(-70), receiverHistory[0].Amount)
+	assert.Equal(t, "wrong recipient", receiverHistory[0].Reason)
+}
+
+// Тест: полный возврат покупки убирает ее из инвентаря и возвращает товар на склад,
+// покупку из невыданного заказа вернуть нельзя.
+func TestRefundPurchase(t *testing.T) {
+	database := newTestDB(t)
+	repo := repository.NewRepository(database)
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	adminID := createTestUser(t, repo, "finance-admin")
+	userID := createTestUser(t, repo, "buyer")
+
+	hoody, err := coinService.AddMerch(ctx, adminID, uniqueName("hoody"), 300)
+	require.NoError(t, err)
+
+	_, err = coinService.RestockMerch(ctx, adminID, hoody.ID, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, coinService.BuyMerch(ctx, userID, hoody.ID, ""))
+
+	var purchaseID, orderID int32
+	err = database.QueryRowContext(ctx, "SELECT id, order_id FROM purchases WHERE user_id = $1", userID).Scan(&purchaseID, &orderID)
+	require.NoError(t, err)
+
+	// Пока заказ не выдан, его нужно отменять, а не возвращать покупку
+	_, err = coinService.RefundPurchase(ctx, adminID, purchaseID, service.ReversalInput{Reason: "damaged"})
+	assert.ErrorIs(t, err, service.ErrPurchaseNotRefundable)
+
+	for _, status := range []string{
+		repository.OrderStatusApproved, repository.OrderStatusReadyForPickup, repository.OrderStatusDelivered,
+	} {
+		_, err = coinService.UpdateOrderStatus(ctx, adminID, orderID, service.OrderStatusUpdate{Status: status})
+		require.NoError(t, err)
+	}
+
+	// Частичный возврат оставляет покупку в инвентаре
+	amount := int32(100)
+	_, err = coinService.RefundPurchase(ctx, adminID, purchaseID, service.ReversalInput{Amount: &amount, Reason: "discount"})
+	require.NoError(t, err)
+
+	purchases, err := repo.GetUserPurchases(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, purchases, 1)
+
+	reversal, err := coinService.RefundPurchase(ctx, adminID, purchaseID, service.ReversalInput{Reason: "returned"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(200), reversal.Amount)
+
+	assert.Equal(t, int32(1000), balanceOf(t, repo, userID))
+
+	purchases, err = repo.GetUserPurchases(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, purchases)
+
+	merch, err := repo.GetMerch(ctx, hoody.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), merch.Stock.Int32)
+
+	history, err := coinService.GetUserReversals(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "purchase", history[0].Type)
+	assert.Equal(t, purchaseID, history[0].OriginalID)
+
+	audit, err := coinService.AuditLedger(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, audit.UnbalancedEntries)
+	assert.Empty(t, audit.Discrepancies)
+}
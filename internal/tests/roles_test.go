@@ -0,0 +1,42 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"avito_coin/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: новый пользователь получает роль сотрудника, выдача и отзыв ролей идемпотентны.
+func TestUserRoles(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, "roles")
+
+	roles, err := repo.GetUserRoles(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{repository.RoleEmployee}, roles)
+
+	granted, err := repo.GrantRole(ctx, userID, repository.RoleShopAdmin, 0)
+	require.NoError(t, err)
+	assert.True(t, granted)
+
+	granted, err = repo.GrantRole(ctx, userID, repository.RoleShopAdmin, 0)
+	require.NoError(t, err)
+	assert.False(t, granted)
+
+	roles, err = repo.GetUserRoles(ctx, userID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{repository.RoleEmployee, repository.RoleShopAdmin}, roles)
+
+	revoked, err := repo.RevokeRole(ctx, userID, repository.RoleShopAdmin)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = repo.RevokeRole(ctx, userID, repository.RoleShopAdmin)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
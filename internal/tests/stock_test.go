@@ -0,0 +1,96 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: покупка списывает остаток, закончившийся товар не продается, резерв держит товар до покупки или отмены.
+func TestMerchStock(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	adminID := createTestUser(t, repo, "shop-admin")
+	userID := createTestUser(t, repo, "buyer")
+
+	merch, err := coinService.AddMerch(ctx, adminID, uniqueName("merch"), 10)
+	require.NoError(t, err)
+	assert.False(t, merch.Stock.Valid)
+
+	merch, err = coinService.RestockMerch(ctx, adminID, merch.ID, 2)
+	require.NoError(t, err)
+	require.True(t, merch.Stock.Valid)
+	assert.Equal(t, int32(2), merch.Stock.Int32)
+
+	require.NoError(t, coinService.BuyMerch(ctx, userID, merch.ID, ""))
+
+	// Последняя единица зарезервирована - купить без резерва нельзя
+	reservationID, _, err := coinService.ReserveMerch(ctx, userID, merch.ID, "")
+	require.NoError(t, err)
+
+	err = coinService.BuyMerch(ctx, userID, merch.ID, "")
+	assert.ErrorIs(t, err, service.ErrSoldOut)
+
+	// Отмена резерва возвращает товар на склад
+	require.NoError(t, coinService.CancelReservation(ctx, userID, reservationID))
+
+	err = coinService.CancelReservation(ctx, userID, reservationID)
+	assert.ErrorIs(t, err, service.ErrReservationNotFound)
+
+	reservationID, _, err = coinService.ReserveMerch(ctx, userID, merch.ID, "")
+	require.NoError(t, err)
+	require.NoError(t, coinService.BuyReservedMerch(ctx, userID, merch.ID, reservationID))
+
+	// Резерв одноразовый
+	err = coinService.BuyReservedMerch(ctx, userID, merch.ID, reservationID)
+	assert.ErrorIs(t, err, service.ErrReservationNotFound)
+
+	merch, err = repo.GetMerch(ctx, merch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), merch.Stock.Int32)
+
+	purchases, err := repo.GetUserPurchases(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, purchases, 2)
+
+	audit, err := coinService.GetMerchAudit(ctx, merch.ID)
+	require.NoError(t, err)
+	require.Len(t, audit, 2)
+	assert.Equal(t, repository.MerchActionRestock, audit[1].Action)
+	assert.Equal(t, int32(2), audit[1].Stock.Int32)
+}
+
+// Тест: истекший резерв возвращается на склад в транзакции покупки, без фоновой очистки.
+func TestExpiredReservationReleasedOnPurchase(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	adminID := createTestUser(t, repo, "shop-admin")
+	userID := createTestUser(t, repo, "buyer")
+
+	merch, err := coinService.AddMerch(ctx, adminID, uniqueName("merch"), 10)
+	require.NoError(t, err)
+
+	merch, err = coinService.RestockMerch(ctx, adminID, merch.ID, 1)
+	require.NoError(t, err)
+
+	reservationID, err := repo.ReserveMerch(ctx, userID, merch.ID, "", time.Now().Add(-time.Second))
+	require.NoError(t, err)
+
+	require.NoError(t, coinService.BuyMerch(ctx, userID, merch.ID, ""))
+
+	err = coinService.BuyReservedMerch(ctx, userID, merch.ID, reservationID)
+	assert.ErrorIs(t, err, service.ErrReservationNotFound)
+
+	merch, err = repo.GetMerch(ctx, merch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), merch.Stock.Int32)
+}
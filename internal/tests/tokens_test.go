@@ -0,0 +1,72 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: повторное предъявление обменянного refresh-токена отзывает всю цепочку.
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, "refresh")
+
+	first, err := coinService.IssueRefreshToken(ctx, userID)
+	require.NoError(t, err)
+
+	// Обычный обмен
+	gotUserID, second, err := coinService.RefreshSession(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, userID, gotUserID)
+
+	// Украденный первый токен предъявлен повторно
+	_, _, err = coinService.RefreshSession(ctx, first)
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+
+	// Цепочка отозвана, токен законного владельца тоже не действует
+	_, _, err = coinService.RefreshSession(ctx, second)
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+}
+
+// Тест: выход со всех устройств отзывает ранее выпущенные access- и refresh-токены.
+func TestRevokeAllSessions(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, "logout")
+
+	refreshToken, err := coinService.IssueRefreshToken(ctx, userID)
+	require.NoError(t, err)
+
+	issuedAt := time.Now().Add(-time.Minute)
+
+	revoked, err := coinService.IsTokenRevoked(ctx, userID, uniqueName("jti"), issuedAt)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	require.NoError(t, coinService.RevokeAllSessions(ctx, userID))
+
+	revoked, err = coinService.IsTokenRevoked(ctx, userID, uniqueName("jti"), issuedAt)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	_, _, err = coinService.RefreshSession(ctx, refreshToken)
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+
+	// Отзыв отдельного access-токена по jti
+	jti := uniqueName("jti")
+	require.NoError(t, coinService.RevokeAccessToken(ctx, jti, time.Now().Add(time.Hour)))
+
+	revoked, err = coinService.IsTokenRevoked(ctx, userID, jti, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
@@ -0,0 +1,97 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"avito_coin/internal/repository"
+	"avito_coin/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест: товар с вариантами продается только по варианту, остаток и цена учитываются по вариантам,
+// инвентарь показывает покупки отдельно по каждому варианту.
+func TestMerchVariants(t *testing.T) {
+	repo := repository.NewRepository(newTestDB(t))
+	coinService := service.NewCoinService(repo)
+	ctx := context.Background()
+
+	adminID := createTestUser(t, repo, "shop-admin")
+	userID := createTestUser(t, repo, "buyer")
+
+	merch, err := coinService.AddMerch(ctx, adminID, uniqueName("t-shirt"), 80)
+	require.NoError(t, err)
+
+	size := "M"
+	price := int32(100)
+	stock := int32(1)
+
+	medium, err := coinService.AddMerchVariant(ctx, adminID, merch.ID, service.MerchVariantInput{
+		Sku: uniqueName("tshirt-m"), Size: &size, Price: &price, Stock: &stock,
+	})
+	require.NoError(t, err)
+
+	large, err := coinService.AddMerchVariant(ctx, adminID, merch.ID, service.MerchVariantInput{Sku: uniqueName("tshirt-l")})
+	require.NoError(t, err)
+
+	_, err = coinService.AddMerchVariant(ctx, adminID, merch.ID, service.MerchVariantInput{Sku: medium.Sku})
+	assert.ErrorIs(t, err, service.ErrVariantSkuTaken)
+
+	// Без варианта купить нельзя, вариант с последней единицей раскупается
+	err = coinService.BuyMerch(ctx, userID, merch.ID, "")
+	assert.ErrorIs(t, err, service.ErrVariantRequired)
+
+	require.NoError(t, coinService.BuyMerch(ctx, userID, merch.ID, medium.Sku))
+
+	err = coinService.BuyMerch(ctx, userID, merch.ID, medium.Sku)
+	assert.ErrorIs(t, err, service.ErrSoldOut)
+
+	// Закончившийся вариант попадает в список заканчивающихся товаров по своему артикулу
+	lowStock, err := coinService.ListLowStockMerch(ctx)
+	require.NoError(t, err)
+
+	var lowSkus []string
+
+	for _, item := range lowStock {
+		if item.ID == merch.ID {
+			lowSkus = append(lowSkus, item.Sku.String)
+			assert.Equal(t, int32(0), item.Stock)
+		}
+	}
+
+	assert.Equal(t, []string{medium.Sku}, lowSkus)
+
+	// Остаток варианта без учета не заканчивается, снятый с продажи вариант не продается
+	require.NoError(t, coinService.BuyMerch(ctx, userID, merch.ID, large.Sku))
+	require.NoError(t, coinService.BuyMerch(ctx, userID, merch.ID, large.Sku))
+
+	inactive := false
+	_, err = coinService.UpdateMerchVariant(ctx, adminID, merch.ID, large.Sku, service.VariantUpdate{Active: &inactive})
+	require.NoError(t, err)
+
+	err = coinService.BuyMerch(ctx, userID, merch.ID, large.Sku)
+	assert.ErrorIs(t, err, service.ErrVariantNotFound)
+
+	// Цена варианта списывается вместо цены товара
+	balance, err := repo.GetUserBalance(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1000-100-80-80), balance)
+
+	info, err := coinService.GetUserPurchases(ctx, userID)
+	require.NoError(t, err)
+
+	quantities := make(map[string]int)
+	for _, item := range *info.Inventory {
+		require.NotNil(t, item.Variant)
+		quantities[*item.Variant] = *item.Quantity
+	}
+
+	assert.Equal(t, map[string]int{medium.Sku: 1, large.Sku: 2}, quantities)
+
+	audit, err := coinService.GetMerchAudit(ctx, merch.ID)
+	require.NoError(t, err)
+	require.Len(t, audit, 4)
+	assert.Equal(t, repository.MerchActionDeactivate, audit[3].Action)
+	assert.Equal(t, large.Sku, audit[3].Variant.String)
+}
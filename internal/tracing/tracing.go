@@ -0,0 +1,100 @@
+// Package tracing - трейсы OpenTelemetry: экспорт спанов, распространение контекста W3C Trace Context
+// и идентификаторы трейса в записях логов.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"avito_coin/internal/config"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Экспортеры трейсов.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Setup - глобальные провайдер трейсов и пропагатор W3C Trace Context. Возвращает функцию, которая
+// отправляет накопленные спаны и останавливает экспорт. Без экспортера спаны не записываются,
+// но контекст входящих запросов все равно распространяется и попадает в логи.
+func Setup(ctx context.Context, cfg config.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil || exporter == nil {
+		return func(context.Context) error { return nil }, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(cfg.TracingServiceName)),
+	)
+	if err != nil {
+		return func(context.Context) error { return nil }, fmt.Errorf("error creating trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// newExporter - экспортер из конфигурации; nil, если трейсы не экспортируются.
+func newExporter(ctx context.Context, cfg config.Config) (sdktrace.SpanExporter, error) {
+	switch cfg.TracingExporter {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterStdout:
+		return stdouttrace.New()
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.TracingOTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.TracingOTLPEndpoint))
+		}
+
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.TracingExporter)
+	}
+}
+
+// LogHook - хук logrus, который добавляет в записи с контекстом (logrus.WithContext)
+// идентификаторы трейса и спана.
+type LogHook struct{}
+
+// Levels - хук срабатывает на всех уровнях.
+func (LogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire - trace_id и span_id из контекста записи.
+func (LogHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if !spanContext.IsValid() {
+		return nil
+	}
+
+	entry.Data["trace_id"] = spanContext.TraceID().String()
+	entry.Data["span_id"] = spanContext.SpanID().String()
+
+	return nil
+}
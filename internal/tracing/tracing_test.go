@@ -0,0 +1,34 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"avito_coin/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Тест: записи с контекстом спана получают trace_id и span_id, остальные записи не меняются.
+func TestLogHook(t *testing.T) {
+	var output bytes.Buffer
+
+	logger := logrus.New()
+	logger.SetOutput(&output)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(tracing.LogHook{})
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	logger.WithContext(ctx).Info("traced")
+	assert.Contains(t, output.String(), `"trace_id":"`+span.SpanContext().TraceID().String()+`"`)
+	assert.Contains(t, output.String(), `"span_id":"`+span.SpanContext().SpanID().String()+`"`)
+
+	output.Reset()
+	logger.WithContext(context.Background()).Info("untraced")
+	logger.Info("without context")
+	assert.NotContains(t, output.String(), "trace_id")
+}